@@ -0,0 +1,60 @@
+package sdk
+
+import "fmt"
+
+// AmendResult reports how AmendOrder applied the change.
+type AmendResult struct {
+	// Replaced is set when the modify was rejected and the order was
+	// canceled and placed again instead.
+	Replaced bool
+	// ModifyStatus is the status of the modify attempt.
+	ModifyStatus any
+	// CancelStatus is the status of the fallback cancel, nil if not attempted.
+	CancelStatus any
+	// Status is the status of the order as it stands after the amend: the
+	// modify status, or the status of the replacement order.
+	Status any
+}
+
+// AmendOrder modifies an order and falls back to cancel-and-replace when the
+// exchange rejects the modify, e.g. because the order is already filled or
+// no longer exists. The replacement is only placed once the cancel succeeded
+// or found the order gone; any other cancel error is returned with the
+// original order possibly still resting.
+// The replacement uses req.OrderRequest as is, including its Cloid.
+func (e *Exchange) AmendOrder(req ModifyRequest, builder *BuilderInfo) (*AmendResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	status, err := e.ModifyOrder(req)
+	if err != nil {
+		return nil, err
+	}
+	result := &AmendResult{
+		ModifyStatus: status,
+		Status:       status,
+	}
+	if _, rejected := status.(error); !rejected {
+		return result, nil
+	}
+
+	coin := req.OrderRequest.Coin
+	if req.Cloid != nil {
+		result.CancelStatus, err = e.CancelByCloid(CancelByCloidRequest{Coin: coin, Cloid: *req.Cloid})
+	} else {
+		result.CancelStatus, err = e.Cancel(CancelRequest{Coin: coin, Oid: req.Oid})
+	}
+	if err != nil {
+		return result, err
+	}
+	if cancelErr, ok := result.CancelStatus.(error); ok && !IsOrderGone(cancelErr) {
+		return result, fmt.Errorf("cancel before replace: %w", cancelErr)
+	}
+
+	result.Status, err = e.Order(req.OrderRequest, builder)
+	if err != nil {
+		return result, err
+	}
+	result.Replaced = true
+	return result, nil
+}
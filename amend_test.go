@@ -0,0 +1,89 @@
+package sdk_test
+
+import (
+	"testing"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+func TestAmendOrder(t *testing.T) {
+	srv, signer := newServer(t, hltest.Config{})
+	srv.SetBook("ETH", []sdk.Level{{Px: 2_999, Sz: 10}}, []sdk.Level{{Px: 3_001, Sz: 10}})
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+	order := sdk.OrderRequest{
+		Coin: "ETH", IsBuy: true, Size: 0.1, LimitPx: 2_000,
+		OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifAlo}},
+	}
+	placed := func() int {
+		n := 0
+		for _, req := range srv.Requests() {
+			if req.Type == "order" {
+				n++
+			}
+		}
+		return n
+	}
+
+	t.Run("modifies a resting order", func(t *testing.T) {
+		status, err := exchange.Order(order, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resting, ok := status.(*sdk.ExchangeRestingOrder)
+		if !ok {
+			t.Fatalf("Expected a resting order, got %#v", status)
+		}
+		moved := order
+		moved.LimitPx = 2_100
+		result, err := exchange.AmendOrder(sdk.NewModifyByOid(resting.Oid, moved), nil)
+		if err != nil {
+			t.Fatalf("AmendOrder failed: %v", err)
+		}
+		if result.Replaced || result.CancelStatus != nil {
+			t.Errorf("Expected a plain modify, got %+v", result)
+		}
+	})
+
+	t.Run("replaces a gone order", func(t *testing.T) {
+		before := placed()
+		result, err := exchange.AmendOrder(sdk.NewModifyByOid(999_999, order), nil)
+		if err != nil {
+			t.Fatalf("AmendOrder failed: %v", err)
+		}
+		if !result.Replaced {
+			t.Fatalf("Expected the order to be replaced, got %+v", result)
+		}
+		if _, ok := result.Status.(*sdk.ExchangeRestingOrder); !ok {
+			t.Errorf("Expected a resting replacement, got %#v", result.Status)
+		}
+		if n := placed() - before; n != 1 {
+			t.Errorf("Expected one replacement order, got %d", n)
+		}
+	})
+
+	t.Run("keeps the order when the cancel fails", func(t *testing.T) {
+		statusError := func(msg string) hltest.Response {
+			return hltest.Response{Body: map[string]any{
+				"status": "ok",
+				"response": map[string]any{
+					"type": "default",
+					"data": map[string]any{"statuses": []any{map[string]any{"error": msg}}},
+				},
+			}}
+		}
+		srv.Script("/exchange", "batchModify", statusError("Invalid price"))
+		srv.Script("/exchange", "cancel", statusError("Too many cancels"))
+		before := placed()
+		result, err := exchange.AmendOrder(sdk.NewModifyByOid(1, order), nil)
+		if err == nil {
+			t.Fatal("Expected the cancel error")
+		}
+		if result == nil || result.Replaced || result.CancelStatus == nil {
+			t.Errorf("Expected no replacement after the failed cancel, got %+v", result)
+		}
+		if n := placed() - before; n != 0 {
+			t.Errorf("Expected no replacement order, got %d", n)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package sdk
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type BatcherConfig struct {
+	// Window is how long the first call of a batch waits for others to join.
+	Window time.Duration
+	// MaxBatchSize flushes a batch early once it holds this many calls.
+	MaxBatchSize int
+	// Builder is attached to every batched order action.
+	Builder *BuilderInfo
+}
+
+// OrderBatcher coalesces concurrent Order, Cancel and ModifyOrder calls made
+// within a short window into single BulkOrders, BulkCancel and
+// BulkModifyOrders actions, so that every call does not pay for its own
+// signed request. Each caller blocks until its batch is sent and receives
+// its own status, with the same possible types as the Exchange methods.
+// Orders and modifies pass the exchange's RiskChecker one call at a time,
+// before they join a batch, so a rejected call fails no other.
+type OrderBatcher struct {
+	exchange *Exchange
+	orders   *batchQueue[OrderRequest]
+	cancels  *batchQueue[CancelRequest]
+	modifies *batchQueue[ModifyRequest]
+}
+
+func NewOrderBatcher(exchange *Exchange, cfg BatcherConfig) *OrderBatcher {
+	if cfg.Window <= 0 {
+		cfg.Window = 5 * time.Millisecond
+	}
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = MaxBatchSize
+	}
+	return &OrderBatcher{
+		exchange: exchange,
+		orders: newBatchQueue(cfg, func(reqs []OrderRequest) ([]any, error) {
+			return exchange.postOrders(reqs, GroupingNa, cfg.Builder)
+		}),
+		cancels:  newBatchQueue(cfg, exchange.BulkCancel),
+		modifies: newBatchQueue(cfg, exchange.postModifies),
+	}
+}
+
+func (b *OrderBatcher) Order(req OrderRequest) (any, error) {
+	if err := b.checkCoin(req.Coin); err != nil {
+		return nil, err
+	}
+	if risk := b.exchange.risk; risk != nil {
+		if err := risk.CheckOrders([]OrderRequest{req}); err != nil {
+			return nil, err
+		}
+	}
+	return b.orders.submit(req)
+}
+
+func (b *OrderBatcher) Cancel(req CancelRequest) (any, error) {
+	if err := b.checkCoin(req.Coin); err != nil {
+		return nil, err
+	}
+	return b.cancels.submit(req)
+}
+
+func (b *OrderBatcher) ModifyOrder(req ModifyRequest) (any, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := b.checkCoin(req.OrderRequest.Coin); err != nil {
+		return nil, err
+	}
+	if risk := b.exchange.risk; risk != nil {
+		if err := risk.CheckModifies([]ModifyRequest{req}); err != nil {
+			return nil, err
+		}
+	}
+	return b.modifies.submit(req)
+}
+
+// Flush sends all pending calls without waiting for their windows to end.
+func (b *OrderBatcher) Flush() {
+	b.orders.flushPending()
+	b.cancels.flushPending()
+	b.modifies.flushPending()
+}
+
+// checkCoin rejects a call up front so that it cannot fail the whole batch
+// it would have joined.
+func (b *OrderBatcher) checkCoin(coin string) error {
+	if _, exist := b.exchange.coinToAsset[coin]; !exist {
+		return fmt.Errorf("coin %s does not exist", coin)
+	}
+	return nil
+}
+
+type batchResult struct {
+	status any
+	err    error
+}
+
+type batchItem[T any] struct {
+	req    T
+	result chan batchResult
+}
+
+type batchQueue[T any] struct {
+	window  time.Duration
+	maxSize int
+	send    func([]T) ([]any, error)
+
+	mu      sync.Mutex
+	pending []batchItem[T]
+	timer   *time.Timer
+}
+
+func newBatchQueue[T any](cfg BatcherConfig, send func([]T) ([]any, error)) *batchQueue[T] {
+	return &batchQueue[T]{
+		window:  cfg.Window,
+		maxSize: cfg.MaxBatchSize,
+		send:    send,
+	}
+}
+
+func (q *batchQueue[T]) submit(req T) (any, error) {
+	item := batchItem[T]{req: req, result: make(chan batchResult, 1)}
+
+	q.mu.Lock()
+	q.pending = append(q.pending, item)
+	if len(q.pending) >= q.maxSize {
+		batch := q.takeLocked()
+		q.mu.Unlock()
+		q.flush(batch)
+	} else {
+		if q.timer == nil {
+			q.timer = time.AfterFunc(q.window, q.flushPending)
+		}
+		q.mu.Unlock()
+	}
+
+	res := <-item.result
+	return res.status, res.err
+}
+
+func (q *batchQueue[T]) flushPending() {
+	q.mu.Lock()
+	batch := q.takeLocked()
+	q.mu.Unlock()
+	q.flush(batch)
+}
+
+func (q *batchQueue[T]) takeLocked() []batchItem[T] {
+	if q.timer != nil {
+		q.timer.Stop()
+		q.timer = nil
+	}
+	batch := q.pending
+	q.pending = nil
+	return batch
+}
+
+func (q *batchQueue[T]) flush(batch []batchItem[T]) {
+	if len(batch) == 0 {
+		return
+	}
+	reqs := make([]T, len(batch))
+	for i, item := range batch {
+		reqs[i] = item.req
+	}
+	statuses, err := q.send(reqs)
+	for i, item := range batch {
+		if err != nil {
+			item.result <- batchResult{err: err}
+			continue
+		}
+		item.result <- batchResult{status: StatusAt(statuses, i)}
+	}
+}
@@ -0,0 +1,106 @@
+package sdk_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+func TestOrderBatcher(t *testing.T) {
+	srv, signer := newServer(t, hltest.Config{})
+	srv.SetBook("SOL", []sdk.Level{{Px: 149, Sz: 100}}, []sdk.Level{{Px: 151, Sz: 100}})
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+	batcher := sdk.NewOrderBatcher(exchange, sdk.BatcherConfig{Window: 50 * time.Millisecond})
+
+	if _, err := batcher.Order(sdk.OrderRequest{Coin: "NOPE", Size: 1, LimitPx: 1}); err == nil {
+		t.Error("Expected an unknown coin to be rejected")
+	}
+
+	const n = 3
+	oids := make(chan uint64, n)
+	wg := new(sync.WaitGroup)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(px float64) {
+			defer wg.Done()
+			result, err := batcher.Order(sdk.OrderRequest{
+				Coin:      "SOL",
+				IsBuy:     true,
+				Size:      1,
+				LimitPx:   px,
+				OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifAlo}},
+			})
+			if err != nil {
+				t.Errorf("Batched order failed: %v", err)
+				return
+			}
+			resting, ok := result.(*sdk.ExchangeRestingOrder)
+			if !ok {
+				t.Errorf("Expected resting order, got %#v", result)
+				return
+			}
+			oids <- resting.Oid
+		}(140 + float64(i))
+	}
+	wg.Wait()
+	close(oids)
+
+	for oid := range oids {
+		wg.Add(1)
+		go func(oid uint64) {
+			defer wg.Done()
+			if _, err := batcher.Cancel(sdk.CancelRequest{Coin: "SOL", Oid: oid}); err != nil {
+				t.Errorf("Batched cancel failed: %v", err)
+			}
+		}(oid)
+	}
+	wg.Wait()
+
+	actions := make(map[string]int)
+	for _, req := range srv.Requests() {
+		if req.Path == "/exchange" {
+			actions[req.Type]++
+		}
+	}
+	if actions["order"] != 1 || actions["cancel"] != 1 {
+		t.Errorf("Expected one order and one cancel action, got %v", actions)
+	}
+}
+
+func TestOrderBatcherRiskPerCall(t *testing.T) {
+	srv, signer := newServer(t, hltest.Config{})
+	srv.SetBook("SOL", []sdk.Level{{Px: 149, Sz: 100}}, []sdk.Level{{Px: 151, Sz: 100}})
+	risk := sdk.NewRiskManager(sdk.RiskConfig{Limits: sdk.RiskLimits{MaxOrderNotional: 500}})
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer, sdk.WithRiskChecker(risk))
+	batcher := sdk.NewOrderBatcher(exchange, sdk.BatcherConfig{Window: 50 * time.Millisecond})
+
+	sizes := []float64{1, 10, 2} // the second is over the notional limit
+	errs := make([]error, len(sizes))
+	wg := new(sync.WaitGroup)
+	for i, size := range sizes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = batcher.Order(sdk.OrderRequest{
+				Coin:      "SOL",
+				IsBuy:     true,
+				Size:      size,
+				LimitPx:   140,
+				OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifAlo}},
+			})
+		}()
+	}
+	wg.Wait()
+
+	var riskErr sdk.RiskError
+	if !errors.As(errs[1], &riskErr) || riskErr.Rule != "maxOrderNotional" {
+		t.Errorf("Expected the oversized order to fail its risk check, got %v", errs[1])
+	}
+	if errs[0] != nil || errs[2] != nil {
+		t.Errorf("Expected the other orders to be placed, got %v and %v", errs[0], errs[2])
+	}
+}
@@ -0,0 +1,216 @@
+package sdk
+
+import (
+	"fmt"
+)
+
+// BracketRequest describes an entry order with optional take-profit and
+// stop-loss children that are placed atomically using normalTpsl grouping.
+type BracketRequest struct {
+	Coin    string
+	IsBuy   bool
+	Size    float64
+	LimitPx float64
+	// OrderType of the entry leg, defaults to a GTC limit order.
+	OrderType OrderType
+	Cloid     *Cloid
+
+	TakeProfitPx float64 // zero skips the take-profit leg
+	StopLossPx   float64 // zero skips the stop-loss leg
+	// TriggerIsMarket makes the children execute as market orders once triggered.
+	TriggerIsMarket bool
+}
+
+// PositionTpslRequest describes take-profit and stop-loss orders attached to
+// an existing position using positionTpsl grouping.
+type PositionTpslRequest struct {
+	Coin   string
+	IsLong bool // side of the position being protected
+	Size   float64
+
+	TakeProfitPx    float64 // zero skips the take-profit leg
+	StopLossPx      float64 // zero skips the stop-loss leg
+	TriggerIsMarket bool
+	// ReferencePx is optional, e.g. the current mark price. When set the
+	// trigger prices are validated against it.
+	ReferencePx float64
+}
+
+// BracketResult holds the status of each leg. Each field has the same
+// possible types as the results of BulkOrders and is nil if the leg was not
+// requested.
+type BracketResult struct {
+	Entry      any
+	TakeProfit any
+	StopLoss   any
+}
+
+func (req *BracketRequest) Validate() error {
+	if req.Size <= 0 {
+		return ValidationError{Field: "Size", Message: "must be positive"}
+	}
+	if req.LimitPx <= 0 {
+		return ValidationError{Field: "LimitPx", Message: "must be positive"}
+	}
+	if req.TakeProfitPx == 0 && req.StopLossPx == 0 {
+		return ValidationError{Field: "TakeProfitPx", Message: "at least one of take-profit or stop-loss is required"}
+	}
+	return validateTriggerDirection(req.IsBuy, req.LimitPx, req.TakeProfitPx, req.StopLossPx)
+}
+
+func (req *PositionTpslRequest) Validate() error {
+	if req.Size <= 0 {
+		return ValidationError{Field: "Size", Message: "must be positive"}
+	}
+	if req.TakeProfitPx == 0 && req.StopLossPx == 0 {
+		return ValidationError{Field: "TakeProfitPx", Message: "at least one of take-profit or stop-loss is required"}
+	}
+	if req.ReferencePx > 0 {
+		return validateTriggerDirection(req.IsLong, req.ReferencePx, req.TakeProfitPx, req.StopLossPx)
+	}
+	if req.TakeProfitPx > 0 && req.StopLossPx > 0 {
+		if req.IsLong && req.TakeProfitPx <= req.StopLossPx {
+			return ValidationError{Field: "TakeProfitPx", Message: "must be above stop-loss for a long position"}
+		}
+		if !req.IsLong && req.TakeProfitPx >= req.StopLossPx {
+			return ValidationError{Field: "TakeProfitPx", Message: "must be below stop-loss for a short position"}
+		}
+	}
+	return nil
+}
+
+// validateTriggerDirection checks that the take-profit sits on the profitable
+// side of px and the stop-loss on the losing side for the given direction.
+func validateTriggerDirection(isBuy bool, px, tp, sl float64) error {
+	if isBuy {
+		if tp > 0 && tp <= px {
+			return ValidationError{Field: "TakeProfitPx", Message: fmt.Sprintf("must be above %v for a buy", px)}
+		}
+		if sl > 0 && sl >= px {
+			return ValidationError{Field: "StopLossPx", Message: fmt.Sprintf("must be below %v for a buy", px)}
+		}
+		return nil
+	}
+	if tp > 0 && tp >= px {
+		return ValidationError{Field: "TakeProfitPx", Message: fmt.Sprintf("must be below %v for a sell", px)}
+	}
+	if sl > 0 && sl <= px {
+		return ValidationError{Field: "StopLossPx", Message: fmt.Sprintf("must be above %v for a sell", px)}
+	}
+	return nil
+}
+
+// BracketOrder places an entry order together with its take-profit and
+// stop-loss children. The children are reduce-only trigger orders on the
+// opposite side that only become active once the entry fills.
+func (e *Exchange) BracketOrder(req BracketRequest, builder *BuilderInfo) (*BracketResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	entry := OrderRequest{
+		Coin:      req.Coin,
+		IsBuy:     req.IsBuy,
+		Size:      req.Size,
+		LimitPx:   req.LimitPx,
+		OrderType: req.OrderType,
+		Cloid:     req.Cloid,
+	}
+	if entry.OrderType.Limit == nil && entry.OrderType.Trigger == nil {
+		entry.OrderType.Limit = &LimitOrderType{Tif: TifGtc}
+	}
+
+	orders := []OrderRequest{entry}
+	tpIdx, slIdx, err := e.appendTpslLegs(&orders, req.Coin, !req.IsBuy, req.Size, req.TakeProfitPx, req.StopLossPx, req.TriggerIsMarket)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, err := e.BulkOrdersWithGrouping(orders, GroupingNormalTpsl, builder)
+	if err != nil {
+		return nil, err
+	}
+	return &BracketResult{
+		Entry:      StatusAt(statuses, 0),
+		TakeProfit: StatusAt(statuses, tpIdx),
+		StopLoss:   StatusAt(statuses, slIdx),
+	}, nil
+}
+
+// PositionTpsl attaches take-profit and stop-loss orders to an open position.
+// The returned result never has an entry leg.
+func (e *Exchange) PositionTpsl(req PositionTpslRequest, builder *BuilderInfo) (*BracketResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	orders := make([]OrderRequest, 0, 2)
+	tpIdx, slIdx, err := e.appendTpslLegs(&orders, req.Coin, !req.IsLong, req.Size, req.TakeProfitPx, req.StopLossPx, req.TriggerIsMarket)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, err := e.BulkOrdersWithGrouping(orders, GroupingPositionTpsl, builder)
+	if err != nil {
+		return nil, err
+	}
+	return &BracketResult{
+		TakeProfit: StatusAt(statuses, tpIdx),
+		StopLoss:   StatusAt(statuses, slIdx),
+	}, nil
+}
+
+// appendTpslLegs appends the trigger legs and returns their indices, -1 for
+// a leg that was skipped.
+func (e *Exchange) appendTpslLegs(
+	orders *[]OrderRequest,
+	coin string,
+	isBuy bool,
+	size, tp, sl float64,
+	isMarket bool,
+) (int, int, error) {
+	asset, exist := e.coinToAsset[coin]
+	if !exist {
+		return -1, -1, fmt.Errorf("coin %s does not exist", coin)
+	}
+	assetDec := e.assetToDecimal[asset]
+
+	tpIdx, slIdx := -1, -1
+	if tp > 0 {
+		tpIdx = len(*orders)
+		*orders = append(*orders, triggerLeg(coin, isBuy, size, adjustPrice(tp, asset, assetDec), isMarket, TakeProfit))
+	}
+	if sl > 0 {
+		slIdx = len(*orders)
+		*orders = append(*orders, triggerLeg(coin, isBuy, size, adjustPrice(sl, asset, assetDec), isMarket, StopLose))
+	}
+	return tpIdx, slIdx, nil
+}
+
+func triggerLeg(coin string, isBuy bool, size, triggerPx float64, isMarket bool, tpsl string) OrderRequest {
+	return OrderRequest{
+		Coin:    coin,
+		IsBuy:   isBuy,
+		Size:    size,
+		LimitPx: triggerPx,
+		OrderType: OrderType{
+			Trigger: &TriggerOrderType{
+				TriggerPx: FloatToString(triggerPx),
+				IsMarket:  isMarket,
+				Tpsl:      tpsl,
+			},
+		},
+		ReduceOnly: true,
+	}
+}
+
+// StatusAt returns the status of the order at idx of a bulk action: nil for
+// a negative idx, and an error if the response has no status for it.
+func StatusAt(statuses []any, idx int) any {
+	if idx < 0 {
+		return nil
+	}
+	if idx >= len(statuses) {
+		return fmt.Errorf("missing status for order %d", idx)
+	}
+	return statuses[idx]
+}
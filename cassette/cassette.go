@@ -0,0 +1,182 @@
+// Package cassette records the HTTP traffic of the SDK clients to a JSONL
+// file and replays it offline, so tests against captured /info and /exchange
+// responses run without network access:
+//
+//	rec, err := cassette.NewRecorder("testdata/orders.jsonl", cassette.Config{})
+//	exchange := sdk.NewExchange(sdk.TestnetAPIURL, nil, meta, signer, sdk.WithTransport(rec))
+//	...
+//	rec.Close()
+//
+//	rep, err := cassette.NewReplayer("testdata/orders.jsonl", cassette.Config{Match: cassette.MatchLenient})
+//	exchange := sdk.NewExchange(sdk.TestnetAPIURL, nil, meta, signer, sdk.WithTransport(rep))
+//
+// Each line of a cassette is an Entry. Headers are not recorded, and the
+// JSON fields named in Config.Redact are replaced by Redacted in both request
+// and response bodies, so signatures never end up in a checked-in file.
+package cassette
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Redacted replaces the values of redacted fields.
+const Redacted = "REDACTED"
+
+var (
+	// DefaultRedact are the fields redacted when Config.Redact is nil. A
+	// recorded signature could be replayed against the exchange while its
+	// nonce is still valid.
+	DefaultRedact = []string{"signature"}
+
+	// DefaultVolatile are the fields ignored by MatchLenient: nonces and
+	// timestamps that differ between a recording and a replay.
+	DefaultVolatile = []string{"nonce", "time", "startTime", "endTime", "expiresAfter"}
+)
+
+// Entry is a recorded request and its response.
+type Entry struct {
+	Method   string          `json:"method"`
+	Path     string          `json:"path"`
+	Request  json.RawMessage `json:"request,omitempty"`
+	Status   int             `json:"status"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Time     time.Time       `json:"time"`
+}
+
+// Config configures a Recorder or Replayer.
+type Config struct {
+	// Redact are the JSON field names redacted at any depth. Defaults to
+	// DefaultRedact.
+	Redact []string
+	// Match decides whether a recorded request body matches an actual one,
+	// both already redacted. Defaults to MatchStrict. Only used by Replayer.
+	Match Matcher
+	// Transport performs the requests of a Recorder. Defaults to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+func (c *Config) redactSet() map[string]bool {
+	fields := c.Redact
+	if fields == nil {
+		fields = DefaultRedact
+	}
+	set := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		set[f] = true
+	}
+	return set
+}
+
+// Matcher reports whether a recorded request body matches an actual one.
+type Matcher func(recorded, actual json.RawMessage) bool
+
+var (
+	// MatchStrict requires equal bodies, up to key order and whitespace.
+	// Exchange actions only match if their nonces are deterministic.
+	MatchStrict Matcher = Lenient()
+
+	// MatchLenient ignores the DefaultVolatile fields.
+	MatchLenient Matcher = Lenient(DefaultVolatile...)
+)
+
+// Lenient returns a Matcher that ignores the given fields at any depth.
+func Lenient(ignore ...string) Matcher {
+	set := make(map[string]bool, len(ignore))
+	for _, f := range ignore {
+		set[f] = true
+	}
+	return func(recorded, actual json.RawMessage) bool {
+		a, err := canonical(recorded, set, "")
+		if err != nil {
+			return false
+		}
+		b, err := canonical(actual, set, "")
+		if err != nil {
+			return false
+		}
+		return bytes.Equal(a, b)
+	}
+}
+
+// canonical re-encodes a JSON body with sorted keys, dropping the given
+// fields, or replacing their values by replace if it is set. Bodies that are
+// not JSON are encoded as a string.
+func canonical(body []byte, fields map[string]bool, replace string) (json.RawMessage, error) {
+	if len(body) == 0 {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return json.Marshal(string(body))
+	}
+	return json.Marshal(rewrite(v, fields, replace))
+}
+
+func rewrite(v any, fields map[string]bool, replace string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			switch {
+			case !fields[k]:
+				v[k] = rewrite(child, fields, replace)
+			case replace == "":
+				delete(v, k)
+			default:
+				v[k] = replace
+			}
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = rewrite(child, fields, replace)
+		}
+	}
+	return v
+}
+
+// Load reads the entries of a cassette.
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// readBody reads and restores the body of a request or response.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
@@ -0,0 +1,101 @@
+package cassette_test
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/cassette"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+func TestRecordReplay(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := sdk.NewLocalSignerFromHex(hex.EncodeToString(crypto.FromECDSA(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := hltest.NewServer(hltest.Config{})
+	srv.Fund(signer.Address(), 10_000)
+	srv.SetBook("BTC", []sdk.Level{{Px: 99_000, Sz: 1}}, []sdk.Level{{Px: 100_000, Sz: 1}})
+	meta := srv.Meta()
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	order := sdk.OrderRequest{
+		Coin: "BTC", IsBuy: true, Size: 0.01, LimitPx: 98_000,
+		OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifGtc}},
+	}
+
+	// session runs the same calls against a live or replayed transport.
+	session := func(info *sdk.Info, exchange *sdk.Exchange) {
+		t.Helper()
+		if _, err := exchange.Order(order, nil); err != nil {
+			t.Fatalf("Failed to place order: %v", err)
+		}
+		orders, err := info.OpenOrders(signer.Address().Hex())
+		if err != nil || len(orders) != 1 || orders[0].LimitPx != 98_000 {
+			t.Fatalf("Unexpected open orders %+v, %v", orders, err)
+		}
+	}
+
+	rec, err := cassette.NewRecorder(path, cassette.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := sdk.NewInfo(srv.URL, sdk.WithInfoTransport(rec))
+	if err != nil {
+		t.Fatalf("Failed to create info: %v", err)
+	}
+	session(info, sdk.NewExchange(srv.URL, nil, meta, signer, sdk.WithTransport(rec)))
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sent := len(srv.Requests())
+	if _, err := sdk.NewExchange(srv.URL, nil, meta, signer, sdk.WithTransport(rec)).Order(order, nil); err == nil {
+		t.Error("Expected a closed recorder to fail the order")
+	}
+	if n := len(srv.Requests()); n != sent {
+		t.Errorf("Expected a closed recorder not to send the order, %d requests sent", n-sent)
+	}
+	srv.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"signature":"REDACTED"`) {
+		t.Errorf("Signature was not redacted:\n%s", data)
+	}
+
+	// offline: the server is gone, and the new nonce only matches leniently
+	rep, err := cassette.NewReplayer(path, cassette.Config{Match: cassette.MatchLenient})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err = sdk.NewInfo(srv.URL, sdk.WithInfoTransport(rep))
+	if err != nil {
+		t.Fatalf("Failed to replay info: %v", err)
+	}
+	session(info, sdk.NewExchange(srv.URL, nil, meta, signer, sdk.WithTransport(rep)))
+	if n := rep.Remaining(); n != 0 {
+		t.Errorf("Expected every entry to be replayed, %d left", n)
+	}
+
+	strict, err := cassette.NewReplayer(path, cassette.Config{Match: cassette.MatchStrict})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sdk.NewInfo(srv.URL, sdk.WithInfoTransport(strict)); err != nil {
+		t.Fatalf("Info requests should match strictly: %v", err)
+	}
+	exchange := sdk.NewExchange(srv.URL, nil, meta, signer, sdk.WithTransport(strict))
+	if _, err := exchange.Order(order, nil); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("Expected a strict mismatch on the nonce, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package cassette
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+var errRecorderClosed = errors.New("cassette: recorder closed")
+
+// Recorder is an http.RoundTripper that performs requests with the
+// configured transport and appends each exchange to a cassette.
+type Recorder struct {
+	next   http.RoundTripper
+	redact map[string]bool
+
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// NewRecorder creates the cassette at path, truncating an existing one.
+func NewRecorder(path string, cfg Config) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	next := cfg.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{next: next, redact: cfg.redactSet(), f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a closed recorder must not send requests it cannot record
+	r.mu.Lock()
+	closed := r.f == nil
+	r.mu.Unlock()
+	if closed {
+		return nil, errRecorderClosed
+	}
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	e := Entry{Method: req.Method, Path: req.URL.Path, Status: resp.StatusCode, Time: time.Now().UTC()}
+	if e.Request, err = canonical(reqBody, r.redact, Redacted); err != nil {
+		return nil, err
+	}
+	if e.Response, err = canonical(respBody, r.redact, Redacted); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		// closed while the request was in flight
+		return nil, errRecorderClosed
+	}
+	if err := r.enc.Encode(e); err != nil {
+		return nil, fmt.Errorf("cassette: failed to write entry: %w", err)
+	}
+	return resp, nil
+}
+
+// Close closes the cassette. Requests made afterwards fail.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
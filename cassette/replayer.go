@@ -0,0 +1,85 @@
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Replayer is an http.RoundTripper that answers requests from a cassette
+// without network access. Each entry is served once, and a request is
+// answered by the first unused entry with the same method and path whose
+// body matches.
+type Replayer struct {
+	match  Matcher
+	redact map[string]bool
+
+	mu      sync.Mutex
+	entries []Entry
+	used    []bool
+}
+
+// NewReplayer loads the cassette at path.
+func NewReplayer(path string, cfg Config) (*Replayer, error) {
+	entries, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	match := cfg.Match
+	if match == nil {
+		match = MatchStrict
+	}
+	return &Replayer{
+		match:   match,
+		redact:  cfg.redactSet(),
+		entries: entries,
+		used:    make([]bool, len(entries)),
+	}, nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := canonical(body, r.redact, Redacted)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, e := range r.entries {
+		if r.used[i] || e.Method != req.Method || e.Path != req.URL.Path || !r.match(e.Request, actual) {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+			StatusCode:    e.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json"}},
+			Body:          io.NopCloser(bytes.NewReader(e.Response)),
+			ContentLength: int64(len(e.Response)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("cassette: no recorded response for %s %s %s", req.Method, req.URL.Path, actual)
+}
+
+// Remaining returns the number of entries not replayed yet.
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,108 @@
+package sdk
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock tells the time used for nonces.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ManualClock is a Clock that only moves when told to, for tests.
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *ManualClock) Set(now time.Time) {
+	c.mu.Lock()
+	c.now = now
+	c.mu.Unlock()
+}
+
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// NonceSource hands out the nonces of signed actions. Nonces must be
+// unique per signer and close to the current time in milliseconds. An error
+// fails the action that needed the nonce.
+type NonceSource interface {
+	NextNonce() (uint64, error)
+}
+
+// maxNonceLag is how far a TimestampNonceSource may fall behind its clock
+// before it jumps forward.
+const maxNonceLag = 300_000
+
+// TimestampNonceSource counts up from the time in milliseconds, so nonces
+// stay unique when several are needed within a millisecond. It is the
+// default of Exchange.
+type TimestampNonceSource struct {
+	clock Clock
+	nonce atomic.Uint64
+}
+
+func NewTimestampNonceSource(clock Clock) *TimestampNonceSource {
+	s := &TimestampNonceSource{clock: clock}
+	s.nonce.Store(timestamp(clock))
+	return s
+}
+
+func (s *TimestampNonceSource) NextNonce() (uint64, error) {
+	return s.next(), nil
+}
+
+func (s *TimestampNonceSource) next() uint64 {
+	nonce := s.nonce.Add(1)
+	now := timestamp(s.clock)
+	// more than 300 seconds behind
+	if nonce+maxNonceLag < now {
+		s.nonce.Swap(now)
+	}
+	return nonce
+}
+
+// SequenceNonceSource returns start+1, start+2, ... regardless of the time,
+// which makes signed payloads reproducible in tests.
+type SequenceNonceSource struct {
+	nonce atomic.Uint64
+}
+
+func NewSequenceNonceSource(start uint64) *SequenceNonceSource {
+	s := &SequenceNonceSource{}
+	s.nonce.Store(start)
+	return s
+}
+
+func (s *SequenceNonceSource) NextNonce() (uint64, error) {
+	return s.nonce.Add(1), nil
+}
+
+func timestamp(clock Clock) uint64 {
+	return uint64(clock.Now().UnixMilli())
+}
@@ -0,0 +1,60 @@
+package sdk_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+func nextNonce(t *testing.T, nonces sdk.NonceSource) uint64 {
+	t.Helper()
+	nonce, err := nonces.NextNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nonce
+}
+
+func TestTimestampNonceSource(t *testing.T) {
+	start := time.UnixMilli(1_700_000_000_000)
+	clock := sdk.NewManualClock(start)
+	nonces := sdk.NewTimestampNonceSource(clock)
+	if a, b := nextNonce(t, nonces), nextNonce(t, nonces); a != 1_700_000_000_001 || b != a+1 {
+		t.Errorf("Expected nonces counting up from the clock, got %d, %d", a, b)
+	}
+
+	clock.Advance(10 * time.Minute)
+	nextNonce(t, nonces)
+	if nonce, now := nextNonce(t, nonces), uint64(clock.Now().UnixMilli()); nonce <= now {
+		t.Errorf("Expected the nonce to catch up with the clock at %d, got %d", now, nonce)
+	}
+}
+
+func TestSequenceNonceSource(t *testing.T) {
+	srv, signer := newServer(t, hltest.Config{})
+	srv.SetBook("BTC", []sdk.Level{{Px: 99_000, Sz: 1}}, []sdk.Level{{Px: 100_000, Sz: 1}})
+	start := uint64(time.Now().UnixMilli())
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer,
+		sdk.WithNonceSource(sdk.NewSequenceNonceSource(start)))
+
+	order := sdk.OrderRequest{
+		Coin: "BTC", IsBuy: true, Size: 0.01, LimitPx: 98_000,
+		OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifGtc}},
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := exchange.Order(order, nil); err != nil {
+			t.Fatalf("Failed to place order: %v", err)
+		}
+	}
+	for i, req := range srv.Requests() {
+		var body struct {
+			Nonce uint64 `json:"nonce"`
+		}
+		if err := json.Unmarshal(req.Body, &body); err != nil || body.Nonce != start+uint64(i)+1 {
+			t.Errorf("Expected nonce %d, got %d, %v", start+uint64(i)+1, body.Nonce, err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package sdk_test
+
+import (
+	"testing"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+func TestCloid(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		if _, err := sdk.ParseCloid("0x00000000000000000000000000000001"); err != nil {
+			t.Fatalf("Parse valid cloid failed: %v", err)
+		}
+		for _, invalid := range []string{"", "0x01", "00000000000000000000000000000001", "0xzz000000000000000000000000000001"} {
+			if _, err := sdk.ParseCloid(invalid); err == nil {
+				t.Fatalf("Expected error for %q", invalid)
+			}
+		}
+	})
+
+	t.Run("generate and decode", func(t *testing.T) {
+		gen := sdk.NewCloidGenerator(7)
+		a, b := gen.NextWithTag(42), gen.NextWithTag(42)
+		if a == b {
+			t.Fatalf("Generated duplicate cloid %s", a)
+		}
+		info := b.Decode()
+		if info.Tag != 42 || info.Instance != 7 {
+			t.Fatalf("Unexpected decoded cloid: %+v", info)
+		}
+	})
+
+	t.Run("place and cancel by cloid", func(t *testing.T) {
+		srv, signer := newServer(t, hltest.Config{})
+		srv.SetBook("SOL", []sdk.Level{{Px: 149, Sz: 100}}, []sdk.Level{{Px: 151, Sz: 100}})
+		exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+		cloid := sdk.NewCloidGenerator(sdk.RandomInstanceTag()).Next()
+		result, err := exchange.Order(sdk.OrderRequest{
+			Coin:      "SOL",
+			IsBuy:     true,
+			Size:      1,
+			LimitPx:   140,
+			OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifGtc}},
+			Cloid:     &cloid,
+		}, nil)
+		if err != nil {
+			t.Fatalf("Order failed: %v", err)
+		}
+		if _, ok := result.(*sdk.ExchangeRestingOrder); !ok {
+			t.Fatalf("Expected resting order, got %#v", result)
+		}
+		result, err = exchange.CancelByCloid(sdk.CancelByCloidRequest{Coin: "SOL", Cloid: cloid})
+		if err != nil {
+			t.Fatalf("Cancel by cloid failed: %v", err)
+		}
+		if err, ok := result.(error); ok {
+			t.Fatalf("Cancel by cloid rejected: %v", err)
+		}
+		info, err := sdk.NewInfo(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if orders, err := info.OpenOrders(signer.Address().Hex()); err != nil || len(orders) != 0 {
+			t.Errorf("Expected no open orders, got %+v, %v", orders, err)
+		}
+	})
+}
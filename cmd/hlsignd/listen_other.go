@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"net"
+)
+
+// listenUnix fails: without a umask the socket cannot be created owner-only.
+func listenUnix(string) (net.Listener, error) {
+	return nil, errors.New("-unix is not supported on this system, use -listen")
+}
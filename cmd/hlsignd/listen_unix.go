@@ -0,0 +1,18 @@
+//go:build unix
+
+package main
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// listenUnix listens on a Unix socket only its owner can connect to.
+func listenUnix(path string) (net.Listener, error) {
+	_ = os.Remove(path)
+	// create the socket owner-only, a chmod after Listen leaves a window
+	umask := syscall.Umask(0o177)
+	defer syscall.Umask(umask)
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,194 @@
+// Command hlsignd is a signing daemon for sdk.RemoteSigner. It holds a key,
+// loaded from a hex key in an environment variable or from an encrypted
+// keystore, and signs the EIP-712 requests of authenticated clients over
+// TCP or a Unix socket, logging each request as a JSON line.
+//
+//	HL_PRIVATE_KEY=... hlsignd -listen :8710 -tls-cert cert.pem -tls-key key.pem -client-ca ca.pem
+//	hlsignd -unix /run/hlsignd.sock -keystore key.json -passphrase-file pass.txt -secret-file secret.txt
+//
+// TCP listeners need TLS and either a shared secret or client certificates;
+// a Unix socket is only reachable by its owner, and only available on Unix
+// systems. Only Hyperliquid actions are signed unless -any-domain is set.
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/signd"
+)
+
+func main() {
+	var (
+		listen         = flag.String("listen", "", "TCP address to listen on, e.g. :8710")
+		unixSocket     = flag.String("unix", "", "Unix socket to listen on instead of TCP")
+		keyEnv         = flag.String("key-env", "HL_PRIVATE_KEY", "environment variable holding the hex private key")
+		keystorePath   = flag.String("keystore", "", "V3 keystore file to use instead of -key-env")
+		passphraseFile = flag.String("passphrase-file", "", "file holding the keystore passphrase")
+		passphraseEnv  = flag.String("passphrase-env", "", "environment variable holding the keystore passphrase")
+		secretFile     = flag.String("secret-file", "", "file holding the shared secret clients send as bearer token")
+		tlsCert        = flag.String("tls-cert", "", "TLS certificate")
+		tlsKey         = flag.String("tls-key", "", "TLS key")
+		clientCA       = flag.String("client-ca", "", "CA of the client certificates, enables mutual TLS")
+		logPath        = flag.String("log", "", "request log file, stderr if empty")
+		allowDigest    = flag.Bool("allow-digest", false, "sign bare digests without their typed data")
+		anyDomain      = flag.Bool("any-domain", false, "sign typed data of any EIP-712 domain, not only Hyperliquid actions")
+	)
+	flag.Parse()
+	if err := run(options{
+		listen: *listen, unixSocket: *unixSocket,
+		keyEnv: *keyEnv, keystorePath: *keystorePath,
+		passphraseFile: *passphraseFile, passphraseEnv: *passphraseEnv,
+		secretFile: *secretFile, tlsCert: *tlsCert, tlsKey: *tlsKey, clientCA: *clientCA,
+		logPath: *logPath, allowDigest: *allowDigest, anyDomain: *anyDomain,
+	}); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type options struct {
+	listen, unixSocket                    string
+	keyEnv, keystorePath                  string
+	passphraseFile, passphraseEnv         string
+	secretFile, tlsCert, tlsKey, clientCA string
+	logPath                               string
+	allowDigest, anyDomain                bool
+}
+
+func run(opts options) error {
+	if (opts.listen == "") == (opts.unixSocket == "") {
+		return errors.New("exactly one of -listen and -unix is required")
+	}
+	signer, err := loadSigner(opts)
+	if err != nil {
+		return err
+	}
+
+	var secret string
+	if opts.secretFile != "" {
+		data, err := os.ReadFile(opts.secretFile)
+		if err != nil {
+			return err
+		}
+		secret = strings.TrimSpace(string(data))
+	}
+	tlsConfig, err := loadTLS(opts)
+	if err != nil {
+		return err
+	}
+	if opts.listen != "" {
+		if tlsConfig == nil {
+			return errors.New("a TCP listener needs -tls-cert and -tls-key")
+		}
+		if secret == "" && tlsConfig.ClientCAs == nil {
+			return errors.New("a TCP listener needs -secret-file or -client-ca")
+		}
+	}
+
+	var logOut io.Writer = os.Stderr
+	if opts.logPath != "" {
+		f, err := os.OpenFile(opts.logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		logOut = f
+	}
+
+	var ln net.Listener
+	if opts.unixSocket != "" {
+		ln, err = listenUnix(opts.unixSocket)
+	} else {
+		ln, err = net.Listen("tcp", opts.listen)
+	}
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+
+	srv := &http.Server{Handler: signd.New(signd.Config{
+		Signer:      signer,
+		Secret:      secret,
+		AllowDigest: opts.allowDigest,
+		AnyDomain:   opts.anyDomain,
+		Log:         logOut,
+	})}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		srv.Close()
+	}()
+	log.Printf("signing for %s on %s", signer.Address().Hex(), ln.Addr())
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func loadSigner(opts options) (sdk.Signer, error) {
+	if opts.keystorePath == "" {
+		key := os.Getenv(opts.keyEnv)
+		if key == "" {
+			return nil, fmt.Errorf("%s is not set", opts.keyEnv)
+		}
+		return sdk.NewLocalSignerFromHex(strings.TrimPrefix(key, "0x"))
+	}
+
+	passphrase := sdk.PassphraseFromPrompt("Keystore passphrase: ")
+	switch {
+	case opts.passphraseFile != "":
+		passphrase = sdk.PassphraseFromFile(opts.passphraseFile)
+	case opts.passphraseEnv != "":
+		passphrase = sdk.PassphraseFromEnv(opts.passphraseEnv)
+	}
+	signer, err := sdk.NewKeystoreSigner(opts.keystorePath, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	// unlocked until the daemon exits
+	if err := signer.Unlock(0); err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+func loadTLS(opts options) (*tls.Config, error) {
+	if opts.tlsCert == "" && opts.tlsKey == "" {
+		if opts.clientCA != "" {
+			return nil, errors.New("-client-ca needs -tls-cert and -tls-key")
+		}
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(opts.tlsCert, opts.tlsKey)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if opts.clientCA != "" {
+		pem, err := os.ReadFile(opts.clientCA)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", opts.clientCA)
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
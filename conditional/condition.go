@@ -0,0 +1,217 @@
+package conditional
+
+import (
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+type Kind string
+
+const (
+	KindTrailingStop Kind = "trailingStop"
+	KindPriceCross   Kind = "priceCross"
+	KindTime         Kind = "time"
+)
+
+// PriceKind selects the stream a condition watches.
+type PriceKind string
+
+const (
+	PriceMid  PriceKind = "mid"  // allMids
+	PriceLast PriceKind = "last" // trades
+)
+
+// ModifyTarget is a modify kept by a condition. The replacement order is a
+// separate field, unlike in sdk.ModifyRequest, so that both cloids survive
+// being persisted.
+type ModifyTarget struct {
+	Oid   uint64           `json:"oid,omitempty"`
+	Cloid *sdk.Cloid       `json:"cloid,omitempty"`
+	Order sdk.OrderRequest `json:"order"`
+}
+
+func (m *ModifyTarget) request() sdk.ModifyRequest {
+	return sdk.ModifyRequest{Oid: m.Oid, Cloid: m.Cloid, OrderRequest: m.Order}
+}
+
+// Action is what a condition does when it fires. Exactly one field is set.
+//
+// A trailing stop with a Modify action does not fire. It trails a resting
+// trigger order instead: every time the stop moves, the trigger price of the
+// order is moved to it, keeping the distance between its limit and trigger
+// prices. The exchange then triggers the order even if this process is down.
+// Once a modify finds the order gone, most likely triggered, the condition
+// is removed; a modify failing otherwise is retried with the next price.
+type Action struct {
+	Order  *sdk.OrderRequest  `json:"order,omitempty"`
+	Market *sdk.MarketRequest `json:"market,omitempty"`
+	Modify *ModifyTarget      `json:"modify,omitempty"`
+}
+
+func (a *Action) Validate() error {
+	n := 0
+	if a.Order != nil {
+		n++
+	}
+	if a.Market != nil {
+		n++
+	}
+	if a.Modify != nil {
+		n++
+		req := a.Modify.request()
+		if err := req.Validate(); err != nil {
+			return err
+		}
+	}
+	if n != 1 {
+		return sdk.ValidationError{Field: "Action", Message: "exactly one of Order, Market or Modify is required"}
+	}
+	return nil
+}
+
+// Condition is a client-side condition. Only the fields of its Kind are
+// used; the state fields are maintained by the engine and persisted with
+// the condition.
+type Condition struct {
+	ID   string `json:"id"`
+	Kind Kind   `json:"kind"`
+	Coin string `json:"coin"`
+	// Price is the price watched, defaults to PriceMid.
+	Price PriceKind `json:"price,omitempty"`
+
+	// IsBuy is the side of a trailing stop: a sell stop trails below the
+	// highest price seen, a buy stop above the lowest. Exactly one of
+	// TrailPercent, e.g. 0.02 for 2%, and TrailAmount is set.
+	IsBuy        bool    `json:"isBuy,omitempty"`
+	TrailPercent float64 `json:"trailPercent,omitempty"`
+	TrailAmount  float64 `json:"trailAmount,omitempty"`
+	// Extreme is the best price seen and Stop the stop derived from it.
+	Extreme float64 `json:"extreme,omitempty"`
+	Stop    float64 `json:"stop,omitempty"`
+
+	// A price cross fires on the first price at or beyond Level, above it
+	// if Above is set, after a price on the other side was seen.
+	Level  float64 `json:"level,omitempty"`
+	Above  bool    `json:"above,omitempty"`
+	LastPx float64 `json:"lastPx,omitempty"`
+
+	// At is when a time condition fires.
+	At time.Time `json:"at,omitzero"`
+
+	Action    Action    `json:"action"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (c *Condition) Validate() error {
+	if c.Coin == "" && c.Kind != KindTime {
+		return sdk.ValidationError{Field: "Coin", Message: "required"}
+	}
+	if c.Price != "" && c.Price != PriceMid && c.Price != PriceLast {
+		return sdk.ValidationError{Field: "Price", Message: "must be mid or last"}
+	}
+	switch c.Kind {
+	case KindTrailingStop:
+		if (c.TrailPercent > 0) == (c.TrailAmount > 0) {
+			return sdk.ValidationError{Field: "TrailPercent", Message: "exactly one of TrailPercent or TrailAmount must be positive"}
+		}
+		if c.TrailPercent >= 1 {
+			return sdk.ValidationError{Field: "TrailPercent", Message: "must be below 1"}
+		}
+		if c.Action.Modify != nil && c.Action.Modify.Order.OrderType.Trigger == nil {
+			return sdk.ValidationError{Field: "Action", Message: "a trailed order must be a trigger order"}
+		}
+	case KindPriceCross:
+		if c.Level <= 0 {
+			return sdk.ValidationError{Field: "Level", Message: "must be positive"}
+		}
+	case KindTime:
+		if c.At.IsZero() {
+			return sdk.ValidationError{Field: "At", Message: "required"}
+		}
+	default:
+		return sdk.ValidationError{Field: "Kind", Message: "unknown kind " + string(c.Kind)}
+	}
+	return c.Action.Validate()
+}
+
+// trails reports whether the condition trails a resting order rather than
+// firing.
+func (c *Condition) trails() bool {
+	return c.Kind == KindTrailingStop && c.Action.Modify != nil
+}
+
+// observe applies a price and reports whether the condition fires and
+// whether its state changed.
+func (c *Condition) observe(px float64) (fire, changed bool) {
+	switch c.Kind {
+	case KindTrailingStop:
+		if c.Extreme == 0 || (!c.IsBuy && px > c.Extreme) || (c.IsBuy && px < c.Extreme) {
+			c.Extreme = px
+			c.Stop = c.stopFrom(px)
+			changed = true
+		}
+		if c.trails() {
+			return false, changed
+		}
+		if c.IsBuy {
+			return px >= c.Stop, changed
+		}
+		return px <= c.Stop, changed
+
+	case KindPriceCross:
+		prev := c.LastPx
+		c.LastPx = px
+		if prev == 0 {
+			return false, true
+		}
+		return !c.beyond(prev) && c.beyond(px), prev != px
+	}
+	return false, false
+}
+
+func (c *Condition) stopFrom(extreme float64) float64 {
+	trail := c.TrailAmount
+	if c.TrailPercent > 0 {
+		trail = extreme * c.TrailPercent
+	}
+	if c.IsBuy {
+		return extreme + trail
+	}
+	return extreme - trail
+}
+
+func (c *Condition) beyond(px float64) bool {
+	if c.Above {
+		return px >= c.Level
+	}
+	return px <= c.Level
+}
+
+func (c *Condition) priceKind() PriceKind {
+	if c.Price == "" {
+		return PriceMid
+	}
+	return c.Price
+}
+
+func (c *Condition) clone() Condition {
+	out := *c
+	if c.Action.Order != nil {
+		order := *c.Action.Order
+		out.Action.Order = &order
+	}
+	if c.Action.Market != nil {
+		market := *c.Action.Market
+		out.Action.Market = &market
+	}
+	if c.Action.Modify != nil {
+		modify := *c.Action.Modify
+		if modify.Order.OrderType.Trigger != nil {
+			trigger := *modify.Order.OrderType.Trigger
+			modify.Order.OrderType.Trigger = &trigger
+		}
+		out.Action.Modify = &modify
+	}
+	return out
+}
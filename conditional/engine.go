@@ -0,0 +1,467 @@
+package conditional
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+type Config struct {
+	// Store persists the conditions; nil keeps them in memory only.
+	Store Store
+	// Builder is attached to the orders sent when conditions fire.
+	Builder *sdk.BuilderInfo
+	// OnFire is called with the result of every fired action and of every
+	// modify of a trailed order.
+	OnFire func(c Condition, status any, err error)
+	// OnError is called when saving the conditions fails.
+	OnError func(err error)
+}
+
+// Engine evaluates conditions against the allMids and trades streams and
+// sends their actions when they fire. A condition is removed and saved
+// before its action is sent, so after a crash it fires at most once.
+// Trailing state is saved at most once a second; everything else as soon as
+// it changes.
+type Engine struct {
+	exchange sdk.Trader
+	ws       *sdk.WebsocketClient
+	cfg      Config
+
+	mu         sync.Mutex
+	conditions map[string]*Condition
+	inFlight   map[string]bool // trailed orders with a modify in flight
+	dirty      bool
+	started    bool
+	subs       map[sdk.Subscription]int
+	stop       chan struct{}
+	done       chan struct{}
+
+	saveMu sync.Mutex
+}
+
+// NewEngine creates an engine and loads the conditions saved in cfg.Store.
+func NewEngine(exchange sdk.Trader, ws *sdk.WebsocketClient, cfg Config) (*Engine, error) {
+	e := &Engine{
+		exchange:   exchange,
+		ws:         ws,
+		cfg:        cfg,
+		conditions: make(map[string]*Condition),
+		inFlight:   make(map[string]bool),
+		subs:       make(map[sdk.Subscription]int),
+	}
+	if cfg.Store != nil {
+		saved, err := cfg.Store.Load()
+		if err != nil {
+			return nil, err
+		}
+		for i := range saved {
+			c := saved[i]
+			e.conditions[c.ID] = &c
+		}
+	}
+	return e, nil
+}
+
+// Start subscribes to the streams the conditions watch and starts the loop
+// firing time conditions and saving state. The loop ends with ctx or Stop.
+func (e *Engine) Start(ctx context.Context) error {
+	e.mu.Lock()
+	e.started = true
+	var coins []string
+	for _, c := range e.conditions {
+		if c.priceKind() == PriceLast {
+			coins = append(coins, c.Coin)
+		}
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	e.stop, e.done = stop, done
+	e.mu.Unlock()
+
+	if err := e.watch(sdk.Subscription{Type: sdk.SubTypeAllMids}); err != nil {
+		return err
+	}
+	for _, coin := range coins {
+		if err := e.watch(sdk.Subscription{Type: sdk.SubTypeTrades, Coin: coin}); err != nil {
+			return err
+		}
+	}
+	go e.run(ctx, stop, done)
+	return nil
+}
+
+// Stop ends the loop, saves the state and unsubscribes from the streams.
+func (e *Engine) Stop() {
+	e.mu.Lock()
+	stop, done := e.stop, e.done
+	e.stop, e.done = nil, nil
+	e.started = false
+	subs := e.subs
+	e.subs = make(map[sdk.Subscription]int)
+	e.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	for sub, id := range subs {
+		_ = e.ws.Unsubscribe(sub, id)
+	}
+	e.save()
+}
+
+// Add validates, saves and starts watching a condition, and returns its id.
+func (e *Engine) Add(c Condition) (string, error) {
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+	if c.ID == "" {
+		c.ID = newID()
+	}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+	c = c.clone()
+
+	e.mu.Lock()
+	if _, exist := e.conditions[c.ID]; exist {
+		e.mu.Unlock()
+		return "", fmt.Errorf("condition %s already exists", c.ID)
+	}
+	e.conditions[c.ID] = &c
+	e.dirty = true
+	started := e.started
+	e.mu.Unlock()
+
+	if err := e.save(); err != nil {
+		e.mu.Lock()
+		delete(e.conditions, c.ID)
+		e.mu.Unlock()
+		return "", err
+	}
+	if started && c.priceKind() == PriceLast {
+		if err := e.watch(sdk.Subscription{Type: sdk.SubTypeTrades, Coin: c.Coin}); err != nil {
+			return c.ID, err
+		}
+	}
+	return c.ID, nil
+}
+
+// Remove drops a condition without firing it.
+func (e *Engine) Remove(id string) error {
+	e.mu.Lock()
+	if _, exist := e.conditions[id]; !exist {
+		e.mu.Unlock()
+		return fmt.Errorf("condition %s not found", id)
+	}
+	delete(e.conditions, id)
+	e.dirty = true
+	e.mu.Unlock()
+	return e.save()
+}
+
+func (e *Engine) Get(id string) (Condition, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c, ok := e.conditions[id]
+	if !ok {
+		return Condition{}, false
+	}
+	return c.clone(), true
+}
+
+// Conditions returns the pending conditions, oldest first.
+func (e *Engine) Conditions() []Condition {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.snapshotLocked()
+}
+
+// ApplyPrice evaluates the conditions of coin that watch kind.
+func (e *Engine) ApplyPrice(coin string, kind PriceKind, px float64) {
+	if px <= 0 {
+		return
+	}
+	var fired []Condition
+	var trailed []Condition
+
+	e.mu.Lock()
+	for id, c := range e.conditions {
+		if c.Coin != coin || c.priceKind() != kind {
+			continue
+		}
+		fire, changed := c.observe(px)
+		if changed {
+			e.dirty = true
+		}
+		if fire {
+			fired = append(fired, c.clone())
+			delete(e.conditions, id)
+			continue
+		}
+		// after a failed modify the stop still differs from the order, so
+		// the modify is retried even when the stop did not move
+		if c.trails() && !e.inFlight[id] && e.stopMovedLocked(c) {
+			e.inFlight[id] = true
+			trailed = append(trailed, c.clone())
+		}
+	}
+	e.mu.Unlock()
+
+	e.dispatch(fired)
+	for _, c := range trailed {
+		go e.trail(c)
+	}
+}
+
+// HandleAllMids is a websocket callback for the allMids channel.
+func (e *Engine) HandleAllMids(msg sdk.WSMessage) {
+	var data sdk.WsAllMids
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		return
+	}
+	e.mu.Lock()
+	coins := make(map[string]struct{})
+	for _, c := range e.conditions {
+		if c.priceKind() == PriceMid {
+			coins[c.Coin] = struct{}{}
+		}
+	}
+	e.mu.Unlock()
+
+	for coin := range coins {
+		mid, ok := data.Mids[coin]
+		if !ok {
+			continue
+		}
+		px, err := strconv.ParseFloat(mid, 64)
+		if err != nil {
+			continue
+		}
+		e.ApplyPrice(coin, PriceMid, px)
+	}
+}
+
+// HandleTrades is a websocket callback for the trades channel. Trades are
+// applied in the order received.
+func (e *Engine) HandleTrades(msg sdk.WSMessage) {
+	var trades []sdk.Trade
+	if err := json.Unmarshal(msg.Data, &trades); err != nil {
+		return
+	}
+	for _, trade := range trades {
+		px, err := strconv.ParseFloat(trade.Px, 64)
+		if err != nil {
+			continue
+		}
+		e.ApplyPrice(trade.Coin, PriceLast, px)
+	}
+}
+
+// CheckTime fires the time conditions that are due.
+func (e *Engine) CheckTime(now time.Time) {
+	var fired []Condition
+	e.mu.Lock()
+	for id, c := range e.conditions {
+		if c.Kind == KindTime && !now.Before(c.At) {
+			fired = append(fired, c.clone())
+			delete(e.conditions, id)
+		}
+	}
+	e.mu.Unlock()
+	e.dispatch(fired)
+}
+
+func (e *Engine) run(ctx context.Context, stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			e.CheckTime(now)
+			e.save()
+		}
+	}
+}
+
+func (e *Engine) watch(sub sdk.Subscription) error {
+	e.mu.Lock()
+	_, exist := e.subs[sub]
+	e.mu.Unlock()
+	if exist {
+		return nil
+	}
+
+	var id int
+	var err error
+	if sub.Type == sdk.SubTypeAllMids {
+		id, err = e.ws.SubscribeToAllMids(e.HandleAllMids)
+	} else {
+		id, err = e.ws.SubscribeToTrades(sub.Coin, e.HandleTrades)
+	}
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.subs[sub] = id
+	e.mu.Unlock()
+	return nil
+}
+
+// dispatch saves the removal of fired conditions, then sends their actions.
+func (e *Engine) dispatch(fired []Condition) {
+	if len(fired) == 0 {
+		return
+	}
+	e.mu.Lock()
+	e.dirty = true
+	e.mu.Unlock()
+	e.save()
+	for _, c := range fired {
+		go e.fire(c)
+	}
+}
+
+func (e *Engine) fire(c Condition) {
+	var status any
+	var err error
+	switch {
+	case c.Action.Order != nil:
+		status, err = e.exchange.Order(*c.Action.Order, e.cfg.Builder)
+	case c.Action.Market != nil:
+		status, err = e.exchange.MarketOrder(*c.Action.Market, e.cfg.Builder)
+	case c.Action.Modify != nil:
+		status, err = e.exchange.ModifyOrder(c.Action.Modify.request())
+	}
+	if e.cfg.OnFire != nil {
+		e.cfg.OnFire(c, status, err)
+	}
+}
+
+// stopMovedLocked reports whether the stop of a trailing condition moved
+// away from the trigger price of its order by at least one price step.
+func (e *Engine) stopMovedLocked(c *Condition) bool {
+	stop, err := e.exchange.RoundPrice(c.Coin, c.Stop)
+	if err != nil {
+		return false
+	}
+	current, _ := strconv.ParseFloat(c.Action.Modify.Order.OrderType.Trigger.TriggerPx, 64)
+	if c.IsBuy {
+		return current == 0 || stop < current
+	}
+	return stop > current
+}
+
+// trail moves the trigger price of a trailed order to the stop of c.
+func (e *Engine) trail(c Condition) {
+	modify := c.Action.Modify
+	stop, err := e.exchange.RoundPrice(c.Coin, c.Stop)
+	if err == nil {
+		trigger := modify.Order.OrderType.Trigger
+		current, _ := strconv.ParseFloat(trigger.TriggerPx, 64)
+		offset := 0.0
+		if current > 0 {
+			offset = modify.Order.LimitPx - current
+		}
+		trigger.TriggerPx = sdk.FloatToString(stop)
+		modify.Order.LimitPx, err = e.exchange.RoundPrice(c.Coin, stop+offset)
+	}
+
+	var status any
+	if err == nil {
+		status, err = e.exchange.ModifyOrder(modify.request())
+	}
+	switch s := status.(type) {
+	case error:
+		err = s
+	case *sdk.ExchangeRestingOrder:
+		// a modified order gets a new oid
+		if modify.Cloid == nil {
+			modify.Oid = s.Oid
+		}
+	}
+
+	// other errors keep the condition, to retry with the next price
+	gone := sdk.IsOrderGone(err)
+	e.mu.Lock()
+	delete(e.inFlight, c.ID)
+	if stored, ok := e.conditions[c.ID]; ok {
+		switch {
+		case gone:
+			// most likely triggered
+			delete(e.conditions, c.ID)
+			e.dirty = true
+		case err == nil:
+			stored.Action.Modify = modify
+			e.dirty = true
+		}
+	}
+	e.mu.Unlock()
+	if gone {
+		e.save()
+	}
+
+	if e.cfg.OnFire != nil {
+		e.cfg.OnFire(c, status, err)
+	}
+}
+
+// save writes the conditions to the store if they changed. Saves are
+// serialized so that an older snapshot never overwrites a newer one.
+func (e *Engine) save() error {
+	if e.cfg.Store == nil {
+		return nil
+	}
+	e.saveMu.Lock()
+	defer e.saveMu.Unlock()
+
+	e.mu.Lock()
+	if !e.dirty {
+		e.mu.Unlock()
+		return nil
+	}
+	e.dirty = false
+	conditions := e.snapshotLocked()
+	e.mu.Unlock()
+
+	if err := e.cfg.Store.Save(conditions); err != nil {
+		e.mu.Lock()
+		e.dirty = true
+		e.mu.Unlock()
+		if e.cfg.OnError != nil {
+			e.cfg.OnError(err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (e *Engine) snapshotLocked() []Condition {
+	conditions := make([]Condition, 0, len(e.conditions))
+	for _, c := range e.conditions {
+		conditions = append(conditions, c.clone())
+	}
+	slices.SortFunc(conditions, func(a, b Condition) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return conditions
+}
+
+func newID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,196 @@
+package conditional_test
+
+import (
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/conditional"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+type fired struct {
+	c      conditional.Condition
+	status any
+	err    error
+}
+
+// newEngine returns an engine on a funded hltest account, fed by ApplyPrice
+// instead of the streams. Every OnFire call is sent to the returned channel.
+func newEngine(t *testing.T, store conditional.Store) (*hltest.Server, *sdk.Exchange, *conditional.Engine, chan fired) {
+	t.Helper()
+	srv := hltest.NewServer(hltest.Config{})
+	t.Cleanup(srv.Close)
+	key, _ := crypto.GenerateKey()
+	signer, err := sdk.NewLocalSignerFromHex(hex.EncodeToString(crypto.FromECDSA(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Fund(signer.Address(), 10_000)
+	srv.SetBook("SOL", []sdk.Level{{Px: 149, Sz: 100}}, []sdk.Level{{Px: 151, Sz: 100}})
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+
+	fires := make(chan fired, 16)
+	engine, err := conditional.NewEngine(exchange, nil, conditional.Config{
+		Store: store,
+		OnFire: func(c conditional.Condition, status any, err error) {
+			fires <- fired{c, status, err}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	return srv, exchange, engine, fires
+}
+
+func next(t *testing.T, fires chan fired) fired {
+	t.Helper()
+	select {
+	case f := <-fires:
+		return f
+	case <-time.After(5 * time.Second):
+		t.Fatal("Condition did not fire")
+		return fired{}
+	}
+}
+
+func TestEngineFires(t *testing.T) {
+	store := conditional.NewFileStore(filepath.Join(t.TempDir(), "conditions.json"))
+	_, _, engine, fires := newEngine(t, store)
+
+	stopID, err := engine.Add(conditional.Condition{
+		Kind:        conditional.KindTrailingStop,
+		Coin:        "SOL",
+		TrailAmount: 2,
+		Action: conditional.Action{
+			Market: &sdk.MarketRequest{Coin: "SOL", Size: 1, Slippage: 0.05},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add trailing stop: %v", err)
+	}
+	crossID, err := engine.Add(conditional.Condition{
+		Kind:  conditional.KindPriceCross,
+		Coin:  "SOL",
+		Level: 155,
+		Above: true,
+		Action: conditional.Action{
+			Order: &sdk.OrderRequest{
+				Coin:      "SOL",
+				IsBuy:     true,
+				Size:      1,
+				LimitPx:   160,
+				OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifIoc}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add price cross: %v", err)
+	}
+
+	for _, px := range []float64{150, 156, 155} {
+		engine.ApplyPrice("SOL", conditional.PriceMid, px)
+	}
+	f := next(t, fires)
+	if f.c.ID != crossID || f.err != nil {
+		t.Fatalf("Expected the price cross to fire, got %+v", f)
+	}
+	c, ok := engine.Get(stopID)
+	if !ok || c.Extreme != 156 || c.Stop != 154 {
+		t.Fatalf("Unexpected trailing stop: %+v", c)
+	}
+
+	// the state is saved, so a restarted engine trails from the same high
+	reloaded, err := conditional.NewEngine(nil, nil, conditional.Config{Store: store})
+	if err != nil {
+		t.Fatalf("Failed to reload engine: %v", err)
+	}
+	if saved := reloaded.Conditions(); len(saved) != 1 || saved[0].ID != stopID || saved[0].Stop != 154 {
+		t.Fatalf("Unexpected saved conditions: %+v", saved)
+	}
+
+	engine.ApplyPrice("SOL", conditional.PriceMid, 154)
+	f = next(t, fires)
+	if f.c.ID != stopID || f.err != nil {
+		t.Fatalf("Expected the trailing stop to fire, got %+v", f)
+	}
+	if filled, ok := f.status.(*sdk.ExchangeFilledOrder); !ok || filled.TotalSize != "1" {
+		t.Errorf("Unexpected market order status: %#v", f.status)
+	}
+	if n := len(engine.Conditions()); n != 0 {
+		t.Errorf("Expected fired conditions to be removed, %d left", n)
+	}
+}
+
+func TestEngineTrailsOrder(t *testing.T) {
+	srv, exchange, engine, fires := newEngine(t, nil)
+	cloid := sdk.Cloid{1}
+	stop := sdk.OrderRequest{
+		Coin:    "SOL",
+		Size:    1,
+		LimitPx: 130,
+		OrderType: sdk.OrderType{Trigger: &sdk.TriggerOrderType{
+			TriggerPx: "140",
+			IsMarket:  true,
+			Tpsl:      "sl",
+		}},
+		Cloid: &cloid,
+	}
+	if _, err := exchange.Order(stop, nil); err != nil {
+		t.Fatalf("Failed to place stop: %v", err)
+	}
+	id, err := engine.Add(conditional.Condition{
+		Kind:        conditional.KindTrailingStop,
+		Coin:        "SOL",
+		TrailAmount: 5,
+		Action:      conditional.Action{Modify: &conditional.ModifyTarget{Cloid: &cloid, Order: stop}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add condition: %v", err)
+	}
+	paper := srv.Paper(exchange.AccountAddress())
+	trailedTo := func(triggerPx, limitPx float64) {
+		t.Helper()
+		orders := paper.FrontendOpenOrders()
+		if len(orders) != 1 || orders[0].TriggerPx != triggerPx || orders[0].LimitPx != limitPx {
+			t.Errorf("Expected the stop at %v/%v, got %+v", triggerPx, limitPx, orders)
+		}
+	}
+
+	engine.ApplyPrice("SOL", conditional.PriceMid, 150)
+	if f := next(t, fires); f.err != nil {
+		t.Fatalf("Modify failed: %v", f.err)
+	}
+	trailedTo(145, 135)
+
+	// a failed modify keeps the condition and is retried with the next
+	// price, even one that does not move the stop
+	srv.Script("/exchange", "batchModify", hltest.ExchangeError("rate limited"))
+	engine.ApplyPrice("SOL", conditional.PriceMid, 152)
+	if f := next(t, fires); f.err == nil {
+		t.Fatal("Expected the modify to fail")
+	}
+	if _, ok := engine.Get(id); !ok {
+		t.Fatal("Expected the condition to survive a failed modify")
+	}
+	engine.ApplyPrice("SOL", conditional.PriceMid, 151)
+	if f := next(t, fires); f.err != nil {
+		t.Fatalf("Retried modify failed: %v", f.err)
+	}
+	trailedTo(147, 137)
+
+	// an order that is gone ends the condition
+	if _, err := exchange.CancelByCloid(sdk.CancelByCloidRequest{Coin: "SOL", Cloid: cloid}); err != nil {
+		t.Fatalf("Failed to cancel stop: %v", err)
+	}
+	engine.ApplyPrice("SOL", conditional.PriceMid, 153)
+	if f := next(t, fires); !sdk.IsOrderGone(f.err) {
+		t.Fatalf("Expected the order to be gone, got %v", f.err)
+	}
+	if _, ok := engine.Get(id); ok {
+		t.Error("Expected the condition to be removed")
+	}
+}
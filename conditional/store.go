@@ -0,0 +1,65 @@
+package conditional
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Store persists the conditions of an engine. Save is given every condition
+// still pending and replaces what was saved before.
+type Store interface {
+	Load() ([]Condition, error)
+	Save(conditions []Condition) error
+}
+
+// FileStore keeps the conditions as JSON in a file. Saves write a temporary
+// file and rename it, so a crash never leaves a partial file behind.
+type FileStore struct {
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Load() ([]Condition, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var conditions []Condition
+	if err := json.Unmarshal(data, &conditions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal conditions: %w", err)
+	}
+	return conditions, nil
+}
+
+func (s *FileStore) Save(conditions []Condition) error {
+	data, err := json.MarshalIndent(conditions, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
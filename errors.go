@@ -1,6 +1,9 @@
 package sdk
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type APIError struct {
 	Code    int    `json:"code"`
@@ -20,3 +23,14 @@ type ValidationError struct {
 func (e ValidationError) Error() string {
 	return fmt.Sprintf("validation error on field %s: %s", e.Field, e.Message)
 }
+
+// IsOrderGone reports whether err is the status of a cancel or modify of an
+// order that was never placed, or is already canceled or filled.
+func IsOrderGone(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "never placed, already canceled, or filled") ||
+		strings.Contains(msg, "Cannot modify canceled or filled order")
+}
@@ -0,0 +1,44 @@
+package examples
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+func TestOrderBatcher(t *testing.T) {
+	exchange := getTestExchange(t)
+	batcher := sdk.NewOrderBatcher(exchange, sdk.BatcherConfig{Window: 20 * time.Millisecond})
+	coin := "SOL"
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(px float64) {
+			defer wg.Done()
+			result, err := batcher.Order(sdk.OrderRequest{
+				Coin:    coin,
+				IsBuy:   true,
+				Size:    1,
+				LimitPx: px,
+				OrderType: sdk.OrderType{
+					Limit: &sdk.LimitOrderType{Tif: sdk.TifAlo},
+				},
+			})
+			if err != nil {
+				t.Errorf("Batched order failed: %v", err)
+				return
+			}
+			t.Logf("Batched order result: %+v", result)
+
+			if resting, ok := result.(*sdk.ExchangeRestingOrder); ok {
+				if _, err := batcher.Cancel(sdk.CancelRequest{Coin: coin, Oid: resting.Oid}); err != nil {
+					t.Errorf("Batched cancel failed: %v", err)
+				}
+			}
+		}(100 + float64(i))
+	}
+	wg.Wait()
+}
@@ -0,0 +1,52 @@
+package examples
+
+import (
+	"testing"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+func TestBracketOrder(t *testing.T) {
+	exchange := getTestExchange(t)
+
+	t.Run("entry with take profit and stop loss", func(t *testing.T) {
+		result, err := exchange.BracketOrder(
+			sdk.BracketRequest{
+				Coin:            "SOL",
+				IsBuy:           true,
+				Size:            1,
+				LimitPx:         150,
+				TakeProfitPx:    180,
+				StopLossPx:      140,
+				TriggerIsMarket: true,
+			},
+			nil,
+		)
+		if err != nil {
+			t.Fatalf("Bracket order failed: %v", err)
+		}
+		t.Logf("Entry: %+v, take profit: %+v, stop loss: %+v", result.Entry, result.TakeProfit, result.StopLoss)
+
+		if resting, ok := result.Entry.(*sdk.ExchangeRestingOrder); ok {
+			if _, err := exchange.Cancel(sdk.CancelRequest{Coin: "SOL", Oid: resting.Oid}); err != nil {
+				t.Fatalf("Cancel failed: %v", err)
+			}
+		}
+	})
+
+	t.Run("invalid trigger direction", func(t *testing.T) {
+		_, err := exchange.BracketOrder(
+			sdk.BracketRequest{
+				Coin:         "SOL",
+				IsBuy:        true,
+				Size:         1,
+				LimitPx:      150,
+				TakeProfitPx: 140,
+			},
+			nil,
+		)
+		if _, ok := err.(sdk.ValidationError); !ok {
+			t.Fatalf("expected validation error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package examples
+
+import (
+	"testing"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+func TestCloid(t *testing.T) {
+	exchange := getTestExchange(t)
+	cloid := sdk.NewCloidGenerator(sdk.RandomInstanceTag()).Next()
+	result, err := exchange.Order(
+		sdk.OrderRequest{
+			Coin:    "SOL",
+			IsBuy:   true,
+			Size:    1,
+			LimitPx: 150,
+			OrderType: sdk.OrderType{
+				Limit: &sdk.LimitOrderType{Tif: sdk.TifGtc},
+			},
+			Cloid: &cloid,
+		},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("Order failed: %v", err)
+	}
+	t.Logf("Order result: %+v", result)
+
+	result, err = exchange.CancelByCloid(sdk.CancelByCloidRequest{Coin: "SOL", Cloid: cloid})
+	if err != nil {
+		t.Fatalf("Cancel by cloid failed: %v", err)
+	}
+	t.Logf("Cancel result: %+v", result)
+}
@@ -0,0 +1,64 @@
+package examples
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/conditional"
+)
+
+func TestConditionalOrders(t *testing.T) {
+	exchange := getTestExchange(t)
+	ws := sdk.NewWebsocketClient(sdk.MainnetAPIURL)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+
+	store := conditional.NewFileStore(filepath.Join(t.TempDir(), "conditions.json"))
+	engine, err := conditional.NewEngine(exchange, ws, conditional.Config{
+		Store: store,
+		OnFire: func(c conditional.Condition, status any, err error) {
+			t.Logf("Condition %s fired: %v, %v", c.ID, status, err)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if err := engine.Start(ctx); err != nil {
+		t.Fatalf("Failed to start engine: %v", err)
+	}
+
+	// sell 0.1 SOL at market once it falls 50% from its high
+	id, err := engine.Add(conditional.Condition{
+		Kind:         conditional.KindTrailingStop,
+		Coin:         "SOL",
+		TrailPercent: 0.5,
+		Action: conditional.Action{
+			Market: &sdk.MarketRequest{Coin: "SOL", Size: 0.1, Slippage: 0.05, ReduceOnly: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add condition: %v", err)
+	}
+	time.Sleep(3 * time.Second)
+	engine.Stop()
+
+	restarted, err := conditional.NewEngine(exchange, ws, conditional.Config{Store: store})
+	if err != nil {
+		t.Fatalf("Failed to reload engine: %v", err)
+	}
+	c, ok := restarted.Get(id)
+	if !ok {
+		t.Fatalf("Condition %s was not persisted", id)
+	}
+	t.Logf("Reloaded trailing stop: high %v, stop %v", c.Extreme, c.Stop)
+	if err := restarted.Remove(id); err != nil {
+		t.Fatalf("Failed to remove condition: %v", err)
+	}
+}
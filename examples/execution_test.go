@@ -0,0 +1,82 @@
+package examples
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/execution"
+)
+
+func TestExecutionIceberg(t *testing.T) {
+	exchange := getTestExchange(t)
+	info, err := sdk.NewInfo(sdk.MainnetAPIURL)
+	if err != nil {
+		t.Fatalf("Failed to create info: %v", err)
+	}
+	ws := sdk.NewWebsocketClient(sdk.MainnetAPIURL)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+
+	engine := execution.NewEngine(exchange, ws, execution.Config{
+		Prices: sdk.NewBookPriceSource(info),
+	})
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Failed to start engine: %v", err)
+	}
+	defer engine.Stop()
+
+	algo, err := engine.Iceberg(ctx, execution.IcebergParams{
+		Coin:            "SOL",
+		IsBuy:           true,
+		Size:            1,
+		DisplaySize:     0.2,
+		RepriceInterval: 5 * time.Second,
+		OnProgress: func(p execution.Progress) {
+			t.Logf("Iceberg %s: %s filled %v/%v @ %v, open %v", p.ID, p.Status, p.FilledSz, p.Size, p.AvgPx, p.OpenSz)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to start iceberg: %v", err)
+	}
+
+	time.Sleep(10 * time.Second)
+	algo.Pause()
+	time.Sleep(2 * time.Second)
+	algo.Cancel()
+	t.Logf("Final progress: %+v", algo.Wait())
+}
+
+func TestExecutionPOV(t *testing.T) {
+	exchange := getTestExchange(t)
+	ws := sdk.NewWebsocketClient(sdk.MainnetAPIURL)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+
+	engine := execution.NewEngine(exchange, ws, execution.Config{})
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Failed to start engine: %v", err)
+	}
+	defer engine.Stop()
+
+	algo, err := engine.POV(ctx, execution.POVParams{
+		Coin:     "SOL",
+		IsBuy:    true,
+		Size:     0.1,
+		Rate:     0.01,
+		MaxSlice: 0.05,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start POV: %v", err)
+	}
+	t.Logf("Final progress: %+v", algo.Wait())
+}
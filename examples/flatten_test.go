@@ -0,0 +1,27 @@
+package examples
+
+import (
+	"testing"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+func TestCancelAll(t *testing.T) {
+	exchange := getTestExchange(t)
+
+	result, err := exchange.CancelAll(sdk.CancelAllFilter{Coin: "SOL"})
+	if err != nil {
+		t.Fatalf("Cancel all failed: %v", err)
+	}
+	t.Logf("Canceled: %d, failed: %+v, remaining: %+v", len(result.Canceled), result.Failed, result.Remaining)
+}
+
+func TestCloseAllPositions(t *testing.T) {
+	exchange := getTestExchange(t)
+
+	result, err := exchange.CloseAllPositions(0.05)
+	if err != nil {
+		t.Fatalf("Close all positions failed: %v", err)
+	}
+	t.Logf("Closed: %+v, failed: %+v, remaining: %+v", result.Closed, result.Failed, result.Remaining)
+}
@@ -0,0 +1,27 @@
+package examples
+
+import (
+	"testing"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+func TestEstimateImpact(t *testing.T) {
+	info, err := sdk.NewInfo(sdk.MainnetAPIURL)
+	if err != nil {
+		t.Fatalf("Failed to create sdk.Info: %v", err)
+	}
+	book, err := info.L2Snapshot("BTC")
+	if err != nil {
+		t.Fatalf("Failed to fetch L2 snapshot: %v", err)
+	}
+
+	for _, isBuy := range []bool{true, false} {
+		est, err := sdk.EstimateImpact(book, isBuy, 10)
+		if err != nil {
+			t.Fatalf("Estimate impact failed: %v", err)
+		}
+		t.Logf("Impact (buy=%v): vwap %v, worst %v, levels %d, unfilled %v, slippage %.6f",
+			isBuy, est.Vwap, est.WorstPx, est.LevelsConsumed, est.Unfilled, est.Slippage)
+	}
+}
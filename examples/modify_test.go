@@ -0,0 +1,60 @@
+package examples
+
+import (
+	"testing"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+func TestModifyOrders(t *testing.T) {
+	exchange := getTestExchange(t)
+	coin := "SOL"
+	order := sdk.OrderRequest{
+		Coin:    coin,
+		IsBuy:   true,
+		Size:    1,
+		LimitPx: 150,
+		OrderType: sdk.OrderType{
+			Limit: &sdk.LimitOrderType{Tif: sdk.TifGtc},
+		},
+	}
+
+	t.Run("invalid modify request", func(t *testing.T) {
+		if _, err := exchange.ModifyOrder(sdk.ModifyRequest{OrderRequest: order}); err == nil {
+			t.Fatal("Expected validation error for modify without oid or cloid")
+		}
+	})
+
+	t.Run("modify by cloid", func(t *testing.T) {
+		cloid := sdk.NewCloidGenerator(sdk.RandomInstanceTag()).Next()
+		order := order
+		order.Cloid = &cloid
+		if _, err := exchange.Order(order, nil); err != nil {
+			t.Fatalf("Order failed: %v", err)
+		}
+
+		order.LimitPx = 151
+		result, err := exchange.ModifyOrder(sdk.NewModifyByCloid(cloid, order))
+		if err != nil {
+			t.Fatalf("Modify failed: %v", err)
+		}
+		t.Logf("Modify result: %+v", result)
+
+		if _, err := exchange.CancelByCloid(sdk.CancelByCloidRequest{Coin: coin, Cloid: cloid}); err != nil {
+			t.Fatalf("Cancel failed: %v", err)
+		}
+	})
+
+	t.Run("amend missing order", func(t *testing.T) {
+		result, err := exchange.AmendOrder(sdk.NewModifyByOid(1, order), nil)
+		if err != nil {
+			t.Fatalf("Amend failed: %v", err)
+		}
+		t.Logf("Amend result: %+v", result)
+		if resting, ok := result.Status.(*sdk.ExchangeRestingOrder); ok {
+			if _, err := exchange.Cancel(sdk.CancelRequest{Coin: coin, Oid: resting.Oid}); err != nil {
+				t.Fatalf("Cancel failed: %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package examples
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/oms"
+)
+
+func TestOMS(t *testing.T) {
+	exchange := getTestExchange(t)
+	ws := sdk.NewWebsocketClient(sdk.MainnetAPIURL)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+
+	orders := oms.New()
+	orders.OnChange(func(order oms.Order, prev oms.Status) {
+		t.Logf("Order %s: %s -> %s (filled %v @ %v)", order.Cloid, prev, order.Status, order.FilledSz, order.AvgPx)
+	})
+	if err := orders.Attach(ws, exchange.AccountAddress().Hex()); err != nil {
+		t.Fatalf("Failed to attach OMS: %v", err)
+	}
+
+	_, err := orders.PlaceOrders(exchange, []sdk.OrderRequest{{
+		Coin:    "SOL",
+		IsBuy:   true,
+		Size:    1,
+		LimitPx: 100,
+		OrderType: sdk.OrderType{
+			Limit: &sdk.LimitOrderType{Tif: sdk.TifAlo},
+		},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("Place orders failed: %v", err)
+	}
+
+	for _, order := range orders.Open() {
+		if order.Oid == 0 {
+			continue
+		}
+		if _, err := exchange.Cancel(sdk.CancelRequest{Coin: order.Request.Coin, Oid: order.Oid}); err != nil {
+			t.Fatalf("Cancel failed: %v", err)
+		}
+	}
+	time.Sleep(2 * time.Second)
+	t.Logf("Tracked orders: %+v", orders.Orders(nil))
+}
@@ -115,3 +115,29 @@ func TestOrders(t *testing.T) {
 		}
 	})
 }
+
+func TestSelfPricedMarketOrders(t *testing.T) {
+	exchange := getTestExchange(t)
+	coin := "kPEPE"
+
+	// MarketPrice is left empty so the order prices itself from the mids
+	result, err := exchange.MarketOrder(
+		sdk.MarketRequest{
+			Coin:     coin,
+			IsBuy:    true,
+			Size:     14078,
+			Slippage: 0.05,
+		},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("Market order failed: %v", err)
+	}
+	t.Logf("Market order result: %+v", result)
+
+	result, err = exchange.MarketClose(coin, 0.05, nil)
+	if err != nil {
+		t.Fatalf("Market close failed: %v", err)
+	}
+	t.Logf("Market close result: %+v", result)
+}
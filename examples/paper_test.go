@@ -0,0 +1,78 @@
+package examples
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/paper"
+)
+
+func TestPaperExchange(t *testing.T) {
+	exchange := getTestExchange(t)
+	info, err := sdk.NewInfo(sdk.MainnetAPIURL)
+	if err != nil {
+		t.Fatalf("Failed to create info: %v", err)
+	}
+	meta, err := info.Meta()
+	if err != nil {
+		t.Fatalf("Failed to get meta: %v", err)
+	}
+	fees, err := info.UserFees(exchange.AccountAddress().Hex())
+	if err != nil {
+		t.Fatalf("Failed to get user fees: %v", err)
+	}
+
+	ws := sdk.NewWebsocketClient(sdk.MainnetAPIURL)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+
+	sim := paper.NewExchange(ws, paper.Config{
+		Meta:    meta,
+		Account: exchange.AccountAddress(),
+		Balance: 1000,
+		Fees:    fees,
+	})
+	defer sim.Close()
+	sim.SubscribeToUserFills(func(msg sdk.WSMessage) {
+		t.Logf("Paper fill: %s", msg.Data)
+	})
+	if err := sim.Watch("BTC"); err != nil {
+		t.Fatalf("Failed to watch: %v", err)
+	}
+	time.Sleep(2 * time.Second)
+
+	// any sdk.Trader works here, the real exchange included
+	var trader sdk.Trader = sim
+	status, err := trader.MarketOrder(sdk.MarketRequest{Coin: "BTC", IsBuy: true, Size: 0.001, Slippage: 0.01}, nil)
+	if err != nil {
+		t.Fatalf("Failed to place market order: %v", err)
+	}
+	t.Logf("Market order: %+v", status)
+
+	status, err = trader.Order(sdk.OrderRequest{
+		Coin:       "BTC",
+		IsBuy:      false,
+		Size:       0.001,
+		LimitPx:    1000,
+		ReduceOnly: true,
+		OrderType: sdk.OrderType{Trigger: &sdk.TriggerOrderType{
+			TriggerPx: "1000",
+			IsMarket:  true,
+			Tpsl:      sdk.StopLose,
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to place stop: %v", err)
+	}
+	t.Logf("Stop: %+v", status)
+
+	state := sim.UserState()
+	t.Logf("Paper account value %s, positions %+v, open orders %+v",
+		state.MarginSummary.AccountValue, state.AssetPositions, sim.OpenOrders())
+}
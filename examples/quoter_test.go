@@ -0,0 +1,45 @@
+package examples
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/quoter"
+)
+
+func TestQuoteManager(t *testing.T) {
+	exchange := getTestExchange(t)
+	ws := sdk.NewWebsocketClient(sdk.MainnetAPIURL)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+
+	manager := quoter.NewManager(exchange, ws, quoter.Config{
+		OnError: func(coin string, err error) {
+			t.Logf("Quote error on %s: %v", coin, err)
+		},
+	})
+	if err := manager.Start(); err != nil {
+		t.Fatalf("Failed to start quote manager: %v", err)
+	}
+	defer manager.Stop()
+
+	coin := "SOL"
+	ladders := []quoter.Quotes{
+		{Bids: []quoter.Level{{Px: 100, Sz: 1}, {Px: 99, Sz: 1}}},
+		{Bids: []quoter.Level{{Px: 100, Sz: 1}, {Px: 98, Sz: 2}}},
+		{},
+	}
+	for _, ladder := range ladders {
+		if err := manager.SetQuotes(coin, ladder); err != nil {
+			t.Fatalf("Failed to set quotes: %v", err)
+		}
+		manager.Wait()
+		t.Logf("Resting quotes: %+v", manager.Orders(coin))
+	}
+}
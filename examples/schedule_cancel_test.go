@@ -0,0 +1,43 @@
+package examples
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+func TestScheduleCancel(t *testing.T) {
+	exchange := getTestExchange(t)
+
+	t.Run("schedule and remove", func(t *testing.T) {
+		cancelAt := uint64(time.Now().Add(time.Minute).UnixMilli())
+		if err := exchange.ScheduleCancel(&cancelAt); err != nil {
+			t.Fatalf("Schedule cancel failed: %v", err)
+		}
+		if err := exchange.ScheduleCancel(nil); err != nil {
+			t.Fatalf("Remove scheduled cancel failed: %v", err)
+		}
+	})
+
+	t.Run("heartbeat", func(t *testing.T) {
+		hb, err := sdk.NewHeartbeat(exchange, sdk.HeartbeatConfig{
+			Timeout:  30 * time.Second,
+			Interval: 5 * time.Second,
+			OnBeat: func(status sdk.HeartbeatStatus) {
+				t.Logf("Heartbeat: %+v", status)
+			},
+		})
+		if err != nil {
+			t.Fatalf("Heartbeat config rejected: %v", err)
+		}
+		if err := hb.Start(context.Background()); err != nil {
+			t.Fatalf("Heartbeat start failed: %v", err)
+		}
+		time.Sleep(6 * time.Second)
+		if err := hb.Disarm(); err != nil {
+			t.Fatalf("Heartbeat disarm failed: %v", err)
+		}
+	})
+}
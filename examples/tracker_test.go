@@ -0,0 +1,47 @@
+package examples
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/tracker"
+)
+
+func TestTracker(t *testing.T) {
+	exchange := getTestExchange(t)
+	info, err := sdk.NewInfo(sdk.MainnetAPIURL)
+	if err != nil {
+		t.Fatalf("Failed to create info: %v", err)
+	}
+	ws := sdk.NewWebsocketClient(sdk.MainnetAPIURL)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+
+	tr := tracker.New(info, ws, tracker.Config{
+		User:              exchange.AccountAddress().Hex(),
+		ReconcileInterval: 10 * time.Second,
+		OnError: func(err error) {
+			t.Logf("Reconcile failed: %v", err)
+		},
+		OnDrift: func(coin string, tracked, actual float64) {
+			t.Logf("Drift on %s: tracked %v, actual %v", coin, tracked, actual)
+		},
+	})
+	if err := tr.Start(ctx); err != nil {
+		t.Fatalf("Failed to start tracker: %v", err)
+	}
+	defer tr.Stop()
+
+	time.Sleep(5 * time.Second)
+	for _, pos := range tr.Positions() {
+		t.Logf("Position %s: %v @ %v, mark %v, upnl %v", pos.Coin, pos.Szi, pos.EntryPx, pos.MarkPx, pos.UnrealizedPnl)
+	}
+	t.Logf("Balances: %+v", tr.Balances())
+	t.Logf("Account: %+v", tr.Account())
+}
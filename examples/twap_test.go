@@ -0,0 +1,47 @@
+package examples
+
+import (
+	"testing"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+func TestTwapOrder(t *testing.T) {
+	exchange := getTestExchange(t)
+
+	twapID, err := exchange.TwapOrder(sdk.TwapRequest{
+		Coin:    "SOL",
+		IsBuy:   true,
+		Size:    1,
+		Minutes: 5,
+	})
+	if err != nil {
+		t.Fatalf("Twap order failed: %v", err)
+	}
+	t.Logf("Twap is running: %d", twapID)
+
+	if err := exchange.TwapCancel("SOL", twapID); err != nil {
+		t.Fatalf("Twap cancel failed: %v", err)
+	}
+	t.Log("Twap canceled")
+}
+
+func TestTwapHistory(t *testing.T) {
+	info, err := sdk.NewInfo(sdk.MainnetAPIURL)
+	if err != nil {
+		t.Fatalf("Failed to create sdk.Info: %v", err)
+	}
+	address := getTestExchange(t).Signer().Address().Hex()
+
+	history, err := info.TwapHistory(address)
+	if err != nil {
+		t.Fatalf("Failed to fetch twap history: %v", err)
+	}
+	t.Logf("Twap history: %+v", history)
+
+	fills, err := info.UserTwapSliceFills(address)
+	if err != nil {
+		t.Fatalf("Failed to fetch twap slice fills: %v", err)
+	}
+	t.Logf("Twap slice fills: %+v", fills)
+}
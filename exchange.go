@@ -3,23 +3,96 @@ package sdk
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"sync/atomic"
-	"time"
+	"net/http"
 
 	"github.com/ethereum/go-ethereum/common"
 )
 
 type Exchange struct {
 	client         *Client
+	info           *Info
 	vault          *common.Address
+	account        *common.Address
+	priceSource    PriceSource
+	bookSource     BookSource
+	risk           RiskChecker
 	coinToAsset    map[string]int
 	assetToDecimal map[int]int
 	signer         Signer
-	nonce          atomic.Uint64
+	clock          Clock
+	nonces         NonceSource
+	nonceStore     NonceStore
+	nonceRetry     bool
 }
 
-func NewExchange(baseApiURL string, vaultAddr *common.Address, meta *Meta, signer Signer) *Exchange {
+type ExchangeOption func(*Exchange)
+
+// WithAccountAddress sets the account the exchange trades for. It is needed
+// when the signer is an agent wallet and no vault is used.
+func WithAccountAddress(addr common.Address) ExchangeOption {
+	return func(e *Exchange) {
+		e.account = &addr
+	}
+}
+
+// WithTransport sets the transport of the HTTP client, e.g. to record or
+// replay traffic. It is shared with the Info used for prices.
+func WithTransport(rt http.RoundTripper) ExchangeOption {
+	return func(e *Exchange) {
+		e.client.httpClient.Transport = rt
+	}
+}
+
+// WithClock sets the clock the default nonce source counts from. Defaults
+// to SystemClock.
+func WithClock(clock Clock) ExchangeOption {
+	return func(e *Exchange) {
+		e.clock = clock
+	}
+}
+
+// WithNonceSource sets where the nonces of signed actions come from.
+// Defaults to a StoreNonceSource with WithNonceStore, else to a
+// TimestampNonceSource on the clock.
+func WithNonceSource(nonces NonceSource) ExchangeOption {
+	return func(e *Exchange) {
+		e.nonces = nonces
+	}
+}
+
+// WithServerClock generates nonces and the deadlines of Heartbeat from
+// clock, and feeds it the timestamps of the user states and book snapshots
+// fetched by the exchange. The exchange has no websocket of its own; to
+// also feed the clock from l2Book messages, subscribe it on a client:
+//
+//	ws.SubscribeToOrderbook("BTC", clock.HandleL2Book)
+func WithServerClock(clock *ServerClock) ExchangeOption {
+	return func(e *Exchange) {
+		e.clock = clock
+		e.info.serverClock = clock
+	}
+}
+
+// WithNonceStore reserves the nonces of the signer in store, so that
+// exchanges sharing the key, also in other processes, never reuse one.
+func WithNonceStore(store NonceStore) ExchangeOption {
+	return func(e *Exchange) {
+		e.nonceStore = store
+	}
+}
+
+// WithNonceRetry sets whether actions the exchange signs itself are signed
+// again with a new nonce and posted once more when rejected for their nonce.
+// Enabled by default; see RetryNonce to set it per call.
+func WithNonceRetry(retry bool) ExchangeOption {
+	return func(e *Exchange) {
+		e.nonceRetry = retry
+	}
+}
+
+func NewExchange(baseApiURL string, vaultAddr *common.Address, meta *Meta, signer Signer, opts ...ExchangeOption) *Exchange {
 	coinToAsset := make(map[string]int)
 	assetToDecimal := make(map[int]int)
 	if meta != nil {
@@ -28,15 +101,28 @@ func NewExchange(baseApiURL string, vaultAddr *common.Address, meta *Meta, signe
 			assetToDecimal[asset] = info.SzDecimals
 		}
 	}
+	client := NewClient(context.Background(), baseApiURL)
 	exchange := Exchange{
-		client:         NewClient(context.Background(), baseApiURL),
+		client:         client,
+		info:           newInfoWithClient(client),
 		vault:          vaultAddr,
 		coinToAsset:    coinToAsset,
 		assetToDecimal: assetToDecimal,
 		signer:         signer,
-		nonce:          atomic.Uint64{},
+		clock:          SystemClock,
+		nonceRetry:     true,
+	}
+	exchange.priceSource = NewMidPriceSource(exchange.info)
+	exchange.bookSource = exchange.info
+	for _, opt := range opts {
+		opt(&exchange)
+	}
+	if exchange.nonces == nil && exchange.nonceStore != nil {
+		exchange.nonces = NewStoreNonceSource(exchange.nonceStore, signer.Address(), exchange.clock)
+	}
+	if exchange.nonces == nil {
+		exchange.nonces = NewTimestampNonceSource(exchange.clock)
 	}
-	exchange.nonce.Store(nowTimestamp())
 	return &exchange
 }
 
@@ -48,6 +134,18 @@ func (e *Exchange) VaultAddress() *common.Address {
 	return e.vault
 }
 
+// AccountAddress returns the account whose state the exchange acts on: the
+// address set with WithAccountAddress, else the vault, else the signer.
+func (e *Exchange) AccountAddress() common.Address {
+	if e.account != nil {
+		return *e.account
+	}
+	if e.vault != nil {
+		return *e.vault
+	}
+	return e.signer.Address()
+}
+
 func (e *Exchange) Order(req OrderRequest, builder *BuilderInfo) (any, error) {
 	orders, err := e.BulkOrders([]OrderRequest{req}, builder)
 	if err != nil {
@@ -71,10 +169,29 @@ func (e *Exchange) MarketOrder(req MarketRequest, builder *BuilderInfo) (any, er
 }
 
 func (e *Exchange) BulkMarketOrders(req []MarketRequest, builder *BuilderInfo) ([]any, error) {
+	unpriced := 0
+	for _, r := range req {
+		if !r.PriceFromBook && r.MarketPrice <= 0 {
+			unpriced++
+		}
+	}
+	source, err := e.batchPriceSource(unpriced)
+	if err != nil {
+		return nil, err
+	}
 	orderReqs := make([]OrderRequest, len(req))
 	for i, r := range req {
-		// Get slippage price
-		price := e.slippagePrice(r.IsBuy, r.Slippage, r.MarketPrice)
+		// Get slippage price, fetching the market price if not given
+		var price float64
+		var err error
+		if r.PriceFromBook {
+			price, err = e.bookPrice(r.Coin, r.IsBuy, r.Size, r.Slippage)
+		} else {
+			price, err = e.marketPrice(source, r.Coin, r.IsBuy, r.Slippage, r.MarketPrice)
+		}
+		if err != nil {
+			return nil, err
+		}
 		orderReqs[i] = OrderRequest{
 			Coin:    r.Coin,
 			IsBuy:   r.IsBuy,
@@ -124,7 +241,26 @@ func (e *Exchange) ModifyOrder(request ModifyRequest) (any, error) {
 }
 
 func (e *Exchange) BulkOrders(orders []OrderRequest, builder *BuilderInfo) ([]any, error) {
-	nonce := e.NextNonce()
+	return e.BulkOrdersWithGrouping(orders, GroupingNa, builder)
+}
+
+// BulkOrdersWithGrouping places orders as a single action with the given
+// grouping, see GroupingNa, GroupingNormalTpsl and GroupingPositionTpsl.
+func (e *Exchange) BulkOrdersWithGrouping(orders []OrderRequest, grouping string, builder *BuilderInfo) ([]any, error) {
+	if e.risk != nil {
+		if err := e.risk.CheckOrders(orders); err != nil {
+			return nil, err
+		}
+	}
+	return e.postOrders(orders, grouping, builder)
+}
+
+// postOrders places orders that already passed the risk check.
+func (e *Exchange) postOrders(orders []OrderRequest, grouping string, builder *BuilderInfo) ([]any, error) {
+	nonce, err := e.NextNonce()
+	if err != nil {
+		return nil, err
+	}
 
 	orderWires := make([]OrderWire, len(orders))
 	for i, order := range orders {
@@ -139,7 +275,7 @@ func (e *Exchange) BulkOrders(orders []OrderRequest, builder *BuilderInfo) ([]an
 	action := &OrderAction{
 		Type:     "order",
 		Orders:   orderWires,
-		Grouping: GroupingNa,
+		Grouping: grouping,
 		Builder:  builder,
 	}
 
@@ -148,15 +284,31 @@ func (e *Exchange) BulkOrders(orders []OrderRequest, builder *BuilderInfo) ([]an
 		return nil, err
 	}
 
-	_, statuses, err := e.PostActionAndParseResponse(action, sig, nonce)
+	_, statuses, err := e.PostActionAndParseResponse(action, sig, nonce, e.resignL1(action))
 	return statuses, err
 }
 
 func (e *Exchange) BulkModifyOrders(request []ModifyRequest) ([]any, error) {
-	nonce := e.NextNonce()
+	if e.risk != nil {
+		if err := e.risk.CheckModifies(request); err != nil {
+			return nil, err
+		}
+	}
+	return e.postModifies(request)
+}
+
+// postModifies modifies orders that already passed the risk check.
+func (e *Exchange) postModifies(request []ModifyRequest) ([]any, error) {
+	nonce, err := e.NextNonce()
+	if err != nil {
+		return nil, err
+	}
 
 	modifyWires := make([]ModifyWire, len(request))
 	for i, req := range request {
+		if err := req.Validate(); err != nil {
+			return nil, err
+		}
 		asset, exist := e.coinToAsset[req.OrderRequest.Coin]
 		if !exist {
 			return nil, fmt.Errorf("coin %s does not exist", req.OrderRequest.Coin)
@@ -175,12 +327,15 @@ func (e *Exchange) BulkModifyOrders(request []ModifyRequest) ([]any, error) {
 		return nil, err
 	}
 
-	_, statuses, err := e.PostActionAndParseResponse(action, sig, nonce)
+	_, statuses, err := e.PostActionAndParseResponse(action, sig, nonce, e.resignL1(action))
 	return statuses, err
 }
 
 func (e *Exchange) BulkCancel(request []CancelRequest) ([]any, error) {
-	nonce := e.NextNonce()
+	nonce, err := e.NextNonce()
+	if err != nil {
+		return nil, err
+	}
 
 	cancelWires := make([]CancelWire, len(request))
 	for i, req := range request {
@@ -201,12 +356,15 @@ func (e *Exchange) BulkCancel(request []CancelRequest) ([]any, error) {
 		return nil, err
 	}
 
-	_, statuses, err := e.PostActionAndParseResponse(action, sig, nonce)
+	_, statuses, err := e.PostActionAndParseResponse(action, sig, nonce, e.resignL1(action))
 	return statuses, err
 }
 
 func (e *Exchange) BulkCancelByCloid(request []CancelByCloidRequest) ([]any, error) {
-	nonce := e.NextNonce()
+	nonce, err := e.NextNonce()
+	if err != nil {
+		return nil, err
+	}
 
 	cancelWires := make([]CancelByCloidWire, len(request))
 	for i, req := range request {
@@ -227,12 +385,15 @@ func (e *Exchange) BulkCancelByCloid(request []CancelByCloidRequest) ([]any, err
 		return nil, err
 	}
 
-	_, statuses, err := e.PostActionAndParseResponse(action, sig, nonce)
+	_, statuses, err := e.PostActionAndParseResponse(action, sig, nonce, e.resignL1(action))
 	return statuses, err
 }
 
 func (e *Exchange) UpdateLeverage(coin string, isCross bool, leverage int) error {
-	nonce := e.NextNonce()
+	nonce, err := e.NextNonce()
+	if err != nil {
+		return err
+	}
 
 	asset, exist := e.coinToAsset[coin]
 	if !exist {
@@ -250,12 +411,15 @@ func (e *Exchange) UpdateLeverage(coin string, isCross bool, leverage int) error
 		return err
 	}
 
-	_, _, err = e.PostActionAndParseResponse(action, sig, nonce)
+	_, _, err = e.PostActionAndParseResponse(action, sig, nonce, e.resignL1(action))
 	return err
 }
 
 func (e *Exchange) UpdateIsolatedMargin(coin string, amount float64) error {
-	nonce := e.NextNonce()
+	nonce, err := e.NextNonce()
+	if err != nil {
+		return err
+	}
 
 	amountInt := FloatToUsdInt(amount)
 	asset, exist := e.coinToAsset[coin]
@@ -274,12 +438,128 @@ func (e *Exchange) UpdateIsolatedMargin(coin string, amount float64) error {
 		return err
 	}
 
-	_, _, err = e.PostActionAndParseResponse(action, sig, nonce)
+	_, _, err = e.PostActionAndParseResponse(action, sig, nonce, e.resignL1(action))
 	return err
 }
 
+// ScheduleCancel schedules a cancel of all open orders at the given time in ms.
+// The time must be at least 5 seconds in the future. Passing nil removes the
+// scheduled cancel. The exchange allows a limited number of triggers per day.
+func (e *Exchange) ScheduleCancel(t *uint64) error {
+	nonce, err := e.NextNonce()
+	if err != nil {
+		return err
+	}
+
+	action := &ScheduleCancelAction{
+		Type: "scheduleCancel",
+		Time: t,
+	}
+
+	sig, err := e.signL1Action(action, nonce)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = e.PostActionAndParseResponse(action, sig, nonce, e.resignL1(action))
+	return err
+}
+
+// TwapOrder places a native TWAP order and returns its id.
+func (e *Exchange) TwapOrder(req TwapRequest) (TwapID, error) {
+	asset, exist := e.coinToAsset[req.Coin]
+	if !exist {
+		return 0, fmt.Errorf("coin %s does not exist", req.Coin)
+	}
+	if req.Minutes <= 0 {
+		return 0, ValidationError{Field: "Minutes", Message: "must be positive"}
+	}
+	if e.risk != nil {
+		if err := e.risk.CheckTwap(req); err != nil {
+			return 0, err
+		}
+	}
+	action := &TwapOrderAction{
+		Type: "twapOrder",
+		Twap: req.ToWire(asset, e.assetToDecimal[asset]),
+	}
+
+	nonce, err := e.NextNonce()
+	if err != nil {
+		return 0, err
+	}
+	sig, err := e.signL1Action(action, nonce)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := e.postActionWithRetry(action, sig, nonce, e.resignL1(action))
+	if err != nil {
+		return 0, err
+	}
+	if resp.Data == nil || len(resp.Data.Status) == 0 {
+		return 0, fmt.Errorf("twap order: missing status in response")
+	}
+	status := new(TwapOrderStatus)
+	if err := json.Unmarshal(resp.Data.Status, status); err != nil {
+		return 0, fmt.Errorf("twap order: %w", err)
+	}
+	if status.Error != nil {
+		return 0, errors.New(*status.Error)
+	}
+	if status.Running == nil {
+		return 0, fmt.Errorf("twap order: unexpected status %s", resp.Data.Status)
+	}
+	return status.Running.TwapID, nil
+}
+
+// TwapCancel cancels a running native TWAP order.
+func (e *Exchange) TwapCancel(coin string, twapID TwapID) error {
+	asset, exist := e.coinToAsset[coin]
+	if !exist {
+		return fmt.Errorf("coin %s does not exist", coin)
+	}
+	action := &TwapCancelAction{
+		Type:   "twapCancel",
+		Asset:  asset,
+		TwapID: twapID,
+	}
+
+	nonce, err := e.NextNonce()
+	if err != nil {
+		return err
+	}
+	sig, err := e.signL1Action(action, nonce)
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.postActionWithRetry(action, sig, nonce, e.resignL1(action))
+	if err != nil {
+		return err
+	}
+	if resp.Data == nil || len(resp.Data.Status) == 0 {
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(resp.Data.Status, &str); err == nil {
+		return nil
+	}
+	status := new(TwapOrderStatus)
+	if err := json.Unmarshal(resp.Data.Status, status); err != nil {
+		return fmt.Errorf("twap cancel: %w", err)
+	}
+	if status.Error != nil {
+		return errors.New(*status.Error)
+	}
+	return nil
+}
+
 func (e *Exchange) VaultUsdTransfer(isDeposit bool, vaultAddress string, amount int) (*ExchangeRequest, error) {
-	nonce := e.NextNonce()
+	nonce, err := e.NextNonce()
+	if err != nil {
+		return nil, err
+	}
 
 	action := &VaultUsdTransferAction{
 		Type:         "vaultTransfer",
@@ -310,16 +590,79 @@ func (e *Exchange) signL1Action(action any, nonce uint64) (*Signature, error) {
 	)
 }
 
-func (e *Exchange) slippagePrice(isBuy bool, slippage float64, price float64) float64 {
+// RoundPrice rounds px to a price the exchange accepts for coin.
+func (e *Exchange) RoundPrice(coin string, px float64) (float64, error) {
+	asset, exist := e.coinToAsset[coin]
+	if !exist {
+		return 0, fmt.Errorf("coin %s does not exist", coin)
+	}
+	return adjustPrice(px, asset, e.assetToDecimal[asset]), nil
+}
+
+// RoundSize rounds size to the size decimals of coin.
+func (e *Exchange) RoundSize(coin string, size float64) (float64, error) {
+	asset, exist := e.coinToAsset[coin]
+	if !exist {
+		return 0, fmt.Errorf("coin %s does not exist", coin)
+	}
+	return RoundToDecimal(size, e.assetToDecimal[asset]), nil
+}
+
+// slippagePrice moves price by slippage against the taker and rounds it to a
+// price the exchange accepts for the coin.
+func (e *Exchange) slippagePrice(coin string, isBuy bool, slippage float64, price float64) (float64, error) {
+	asset, exist := e.coinToAsset[coin]
+	if !exist {
+		return 0, fmt.Errorf("coin %s does not exist", coin)
+	}
 	if isBuy {
 		price *= 1 + slippage
 	} else {
 		price *= 1 - slippage
 	}
-	return price
+	return adjustPrice(price, asset, e.assetToDecimal[asset]), nil
 }
 
-func (e *Exchange) PostActionAndParseResponse(action Action, signature *Signature, nonce uint64) (string, []any, error) {
+// PostActionAndParseResponse posts a signed action. An action rejected for
+// its nonce is only signed again with a new one and posted once more when
+// the caller passes Resign or RetryNonce, as it may have been signed by
+// another key than the exchange's.
+func (e *Exchange) PostActionAndParseResponse(action Action, signature *Signature, nonce uint64, opts ...PostOption) (string, []any, error) {
+	respInner, err := e.postActionWithRetry(action, signature, nonce, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+	if respInner.Data == nil {
+		return respInner.Type, nil, nil
+	}
+	statuses := make([]any, len(respInner.Data.Statuses))
+	for i, status := range respInner.Data.Statuses {
+		statuses[i] = status.Parse()
+	}
+	return respInner.Type, statuses, nil
+}
+
+// postActionWithRetry posts a signed action like PostActionAndParseResponse
+// and returns the response as is, for actions whose statuses are not order
+// statuses.
+func (e *Exchange) postActionWithRetry(action Action, signature *Signature, nonce uint64, opts ...PostOption) (*ExchangeSuccessResponse, error) {
+	var cfg postConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	retry := e.nonceRetry && cfg.resign != nil
+	if cfg.retry != nil {
+		retry = *cfg.retry
+	}
+	resp, err := e.postAction(action, signature, nonce)
+	var nonceErr *NonceError
+	if retry && errors.As(err, &nonceErr) {
+		resp, err = e.retryNonce(cfg, action, nonceErr)
+	}
+	return resp, err
+}
+
+func (e *Exchange) postAction(action Action, signature *Signature, nonce uint64) (*ExchangeSuccessResponse, error) {
 	payload := ExchangeRequest{
 		Action:    action,
 		Nonce:     nonce,
@@ -330,36 +673,24 @@ func (e *Exchange) PostActionAndParseResponse(action Action, signature *Signatur
 	}
 	response, err := e.client.post("/exchange", payload)
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
 	respStatus := new(ExchangeResponsesStatus)
 	if err = json.Unmarshal(response, respStatus); err != nil {
-		return "", nil, err
-	}
-	respInner, err := respStatus.Parse()
-	if err != nil {
-		return "", nil, err
-	}
-	if respInner.Data == nil {
-		return respInner.Type, nil, nil
+		return nil, err
 	}
-	statuses := make([]any, len(respInner.Data.Statuses))
-	for i, status := range respInner.Data.Statuses {
-		statuses[i] = status.Parse()
+	resp, err := respStatus.Parse()
+	if err != nil && isNonceRejection(err.Error()) {
+		return nil, &NonceError{Nonce: nonce, Message: err.Error()}
 	}
-	return respInner.Type, statuses, nil
+	return resp, err
 }
 
-func (e *Exchange) NextNonce() uint64 {
-	nonce := e.nonce.Add(1)
-	now := nowTimestamp()
-	// more than 300 seconds behind
-	if nonce+300_000 < now {
-		e.nonce.Swap(now)
-	}
-	return nonce
+func (e *Exchange) NextNonce() (uint64, error) {
+	return e.nonces.NextNonce()
 }
 
-func nowTimestamp() uint64 {
-	return uint64(time.Now().UnixMilli())
+// Clock returns the clock of the exchange, see WithClock.
+func (e *Exchange) Clock() Clock {
+	return e.clock
 }
@@ -43,27 +43,38 @@ func FromApproveAgentReq(req *ApproveAgentRequest) *ApproveAgentAction {
 
 // ApproveAgent sends a request to approve an agent wallet for the main account.
 // All parameters are required as per the API specification.
-func ApproveAgent(e *sdk.Exchange, req ApproveAgentRequest) (any, error) {
-	nonce := e.NextNonce()
-	req.Nonce = nonce
-	action := FromApproveAgentReq(&req)
-	actionT := map[string]interface{}{
-		"nonce":            new(big.Int).SetUint64(nonce),
-		"agentAddress":     req.AgentAddress.Hex(),
-		"agentName":        req.AgentName,
-		"hyperliquidChain": req.HyperliquidChain,
-		"signatureChainId": req.SignatureChainId,
+func ApproveAgent(e sdk.ActionPoster, req ApproveAgentRequest) (any, error) {
+	// sign is called again with a new nonce if the exchange rejects this one
+	sign := func(nonce uint64) (sdk.Action, *sdk.Signature, error) {
+		req.Nonce = nonce
+		action := FromApproveAgentReq(&req)
+		actionT := map[string]interface{}{
+			"nonce":            new(big.Int).SetUint64(nonce),
+			"agentAddress":     req.AgentAddress.Hex(),
+			"agentName":        req.AgentName,
+			"hyperliquidChain": req.HyperliquidChain,
+			"signatureChainId": req.SignatureChainId,
+		}
+
+		// Note: The signature is generated based on the *action* content and the *request* nonce.
+		sig, err := signAgent(e, actionT)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to sign approveAgent action: %w", err)
+		}
+		return action, sig, nil
 	}
-
-	// Note: The signature is generated based on the *action* content and the *request* nonce.
-	sig, err := signAgent(e, actionT)
+	nonce, err := e.NextNonce()
+	if err != nil {
+		return nil, err
+	}
+	action, sig, err := sign(nonce)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign approveAgent action: %w", err)
+		return nil, err
 	}
 
 	// The response structure for approveAgent might be simpler (e.g., just status confirmation)
 	// Adjust parsing if needed based on actual API response.
-	respType, statuses, err := e.PostActionAndParseResponse(action, sig, nonce)
+	respType, statuses, err := e.PostActionAndParseResponse(action, sig, nonce, sdk.Resign(sign))
 	if err != nil {
 		return nil, fmt.Errorf("approveAgent request failed: %w", err)
 	}
@@ -90,7 +101,7 @@ func signAgentPayloadTypes() []apitypes.Type {
 }
 
 // Sign for Approve Agent
-func signAgent(e *sdk.Exchange, action apitypes.TypedDataMessage) (*sdk.Signature, error) {
+func signAgent(e sdk.ActionPoster, action apitypes.TypedDataMessage) (*sdk.Signature, error) {
 	payload := signAgentPayloadTypes()
 	primaryType := signAgentPrimaryType
 	return sdk.SignUserSignedAction(e.Signer(), action, payload, primaryType)
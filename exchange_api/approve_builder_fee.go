@@ -39,27 +39,38 @@ func FromBuilderFeeReq(req *ApproveBuilderFeeRequest) *ApproveBuilderFeeAction {
 	}
 }
 
-func ApproveBuilderFee(e *sdk.Exchange, req ApproveBuilderFeeRequest) (any, error) {
-	nonce := e.NextNonce()
-	req.Nonce = nonce
-	action := FromBuilderFeeReq(&req)
-	actionT := map[string]interface{}{
-		"nonce":            new(big.Int).SetUint64(nonce),
-		"builder":          req.Builder.Hex(),
-		"maxFeeRate":       req.MaxFeeRate,
-		"hyperliquidChain": req.HyperliquidChain,
-		"signatureChainId": req.SignatureChainId,
+func ApproveBuilderFee(e sdk.ActionPoster, req ApproveBuilderFeeRequest) (any, error) {
+	// sign is called again with a new nonce if the exchange rejects this one
+	sign := func(nonce uint64) (sdk.Action, *sdk.Signature, error) {
+		req.Nonce = nonce
+		action := FromBuilderFeeReq(&req)
+		actionT := map[string]interface{}{
+			"nonce":            new(big.Int).SetUint64(nonce),
+			"builder":          req.Builder.Hex(),
+			"maxFeeRate":       req.MaxFeeRate,
+			"hyperliquidChain": req.HyperliquidChain,
+			"signatureChainId": req.SignatureChainId,
+		}
+
+		// Note: The signature is generated based on the *action* content and the *request* nonce.
+		sig, err := signApproveBuilderFee(e, actionT)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to sign approveBuilderFee action: %w", err)
+		}
+		return action, sig, nil
 	}
-
-	// Note: The signature is generated based on the *action* content and the *request* nonce.
-	sig, err := signApproveBuilderFee(e, actionT)
+	nonce, err := e.NextNonce()
+	if err != nil {
+		return nil, err
+	}
+	action, sig, err := sign(nonce)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign approveBuilderFee action: %w", err)
+		return nil, err
 	}
 
 	// The response structure for approveBuilderFee might be simpler (e.g., just status confirmation)
 	// Adjust parsing if needed based on actual API response.
-	respType, statuses, err := e.PostActionAndParseResponse(action, sig, nonce)
+	respType, statuses, err := e.PostActionAndParseResponse(action, sig, nonce, sdk.Resign(sign))
 	if err != nil {
 		return nil, fmt.Errorf("approveBuilderFee request failed: %w", err)
 	}
@@ -85,7 +96,7 @@ func signApproveBuilderFeePayloadTypes() []apitypes.Type {
 	}
 }
 
-func signApproveBuilderFee(e *sdk.Exchange, action apitypes.TypedDataMessage) (*sdk.Signature, error) {
+func signApproveBuilderFee(e sdk.ActionPoster, action apitypes.TypedDataMessage) (*sdk.Signature, error) {
 	payload := signApproveBuilderFeePayloadTypes()
 	return sdk.SignUserSignedAction(e.Signer(), action, payload, approveBuilderFeePrimaryType)
 }
@@ -0,0 +1,20 @@
+package exchange_api
+
+import (
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+// WithNonces returns e with the nonces of the helpers taken from nonces,
+// e.g. a SequenceNonceSource for reproducible signatures.
+func WithNonces(e sdk.ActionPoster, nonces sdk.NonceSource) sdk.ActionPoster {
+	return noncePoster{ActionPoster: e, nonces: nonces}
+}
+
+type noncePoster struct {
+	sdk.ActionPoster
+	nonces sdk.NonceSource
+}
+
+func (p noncePoster) NextNonce() (uint64, error) {
+	return p.nonces.NextNonce()
+}
@@ -38,27 +38,38 @@ func FromBuilderTransferUSDReq(req *TransferUSDRequest) *TransferUSDAction {
 	}
 }
 
-func TansferUSD(e *sdk.Exchange, req TransferUSDRequest) (any, error) {
-	nonce := e.NextNonce()
-	req.Nonce = nonce
-	action := FromBuilderTransferUSDReq(&req)
-	actionT := map[string]interface{}{
-		"destination":      req.Destination,
-		"amount":           req.Amount,
-		"time":             new(big.Int).SetUint64(nonce),
-		"hyperliquidChain": req.HyperliquidChain,
-		"signatureChainId": req.SignatureChainId,
-	}
+func TansferUSD(e sdk.ActionPoster, req TransferUSDRequest) (any, error) {
+	// sign is called again with a new nonce if the exchange rejects this one
+	sign := func(nonce uint64) (sdk.Action, *sdk.Signature, error) {
+		req.Nonce = nonce
+		action := FromBuilderTransferUSDReq(&req)
+		actionT := map[string]interface{}{
+			"destination":      req.Destination,
+			"amount":           req.Amount,
+			"time":             new(big.Int).SetUint64(nonce),
+			"hyperliquidChain": req.HyperliquidChain,
+			"signatureChainId": req.SignatureChainId,
+		}
 
-	// Note: The signature is generated based on the *action* content and the *request* nonce.
-	sig, err := signTransferUSD(e, actionT)
+		// Note: The signature is generated based on the *action* content and the *request* nonce.
+		sig, err := signTransferUSD(e, actionT)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to sign Transfer USD action: %w", err)
+		}
+		return action, sig, nil
+	}
+	nonce, err := e.NextNonce()
+	if err != nil {
+		return nil, err
+	}
+	action, sig, err := sign(nonce)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign Transfer USD action: %w", err)
+		return nil, err
 	}
 
 	// The response structure for transfer USD might be simpler (e.g., just status confirmation)
 	// Adjust parsing if needed based on actual API response.
-	respType, statuses, err := e.PostActionAndParseResponse(action, sig, nonce)
+	respType, statuses, err := e.PostActionAndParseResponse(action, sig, nonce, sdk.Resign(sign))
 	if err != nil {
 		return nil, fmt.Errorf("transferUSDC request failed: %w", err)
 	}
@@ -84,7 +95,7 @@ func signTransferUSDPayload() []apitypes.Type {
 	}
 }
 
-func signTransferUSD(e *sdk.Exchange, action apitypes.TypedDataMessage) (*sdk.Signature, error) {
+func signTransferUSD(e sdk.ActionPoster, action apitypes.TypedDataMessage) (*sdk.Signature, error) {
 	payload := signTransferUSDPayload()
 	return sdk.SignUserSignedAction(e.Signer(), action, payload, transferUSDPrimaryType)
 }
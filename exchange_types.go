@@ -18,7 +18,10 @@ const (
 	TakeProfit string = "tp"
 	StopLose   string = "sl"
 
-	GroupingNa   string = "na"
+	GroupingNa           string = "na"
+	GroupingNormalTpsl   string = "normalTpsl"   // entry order followed by linked tp/sl children
+	GroupingPositionTpsl string = "positionTpsl" // tp/sl attached to the whole position
+	// Deprecated: the exchange expects GroupingNormalTpsl or GroupingPositionTpsl.
 	GroupingTpsl string = "tpsl"
 )
 
@@ -79,14 +82,35 @@ type OrderRequest struct {
 	LimitPx    float64
 	OrderType  OrderType
 	ReduceOnly bool
-	Cloid      *string
+	Cloid      *Cloid
 }
 
+// ModifyRequest identifies the order to modify by exactly one of Oid or
+// Cloid. Use NewModifyByOid or NewModifyByCloid to build one.
 type ModifyRequest struct {
-	Oid          any
+	Oid          uint64
+	Cloid        *Cloid
 	OrderRequest OrderRequest
 }
 
+func NewModifyByOid(oid uint64, order OrderRequest) ModifyRequest {
+	return ModifyRequest{Oid: oid, OrderRequest: order}
+}
+
+func NewModifyByCloid(cloid Cloid, order OrderRequest) ModifyRequest {
+	return ModifyRequest{Cloid: &cloid, OrderRequest: order}
+}
+
+func (req *ModifyRequest) Validate() error {
+	if req.Oid == 0 && req.Cloid == nil {
+		return ValidationError{Field: "Oid", Message: "one of Oid or Cloid is required"}
+	}
+	if req.Oid != 0 && req.Cloid != nil {
+		return ValidationError{Field: "Oid", Message: "only one of Oid or Cloid may be set"}
+	}
+	return nil
+}
+
 type CancelRequest struct {
 	Coin string
 	Oid  uint64
@@ -97,14 +121,29 @@ type MarketRequest struct {
 	IsBuy       bool
 	ReduceOnly  bool
 	Size        float64
-	MarketPrice float64
+	MarketPrice float64 // zero prices the order from the exchange's PriceSource
 	Slippage    float64
-	Cloid       *string
+	Cloid       *Cloid
+	// PriceFromBook prices the order from the worst book level needed to fill
+	// Size, see EstimateImpact. Slippage is then applied on top of that level.
+	PriceFromBook bool
+}
+
+type TwapRequest struct {
+	Coin       string
+	IsBuy      bool
+	Size       float64
+	ReduceOnly bool
+	Minutes    int
+	Randomize  bool
 }
 
+// TwapID identifies a native TWAP order on the exchange.
+type TwapID uint64
+
 type CancelByCloidRequest struct {
 	Coin  string
-	Cloid string
+	Cloid Cloid
 }
 
 type OrderType struct {
@@ -138,7 +177,7 @@ type OrderWire struct {
 }
 
 type ModifyWire struct {
-	Oid   any       `json:"oid" msgpack:"oid"`
+	Oid   any       `json:"oid" msgpack:"oid"` // uint64 oid or cloid string
 	Order OrderWire `json:"order" msgpack:"order"`
 }
 
@@ -152,6 +191,15 @@ type CancelByCloidWire struct {
 	Cloid string `json:"cloid" msgpack:"cloid"`
 }
 
+type TwapWire struct {
+	Asset      int    `json:"a" msgpack:"a"`
+	IsBuy      bool   `json:"b" msgpack:"b"`
+	Size       string `json:"s" msgpack:"s"`
+	ReduceOnly bool   `json:"r" msgpack:"r"`
+	Minutes    int    `json:"m" msgpack:"m"`
+	Randomize  bool   `json:"t" msgpack:"t"`
+}
+
 type TriggerOrderTypeWire struct {
 	TriggerPx string `json:"triggerPx" msgpack:"triggerPx"`
 	IsMarket  bool   `json:"isMarket" msgpack:"isMarket"`
@@ -181,19 +229,34 @@ func (req *OrderRequest) ToWire(asset, assetDec int) OrderWire {
 		Size:       FloatToString(RoundToDecimal(req.Size, assetDec)),
 	}
 	if req.Cloid != nil {
-		wire.Cloid = *req.Cloid
+		wire.Cloid = req.Cloid.String()
 	}
 
 	return wire
 }
 
 func (req *ModifyRequest) ToWire(asset, assetDec int) ModifyWire {
+	var oid any = req.Oid
+	if req.Cloid != nil {
+		oid = req.Cloid.String()
+	}
 	return ModifyWire{
-		Oid:   req.Oid,
+		Oid:   oid,
 		Order: req.OrderRequest.ToWire(asset, assetDec),
 	}
 }
 
+func (req *TwapRequest) ToWire(asset, assetDec int) TwapWire {
+	return TwapWire{
+		Asset:      asset,
+		IsBuy:      req.IsBuy,
+		Size:       FloatToString(RoundToDecimal(req.Size, assetDec)),
+		ReduceOnly: req.ReduceOnly,
+		Minutes:    req.Minutes,
+		Randomize:  req.Randomize,
+	}
+}
+
 func (req *CancelRequest) ToWire(asset int) CancelWire {
 	return CancelWire{
 		Asset: asset,
@@ -204,7 +267,7 @@ func (req *CancelRequest) ToWire(asset int) CancelWire {
 func (req *CancelByCloidRequest) ToWire(asset int) CancelByCloidWire {
 	return CancelByCloidWire{
 		Asset: asset,
-		Cloid: req.Cloid,
+		Cloid: req.Cloid.String(),
 	}
 }
 
@@ -311,6 +374,34 @@ func (action *VaultUsdTransferAction) Tp() string {
 	return action.Type
 }
 
+type ScheduleCancelAction struct {
+	Type string  `json:"type" msgpack:"type"`
+	Time *uint64 `json:"time,omitempty" msgpack:"time,omitempty"` // nil removes the scheduled cancel
+}
+
+func (action *ScheduleCancelAction) Tp() string {
+	return action.Type
+}
+
+type TwapOrderAction struct {
+	Type string   `json:"type" msgpack:"type"`
+	Twap TwapWire `json:"twap" msgpack:"twap"`
+}
+
+func (action *TwapOrderAction) Tp() string {
+	return action.Type
+}
+
+type TwapCancelAction struct {
+	Type   string `json:"type" msgpack:"type"`
+	Asset  int    `json:"a" msgpack:"a"`
+	TwapID TwapID `json:"t" msgpack:"t"`
+}
+
+func (action *TwapCancelAction) Tp() string {
+	return action.Type
+}
+
 // Response related
 
 type ExchangeRestingOrder struct {
@@ -376,6 +467,17 @@ func (s *ExchangeDataStatus) Parse() any {
 
 type ExchangeDataStatuses struct {
 	Statuses []ExchangeDataStatus `json:"statuses"`
+	// Status is set instead of Statuses by single-result actions such as twapOrder.
+	Status json.RawMessage `json:"status,omitempty"`
+}
+
+type TwapRunningStatus struct {
+	TwapID TwapID `json:"twapId"`
+}
+
+type TwapOrderStatus struct {
+	Running *TwapRunningStatus `json:"running"`
+	Error   *string            `json:"error"`
 }
 
 type ExchangeSuccessResponse struct {
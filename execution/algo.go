@@ -0,0 +1,556 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusPaused    Status = "paused"
+	StatusCompleted Status = "completed"
+	// StatusExpired is reported by a TWAP that reached the end of its
+	// schedule without filling its whole size.
+	StatusExpired  Status = "expired"
+	StatusCanceled Status = "canceled"
+	StatusFailed   Status = "failed"
+)
+
+// Terminal reports whether the algorithm has ended.
+func (s Status) Terminal() bool {
+	return s != StatusRunning && s != StatusPaused
+}
+
+// Progress is a snapshot of an algorithm.
+type Progress struct {
+	ID       string
+	Coin     string
+	IsBuy    bool
+	Status   Status
+	Size     float64
+	FilledSz float64
+	AvgPx    float64
+	Fees     float64
+	// OpenSz is the size of child orders resting or in flight.
+	OpenSz float64
+	// Slices counts the child orders sent.
+	Slices    int
+	Err       error
+	UpdatedAt time.Time
+}
+
+// ProgressFunc is called after every fill and status change of an algorithm.
+type ProgressFunc func(Progress)
+
+// child is an order sent by an algorithm. Its fills are known from the REST
+// result for IOC orders and from userFills for all orders; whichever reports
+// more is used, so the two never count twice.
+type child struct {
+	size       float64
+	px         float64
+	open       bool
+	wsFilled   float64
+	wsNotional float64
+	fees       float64
+	tids       map[int64]struct{}
+	respFilled float64
+	respAvgPx  float64
+}
+
+func (c *child) filled() (float64, float64) {
+	if c.wsFilled >= c.respFilled {
+		return c.wsFilled, c.wsNotional
+	}
+	return c.respFilled, c.respFilled * c.respAvgPx
+}
+
+// strategy is the part of an algorithm deciding what to send. step is called
+// when the algorithm is running and woken by a timer, a fill or an order
+// update. It returns when to be called again, or a terminal status.
+type strategy interface {
+	step(ctx context.Context) (time.Duration, Status, error)
+}
+
+// Algo is a running execution algorithm.
+type Algo struct {
+	id         string
+	engine     *Engine
+	coin       string
+	isBuy      bool
+	size       float64
+	onProgress ProgressFunc
+
+	mu       sync.Mutex
+	status   Status
+	err      error
+	children map[sdk.Cloid]*child
+	slices   int
+	updated  time.Time
+
+	wake       chan struct{}
+	cancel     chan struct{}
+	cancelOnce sync.Once
+	done       chan struct{}
+}
+
+func newAlgo(engine *Engine, coin string, isBuy bool, size float64, onProgress ProgressFunc) *Algo {
+	return &Algo{
+		engine:     engine,
+		coin:       coin,
+		isBuy:      isBuy,
+		size:       size,
+		onProgress: onProgress,
+		status:     StatusRunning,
+		children:   make(map[sdk.Cloid]*child),
+		updated:    time.Now(),
+		wake:       make(chan struct{}, 1),
+		cancel:     make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+func (a *Algo) ID() string {
+	return a.id
+}
+
+// Pause cancels the resting child orders and stops sending new ones until
+// Resume.
+func (a *Algo) Pause() {
+	a.setStatus(StatusRunning, StatusPaused)
+}
+
+func (a *Algo) Resume() {
+	a.setStatus(StatusPaused, StatusRunning)
+}
+
+// Cancel stops the algorithm and cancels its resting child orders.
+func (a *Algo) Cancel() {
+	a.cancelOnce.Do(func() { close(a.cancel) })
+}
+
+// Done is closed once the algorithm has ended.
+func (a *Algo) Done() <-chan struct{} {
+	return a.done
+}
+
+// Wait blocks until the algorithm has ended and returns its final progress.
+func (a *Algo) Wait() Progress {
+	<-a.done
+	return a.Progress()
+}
+
+func (a *Algo) Progress() Progress {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.progressLocked()
+}
+
+func (a *Algo) progressLocked() Progress {
+	p := Progress{
+		ID:        a.id,
+		Coin:      a.coin,
+		IsBuy:     a.isBuy,
+		Status:    a.status,
+		Size:      a.size,
+		Slices:    a.slices,
+		Err:       a.err,
+		UpdatedAt: a.updated,
+	}
+	var notional float64
+	for _, c := range a.children {
+		filled, ntl := c.filled()
+		p.FilledSz += filled
+		notional += ntl
+		p.Fees += c.fees
+		if c.open {
+			p.OpenSz += max(c.size-filled, 0)
+		}
+	}
+	if p.FilledSz > 0 {
+		p.AvgPx = notional / p.FilledSz
+	}
+	return p
+}
+
+func (a *Algo) setStatus(from, to Status) {
+	a.mu.Lock()
+	if a.status != from {
+		a.mu.Unlock()
+		return
+	}
+	a.status = to
+	a.updated = time.Now()
+	p := a.progressLocked()
+	a.mu.Unlock()
+
+	a.signal()
+	a.report(p)
+}
+
+func (a *Algo) signal() {
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (a *Algo) report(p Progress) {
+	if a.onProgress != nil {
+		a.onProgress(p)
+	}
+}
+
+func (a *Algo) onFill(fill sdk.WsFill) {
+	a.mu.Lock()
+	c, ok := a.children[*fill.Cloid]
+	if !ok {
+		a.mu.Unlock()
+		return
+	}
+	if _, seen := c.tids[fill.Tid]; seen {
+		a.mu.Unlock()
+		return
+	}
+	c.tids[fill.Tid] = struct{}{}
+	px, _ := strconv.ParseFloat(fill.Px, 64)
+	sz, _ := strconv.ParseFloat(fill.Sz, 64)
+	fee, _ := strconv.ParseFloat(fill.Fee, 64)
+	c.wsFilled += sz
+	c.wsNotional += px * sz
+	c.fees += fee
+	if filled, _ := c.filled(); filled >= c.size-sizeEpsilon {
+		c.open = false
+	}
+	a.updated = time.Now()
+	p := a.progressLocked()
+	a.mu.Unlock()
+
+	a.signal()
+	a.report(p)
+}
+
+func (a *Algo) onOrderUpdate(update sdk.WsOrder) {
+	if !isClosed(update.Status) {
+		return
+	}
+	a.mu.Lock()
+	c, ok := a.children[*update.Order.Cloid]
+	if !ok || !c.open {
+		a.mu.Unlock()
+		return
+	}
+	c.open = false
+	// until userFills catches up, the sizes of the update tell how much
+	// filled, at the child's price
+	origSz, _ := strconv.ParseFloat(update.Order.OrigSz, 64)
+	sz, _ := strconv.ParseFloat(update.Order.Sz, 64)
+	if filled := origSz - sz; filled > c.respFilled {
+		c.respFilled = filled
+		c.respAvgPx = c.px
+	}
+	a.updated = time.Now()
+	p := a.progressLocked()
+	a.mu.Unlock()
+
+	a.signal()
+	a.report(p)
+}
+
+func (a *Algo) remaining() float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.remainingLocked()
+}
+
+// remainingLocked returns the size neither filled nor resting.
+func (a *Algo) remainingLocked() float64 {
+	p := a.progressLocked()
+	return max(a.size-p.FilledSz-p.OpenSz, 0)
+}
+
+// run drives s until the algorithm ends.
+func (a *Algo) run(ctx context.Context, s strategy) {
+	defer close(a.done)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			a.finish(StatusCanceled, ctx.Err())
+			return
+		case <-a.cancel:
+			a.finish(StatusCanceled, nil)
+			return
+		case <-a.wake:
+		case <-timer.C:
+		}
+
+		if done, err := a.filledUp(); err != nil || done {
+			a.finish(StatusCompleted, err)
+			return
+		}
+		a.mu.Lock()
+		paused := a.status == StatusPaused
+		a.mu.Unlock()
+		if paused {
+			if a.cancelOpen() {
+				// try again later rather than on every wake-up
+				timer.Reset(idleWait)
+			}
+			continue
+		}
+
+		next, final, err := s.step(ctx)
+		if err != nil {
+			a.finish(StatusFailed, err)
+			return
+		}
+		if final != "" {
+			a.finish(final, nil)
+			return
+		}
+		timer.Reset(next)
+	}
+}
+
+// filledUp reports whether what is left to fill is below the size step of
+// the coin.
+func (a *Algo) filledUp() (bool, error) {
+	a.mu.Lock()
+	p := a.progressLocked()
+	a.mu.Unlock()
+	left, err := a.engine.exchange.RoundSize(a.coin, a.size-p.FilledSz)
+	if err != nil {
+		return false, err
+	}
+	return left <= 0, nil
+}
+
+func (a *Algo) finish(status Status, err error) {
+	a.cancelOpen()
+	if err != nil && status == StatusCompleted {
+		status = StatusFailed
+	}
+	a.mu.Lock()
+	a.status = status
+	a.err = err
+	a.updated = time.Now()
+	p := a.progressLocked()
+	a.mu.Unlock()
+
+	a.report(p)
+}
+
+// sliceSize rounds the size of the next child order, capped by what is left.
+func (a *Algo) sliceSize(size float64) (float64, error) {
+	return a.engine.exchange.RoundSize(a.coin, min(size, a.remaining()))
+}
+
+// passivePrice returns the price of a post-only child: the touch on the
+// algorithm's own side, capped by limitPx when set.
+func (a *Algo) passivePrice(limitPx float64) (float64, error) {
+	prices := a.engine.cfg.Prices
+	if prices == nil {
+		if limitPx <= 0 {
+			return 0, fmt.Errorf("post-only slices need a LimitPx or Config.Prices")
+		}
+		return limitPx, nil
+	}
+	px, err := prices.ReferencePrice(a.coin, !a.isBuy)
+	if err != nil {
+		return 0, err
+	}
+	if limitPx > 0 {
+		if a.isBuy {
+			px = min(px, limitPx)
+		} else {
+			px = max(px, limitPx)
+		}
+	}
+	return a.engine.exchange.RoundPrice(a.coin, px)
+}
+
+// postOnly places a post-only child order. A child that would cross is
+// reported by retry instead of an error.
+func (a *Algo) postOnly(size, px float64) (retry bool, err error) {
+	status, err := a.send(size, px, func(cloid *sdk.Cloid) (any, error) {
+		return a.engine.exchange.Order(sdk.OrderRequest{
+			Coin:      a.coin,
+			IsBuy:     a.isBuy,
+			Size:      size,
+			LimitPx:   px,
+			OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifAlo}},
+			Cloid:     cloid,
+		}, nil)
+	})
+	if err != nil {
+		return false, err
+	}
+	if statusErr, ok := status.(error); ok {
+		if isPostOnlyReject(statusErr) {
+			return true, nil
+		}
+		return false, statusErr
+	}
+	return false, nil
+}
+
+// take sends an IOC child order, priced at limitPx when set and slippage
+// away from the exchange's reference price otherwise. Child orders the book
+// cannot fill at the price are not errors.
+func (a *Algo) take(size, limitPx, slippage float64) error {
+	status, err := a.send(size, limitPx, func(cloid *sdk.Cloid) (any, error) {
+		if limitPx > 0 {
+			return a.engine.exchange.Order(sdk.OrderRequest{
+				Coin:      a.coin,
+				IsBuy:     a.isBuy,
+				Size:      size,
+				LimitPx:   limitPx,
+				OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifIoc}},
+				Cloid:     cloid,
+			}, nil)
+		}
+		return a.engine.exchange.MarketOrder(sdk.MarketRequest{
+			Coin:     a.coin,
+			IsBuy:    a.isBuy,
+			Size:     size,
+			Slippage: slippage,
+			Cloid:    cloid,
+		}, nil)
+	})
+	if err != nil {
+		return err
+	}
+	if statusErr, ok := status.(error); ok && !isNoMatch(statusErr) {
+		return statusErr
+	}
+	return nil
+}
+
+// send registers a child before it is sent, so that its fills are accounted
+// even if they arrive before the result, then applies the result.
+func (a *Algo) send(size, px float64, place func(cloid *sdk.Cloid) (any, error)) (any, error) {
+	cloid := a.engine.newChild(a)
+	a.mu.Lock()
+	a.children[cloid] = &child{size: size, px: px, open: true, tids: make(map[int64]struct{})}
+	a.slices++
+	a.mu.Unlock()
+
+	status, err := place(&cloid)
+
+	a.mu.Lock()
+	c := a.children[cloid]
+	switch s := status.(type) {
+	case *sdk.ExchangeRestingOrder:
+		// stays open until filled or canceled
+	case *sdk.ExchangeFilledOrder:
+		c.respFilled, _ = strconv.ParseFloat(s.TotalSize, 64)
+		c.respAvgPx, _ = strconv.ParseFloat(s.AveragePx, 64)
+		c.open = false
+	default:
+		c.open = false
+	}
+	if err != nil {
+		c.open = false
+	}
+	if c.open {
+		// an IOC never rests, whatever the result says
+		if filled, _ := c.filled(); filled >= c.size-sizeEpsilon {
+			c.open = false
+		}
+	}
+	a.updated = time.Now()
+	p := a.progressLocked()
+	a.mu.Unlock()
+
+	a.report(p)
+	return status, err
+}
+
+// cancelOpen cancels the resting child orders and reports whether any of
+// them is still open because its cancel failed.
+func (a *Algo) cancelOpen() bool {
+	a.mu.Lock()
+	var reqs []sdk.CancelByCloidRequest
+	for cloid, c := range a.children {
+		if c.open {
+			reqs = append(reqs, sdk.CancelByCloidRequest{Coin: a.coin, Cloid: cloid})
+		}
+	}
+	a.mu.Unlock()
+	if len(reqs) == 0 {
+		return false
+	}
+
+	statuses, err := a.engine.exchange.BulkCancelByCloid(reqs)
+	if err != nil {
+		return true
+	}
+	a.mu.Lock()
+	open := false
+	for i, req := range reqs {
+		c := a.children[req.Cloid]
+		if i < len(statuses) {
+			if _, failed := statuses[i].(error); failed {
+				// most likely filled meanwhile; the stream will tell
+				open = open || c.open
+				continue
+			}
+		}
+		c.open = false
+	}
+	p := a.progressLocked()
+	a.mu.Unlock()
+
+	a.report(p)
+	return open
+}
+
+// openChild returns the cloid and price of a resting child order, if any.
+func (a *Algo) openChild() (sdk.Cloid, float64, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for cloid, c := range a.children {
+		if c.open {
+			return cloid, c.px, true
+		}
+	}
+	return sdk.Cloid{}, 0, false
+}
+
+const (
+	sizeEpsilon = 1e-9
+	// idleWait is how long a step waits when only a fill or order update
+	// can make progress, as a safety net for missed events.
+	idleWait = 30 * time.Second
+	// DefaultSlippage applies to IOC slices without a LimitPx.
+	DefaultSlippage = 0.05
+)
+
+// isClosed reports whether an orderUpdates status ends the order.
+func isClosed(status string) bool {
+	return status == "filled" || status == "canceled" || status == "rejected" ||
+		strings.HasSuffix(status, "Canceled") || strings.HasSuffix(status, "Rejected")
+}
+
+func isPostOnlyReject(err error) bool {
+	return strings.Contains(err.Error(), "Post only")
+}
+
+func isNoMatch(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "could not immediately match")
+}
+
+func priceMoved(a, b float64) bool {
+	return math.Abs(a-b) > sizeEpsilon*max(math.Abs(a), 1)
+}
@@ -0,0 +1,212 @@
+package execution
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+type Config struct {
+	// User is the account whose fills and order updates are followed,
+	// defaults to the exchange's account.
+	User string
+	// Tag is embedded in the cloid of every child order, see
+	// sdk.CloidGenerator.
+	Tag uint16
+	// Instance of the cloid generator, random if zero.
+	Instance uint16
+	// Prices prices post-only slices at the touch when an algorithm has no
+	// LimitPx, e.g. sdk.NewBookPriceSource(info). ReferencePrice is asked
+	// for the opposite side, so a buy joins the best bid.
+	Prices sdk.PriceSource
+}
+
+// Engine runs execution algorithms on an exchange. It follows the userFills
+// and orderUpdates streams once for all algorithms and routes every event to
+// the algorithm owning the child order by cloid, so fills are accounted in
+// one place whichever of the REST result and the stream arrives first.
+type Engine struct {
+	exchange sdk.Trader
+	ws       *sdk.WebsocketClient
+	cfg      Config
+	cloids   *sdk.CloidGenerator
+
+	mu      sync.Mutex
+	algos   map[string]*Algo
+	byCloid map[sdk.Cloid]*Algo
+	subs    []subscription
+	nextID  int
+}
+
+type subscription struct {
+	sub sdk.Subscription
+	id  int
+}
+
+func NewEngine(exchange sdk.Trader, ws *sdk.WebsocketClient, cfg Config) *Engine {
+	if cfg.User == "" {
+		cfg.User = exchange.AccountAddress().Hex()
+	}
+	instance := cfg.Instance
+	if instance == 0 {
+		instance = sdk.RandomInstanceTag()
+	}
+	return &Engine{
+		exchange: exchange,
+		ws:       ws,
+		cfg:      cfg,
+		cloids:   sdk.NewCloidGenerator(instance),
+		algos:    make(map[string]*Algo),
+		byCloid:  make(map[sdk.Cloid]*Algo),
+	}
+}
+
+// Start subscribes to the fills and order updates of the configured user.
+func (e *Engine) Start() error {
+	fillsID, err := e.ws.SubscribeToUserFills(e.cfg.User, e.HandleUserFills)
+	if err != nil {
+		return err
+	}
+	updatesID, err := e.ws.SubscribeToOrderUpdates(e.cfg.User, e.HandleOrderUpdates)
+	if err != nil {
+		_ = e.ws.Unsubscribe(sdk.Subscription{Type: sdk.SubTypeUserFills, User: e.cfg.User}, fillsID)
+		return err
+	}
+	e.mu.Lock()
+	e.subs = append(e.subs,
+		subscription{sdk.Subscription{Type: sdk.SubTypeUserFills, User: e.cfg.User}, fillsID},
+		subscription{sdk.Subscription{Type: sdk.SubTypeOrderUpdates, User: e.cfg.User}, updatesID},
+	)
+	e.mu.Unlock()
+	return nil
+}
+
+// Stop cancels every running algorithm, waits for them to end and
+// unsubscribes from the streams.
+func (e *Engine) Stop() {
+	e.mu.Lock()
+	algos := make([]*Algo, 0, len(e.algos))
+	for _, algo := range e.algos {
+		algos = append(algos, algo)
+	}
+	subs := e.subs
+	e.subs = nil
+	e.mu.Unlock()
+
+	for _, algo := range algos {
+		algo.Cancel()
+	}
+	for _, algo := range algos {
+		<-algo.Done()
+	}
+	for _, s := range subs {
+		_ = e.ws.Unsubscribe(s.sub, s.id)
+	}
+}
+
+// Algo returns the algorithm with the given id.
+func (e *Engine) Algo(id string) (*Algo, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	algo, ok := e.algos[id]
+	return algo, ok
+}
+
+// Algos returns all algorithms not yet removed.
+func (e *Engine) Algos() []*Algo {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	algos := make([]*Algo, 0, len(e.algos))
+	for _, algo := range e.algos {
+		algos = append(algos, algo)
+	}
+	return algos
+}
+
+// Remove forgets a finished algorithm. Fills of its children that arrive
+// afterwards are no longer accounted.
+func (e *Engine) Remove(id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	algo, ok := e.algos[id]
+	if !ok {
+		return nil
+	}
+	if !algo.Progress().Status.Terminal() {
+		return fmt.Errorf("algo %s is still running", id)
+	}
+	delete(e.algos, id)
+	for cloid, owner := range e.byCloid {
+		if owner == algo {
+			delete(e.byCloid, cloid)
+		}
+	}
+	return nil
+}
+
+// HandleUserFills is a websocket callback for the userFills channel.
+func (e *Engine) HandleUserFills(msg sdk.WSMessage) {
+	var data sdk.WsUserFills
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		return
+	}
+	for _, fill := range data.Fills {
+		if fill.Cloid == nil {
+			continue
+		}
+		if algo := e.owner(*fill.Cloid); algo != nil {
+			algo.onFill(fill)
+		}
+	}
+}
+
+// HandleOrderUpdates is a websocket callback for the orderUpdates channel.
+func (e *Engine) HandleOrderUpdates(msg sdk.WSMessage) {
+	var updates []sdk.WsOrder
+	if err := json.Unmarshal(msg.Data, &updates); err != nil {
+		return
+	}
+	for _, update := range updates {
+		if update.Order.Cloid == nil {
+			continue
+		}
+		if algo := e.owner(*update.Order.Cloid); algo != nil {
+			algo.onOrderUpdate(update)
+		}
+	}
+}
+
+func (e *Engine) owner(cloid sdk.Cloid) *Algo {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.byCloid[cloid]
+}
+
+// newChild assigns a cloid to a child order of algo.
+func (e *Engine) newChild(algo *Algo) sdk.Cloid {
+	cloid := e.cloids.NextWithTag(e.cfg.Tag)
+	e.mu.Lock()
+	e.byCloid[cloid] = algo
+	e.mu.Unlock()
+	return cloid
+}
+
+func (e *Engine) register(kind string, algo *Algo) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.nextID++
+	algo.id = fmt.Sprintf("%s-%d", kind, e.nextID)
+	e.algos[algo.id] = algo
+}
+
+func (e *Engine) isOwnTrade(trade sdk.Trade) bool {
+	for _, user := range trade.Users {
+		if strings.EqualFold(user, e.cfg.User) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,325 @@
+package execution_test
+
+import (
+	"context"
+	"encoding/hex"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/execution"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+type testEnv struct {
+	srv    *hltest.Server
+	signer *sdk.LocalSigner
+	engine *execution.Engine
+}
+
+// newEnv starts an engine trading SOL, quoted 149/151, on a funded account.
+// prices sets Config.Prices to the book of the server.
+func newEnv(t *testing.T, prices bool) *testEnv {
+	t.Helper()
+	srv := hltest.NewServer(hltest.Config{})
+	t.Cleanup(srv.Close)
+	key, _ := crypto.GenerateKey()
+	signer, err := sdk.NewLocalSignerFromHex(hex.EncodeToString(crypto.FromECDSA(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Fund(signer.Address(), 10_000)
+	srv.SetBook("SOL", []sdk.Level{{Px: 149, Sz: 100}}, []sdk.Level{{Px: 151, Sz: 100}})
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+	info, err := sdk.NewInfo(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws := sdk.NewWebsocketClient(srv.URL)
+	// the connection lives as long as the context it was opened with
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+
+	cfg := execution.Config{}
+	if prices {
+		cfg.Prices = sdk.NewBookPriceSource(info)
+	}
+	engine := execution.NewEngine(exchange, ws, cfg)
+	if err := engine.Start(); err != nil {
+		t.Fatalf("Failed to start engine: %v", err)
+	}
+	t.Cleanup(engine.Stop)
+	time.Sleep(100 * time.Millisecond)
+	return &testEnv{srv: srv, signer: signer, engine: engine}
+}
+
+func (env *testEnv) openOrders() []sdk.OpenOrder {
+	return env.srv.Paper(env.signer.Address()).OpenOrders()
+}
+
+func (env *testEnv) requests(typ string) int {
+	n := 0
+	for _, req := range env.srv.Requests() {
+		if req.Type == typ {
+			n++
+		}
+	}
+	return n
+}
+
+// waitFor polls cond until it holds or fails the test after five seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func wait(t *testing.T, algo *execution.Algo) execution.Progress {
+	t.Helper()
+	select {
+	case <-algo.Done():
+		return algo.Progress()
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Algo did not end: %+v", algo.Progress())
+		return execution.Progress{}
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestTWAPSlices(t *testing.T) {
+	env := newEnv(t, false)
+	algo, err := env.engine.TWAP(context.Background(), execution.TWAPParams{
+		Coin:     "SOL",
+		IsBuy:    true,
+		Size:     1,
+		Duration: 200 * time.Millisecond,
+		Slices:   4,
+		LimitPx:  152,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := wait(t, algo)
+	if p.Status != execution.StatusCompleted || !near(p.FilledSz, 1) || !near(p.AvgPx, 151) || p.Slices != 4 {
+		t.Errorf("Unexpected progress: %+v", p)
+	}
+	if n := env.requests("order"); n != 4 {
+		t.Errorf("Expected 4 child orders, got %d", n)
+	}
+
+	// the stream catching up must not count the fills twice
+	time.Sleep(100 * time.Millisecond)
+	p = algo.Progress()
+	if !near(p.FilledSz, 1) || !near(p.AvgPx, 151) || p.Fees <= 0 || p.OpenSz != 0 {
+		t.Errorf("Unexpected progress after the stream: %+v", p)
+	}
+}
+
+func TestTWAPExpired(t *testing.T) {
+	env := newEnv(t, false)
+	algo, err := env.engine.TWAP(context.Background(), execution.TWAPParams{
+		Coin:     "SOL",
+		IsBuy:    true,
+		Size:     1,
+		Duration: 100 * time.Millisecond,
+		Slices:   2,
+		LimitPx:  140,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := wait(t, algo)
+	if p.Status != execution.StatusExpired || p.FilledSz != 0 || p.Err != nil {
+		t.Errorf("Unexpected progress: %+v", p)
+	}
+}
+
+func TestIcebergRefresh(t *testing.T) {
+	env := newEnv(t, false)
+	algo, err := env.engine.Iceberg(context.Background(), execution.IcebergParams{
+		Coin:          "SOL",
+		IsBuy:         true,
+		Size:          1,
+		DisplaySize:   0.5,
+		LimitPx:       150,
+		RetryInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		waitFor(t, "a resting slice", func() bool {
+			orders := env.openOrders()
+			return len(orders) == 1 && orders[0].Size == 0.5
+		})
+		// a seller takes the slice, then the book moves back
+		env.srv.SetBook("SOL", []sdk.Level{{Px: 148, Sz: 100}}, []sdk.Level{{Px: 149, Sz: 100}})
+		env.srv.SetBook("SOL", []sdk.Level{{Px: 149, Sz: 100}}, []sdk.Level{{Px: 151, Sz: 100}})
+	}
+	p := wait(t, algo)
+	if p.Status != execution.StatusCompleted || !near(p.FilledSz, 1) || !near(p.AvgPx, 150) || p.Slices < 2 {
+		t.Errorf("Unexpected progress: %+v", p)
+	}
+	if orders := env.openOrders(); len(orders) != 0 {
+		t.Errorf("Expected no open orders, got %+v", orders)
+	}
+}
+
+func TestIcebergReprice(t *testing.T) {
+	env := newEnv(t, true)
+	algo, err := env.engine.Iceberg(context.Background(), execution.IcebergParams{
+		Coin:            "SOL",
+		IsBuy:           true,
+		Size:            1,
+		DisplaySize:     0.5,
+		RepriceInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	restingAt := func(px float64) func() bool {
+		return func() bool {
+			orders := env.openOrders()
+			return len(orders) == 1 && orders[0].LimitPx == px
+		}
+	}
+	waitFor(t, "a slice at the bid", restingAt(149))
+
+	env.srv.SetBook("SOL", []sdk.Level{{Px: 149.5, Sz: 100}}, []sdk.Level{{Px: 151, Sz: 100}})
+	waitFor(t, "the slice to follow the bid", restingAt(149.5))
+
+	algo.Cancel()
+	p := wait(t, algo)
+	if p.Status != execution.StatusCanceled || p.FilledSz != 0 || p.OpenSz != 0 {
+		t.Errorf("Unexpected progress: %+v", p)
+	}
+	if orders := env.openOrders(); len(orders) != 0 {
+		t.Errorf("Expected no open orders, got %+v", orders)
+	}
+}
+
+func TestIcebergRepriceCancelFails(t *testing.T) {
+	env := newEnv(t, true)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	algo, err := env.engine.Iceberg(ctx, execution.IcebergParams{
+		Coin:            "SOL",
+		IsBuy:           true,
+		Size:            1,
+		DisplaySize:     0.5,
+		RepriceInterval: 10 * time.Millisecond,
+		RetryInterval:   100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "a resting slice", func() bool { return len(env.openOrders()) == 1 })
+
+	errs := make([]hltest.Response, 100)
+	for i := range errs {
+		errs[i] = hltest.ExchangeError("rate limited")
+	}
+	env.srv.Script("/exchange", "cancelByCloid", errs...)
+	env.srv.SetBook("SOL", []sdk.Level{{Px: 149.5, Sz: 100}}, []sdk.Level{{Px: 151, Sz: 100}})
+	time.Sleep(300 * time.Millisecond)
+
+	// one attempt per RetryInterval, not a loop of cancels
+	if n := env.requests("cancelByCloid"); n == 0 || n > 5 {
+		t.Errorf("Expected a few cancel attempts, got %d", n)
+	}
+	if p := algo.Progress(); p.Status != execution.StatusRunning || p.Slices != 1 {
+		t.Errorf("Unexpected progress: %+v", p)
+	}
+}
+
+func TestAlgoPauseResumeCancel(t *testing.T) {
+	env := newEnv(t, false)
+	algo, err := env.engine.Iceberg(context.Background(), execution.IcebergParams{
+		Coin:        "SOL",
+		IsBuy:       true,
+		Size:        1,
+		DisplaySize: 0.5,
+		LimitPx:     150,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "a resting slice", func() bool { return len(env.openOrders()) == 1 })
+
+	algo.Pause()
+	waitFor(t, "the slice to be canceled", func() bool { return len(env.openOrders()) == 0 })
+	time.Sleep(50 * time.Millisecond)
+	if p := algo.Progress(); p.Status != execution.StatusPaused || p.OpenSz != 0 || p.Slices != 1 {
+		t.Errorf("Unexpected progress while paused: %+v", p)
+	}
+
+	algo.Resume()
+	waitFor(t, "a slice after resuming", func() bool { return len(env.openOrders()) == 1 })
+	if p := algo.Progress(); p.Status != execution.StatusRunning || p.Slices != 2 {
+		t.Errorf("Unexpected progress after resuming: %+v", p)
+	}
+
+	algo.Cancel()
+	p := wait(t, algo)
+	if p.Status != execution.StatusCanceled || p.Err != nil {
+		t.Errorf("Unexpected progress: %+v", p)
+	}
+	if orders := env.openOrders(); len(orders) != 0 {
+		t.Errorf("Expected no open orders, got %+v", orders)
+	}
+	if err := env.engine.Remove(algo.ID()); err != nil {
+		t.Errorf("Remove failed: %v", err)
+	}
+	if _, ok := env.engine.Algo(algo.ID()); ok {
+		t.Error("Expected the algo to be removed")
+	}
+}
+
+func TestPOVParticipation(t *testing.T) {
+	env := newEnv(t, false)
+	algo, err := env.engine.POV(context.Background(), execution.POVParams{
+		Coin:     "SOL",
+		IsBuy:    true,
+		Size:     2,
+		Rate:     0.2,
+		Interval: 20 * time.Millisecond,
+		LimitPx:  152,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	other := "0x0000000000000000000000000000000000000001"
+	trade := sdk.Trade{Coin: "SOL", Side: "B", Px: "151", Sz: "4", Users: []string{other, other}}
+
+	// 20% of the volume is a quarter of everyone else's
+	env.srv.AddTrades(trade)
+	waitFor(t, "the first child", func() bool { return near(algo.Progress().FilledSz, 1) })
+	time.Sleep(100 * time.Millisecond)
+	if p := algo.Progress(); !near(p.FilledSz, 1) || p.Status != execution.StatusRunning {
+		t.Errorf("Expected to stay at the rate, got %+v", p)
+	}
+
+	trade.Tid = 2
+	env.srv.AddTrades(trade)
+	p := wait(t, algo)
+	if p.Status != execution.StatusCompleted || !near(p.FilledSz, 2) || !near(p.AvgPx, 151) || p.Slices != 2 {
+		t.Errorf("Unexpected progress: %+v", p)
+	}
+}
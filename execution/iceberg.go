@@ -0,0 +1,110 @@
+package execution
+
+import (
+	"context"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+type IcebergParams struct {
+	Coin  string
+	IsBuy bool
+	Size  float64
+	// DisplaySize is the size of each visible slice.
+	DisplaySize float64
+	// LimitPx is the price of the slices. Zero joins the touch, which needs
+	// Config.Prices; with both set the touch is capped at LimitPx.
+	LimitPx float64
+	// RepriceInterval is how often a resting slice is moved to the touch
+	// when it changed. Zero leaves slices where they were placed.
+	RepriceInterval time.Duration
+	// RetryInterval is the wait after a slice was rejected for crossing the
+	// book or could not be canceled to reprice it, defaults to one second.
+	RetryInterval time.Duration
+	OnProgress    ProgressFunc
+}
+
+func (p *IcebergParams) Validate() error {
+	if p.Size <= 0 {
+		return sdk.ValidationError{Field: "Size", Message: "must be positive"}
+	}
+	if p.DisplaySize <= 0 {
+		return sdk.ValidationError{Field: "DisplaySize", Message: "must be positive"}
+	}
+	if p.LimitPx < 0 {
+		return sdk.ValidationError{Field: "LimitPx", Message: "must not be negative"}
+	}
+	return nil
+}
+
+// Iceberg works Size as a sequence of post-only slices of DisplaySize, only
+// one of them resting at a time. A slice is replenished once it filled.
+func (e *Engine) Iceberg(ctx context.Context, params IcebergParams) (*Algo, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if params.LimitPx == 0 && e.cfg.Prices == nil {
+		return nil, sdk.ValidationError{Field: "LimitPx", Message: "required without Config.Prices"}
+	}
+	if params.RetryInterval <= 0 {
+		params.RetryInterval = time.Second
+	}
+	algo := newAlgo(e, params.Coin, params.IsBuy, params.Size, params.OnProgress)
+	e.register("iceberg", algo)
+	go algo.run(ctx, &iceberg{algo: algo, params: params})
+	return algo, nil
+}
+
+type iceberg struct {
+	algo   *Algo
+	params IcebergParams
+}
+
+func (s *iceberg) step(_ context.Context) (time.Duration, Status, error) {
+	a := s.algo
+	repricing := s.params.RepriceInterval > 0 && a.engine.cfg.Prices != nil
+
+	if _, px, ok := a.openChild(); ok {
+		if !repricing {
+			return idleWait, "", nil
+		}
+		touch, err := a.passivePrice(s.params.LimitPx)
+		if err != nil {
+			return 0, "", err
+		}
+		if !priceMoved(px, touch) {
+			return s.params.RepriceInterval, "", nil
+		}
+		if a.cancelOpen() {
+			// the cancel failed, try again later rather than right away
+			return s.params.RetryInterval, "", nil
+		}
+		// the next step places the slice again at the new price
+		return 0, "", nil
+	}
+
+	size, err := a.sliceSize(s.params.DisplaySize)
+	if err != nil {
+		return 0, "", err
+	}
+	if size <= 0 {
+		// the rest is in flight
+		return idleWait, "", nil
+	}
+	px, err := a.passivePrice(s.params.LimitPx)
+	if err != nil {
+		return 0, "", err
+	}
+	retry, err := a.postOnly(size, px)
+	if err != nil {
+		return 0, "", err
+	}
+	if retry {
+		return s.params.RetryInterval, "", nil
+	}
+	if repricing {
+		return s.params.RepriceInterval, "", nil
+	}
+	return idleWait, "", nil
+}
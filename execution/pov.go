@@ -0,0 +1,145 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+type POVParams struct {
+	Coin  string
+	IsBuy bool
+	Size  float64
+	// Rate is the targeted share of the traded volume, e.g. 0.1 for 10%.
+	Rate float64
+	// MinSlice holds back child orders until the shortfall reaches it,
+	// defaults to the coin's size step. MaxSlice caps a child order, zero
+	// means no cap.
+	MinSlice float64
+	MaxSlice float64
+	// Interval is how often the shortfall is checked, defaults to one second.
+	Interval time.Duration
+	// LimitPx caps the price of every child order; zero prices them
+	// Slippage away from the exchange's PriceSource.
+	LimitPx    float64
+	Slippage   float64 // defaults to DefaultSlippage
+	OnProgress ProgressFunc
+}
+
+func (p *POVParams) Validate() error {
+	if p.Size <= 0 {
+		return sdk.ValidationError{Field: "Size", Message: "must be positive"}
+	}
+	if p.Rate <= 0 || p.Rate >= 1 {
+		return sdk.ValidationError{Field: "Rate", Message: "must be between 0 and 1"}
+	}
+	if p.MinSlice < 0 || p.MaxSlice < 0 {
+		return sdk.ValidationError{Field: "MinSlice", Message: "slice bounds must not be negative"}
+	}
+	if p.MaxSlice > 0 && p.MinSlice > p.MaxSlice {
+		return sdk.ValidationError{Field: "MinSlice", Message: "must not exceed MaxSlice"}
+	}
+	if p.LimitPx < 0 {
+		return sdk.ValidationError{Field: "LimitPx", Message: "must not be negative"}
+	}
+	return nil
+}
+
+// POV follows the trades stream of the coin and sends IOC child orders so
+// that the algorithm's fills stay at Rate of the total volume traded since
+// it started. Trades of the account itself are not counted as market
+// volume, so the target is Rate/(1-Rate) of everyone else's volume.
+func (e *Engine) POV(ctx context.Context, params POVParams) (*Algo, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if params.Interval <= 0 {
+		params.Interval = time.Second
+	}
+	if params.Slippage == 0 {
+		params.Slippage = DefaultSlippage
+	}
+	algo := newAlgo(e, params.Coin, params.IsBuy, params.Size, params.OnProgress)
+	s := &pov{algo: algo, params: params}
+
+	sub := sdk.Subscription{Type: sdk.SubTypeTrades, Coin: params.Coin}
+	id, err := e.ws.SubscribeToTrades(params.Coin, s.onTrades)
+	if err != nil {
+		return nil, err
+	}
+	e.register("pov", algo)
+	go algo.run(ctx, s)
+	go func() {
+		<-algo.Done()
+		_ = e.ws.Unsubscribe(sub, id)
+	}()
+	return algo, nil
+}
+
+type pov struct {
+	algo   *Algo
+	params POVParams
+	next   time.Time
+
+	mu     sync.Mutex
+	volume float64
+}
+
+func (s *pov) onTrades(msg sdk.WSMessage) {
+	var trades []sdk.Trade
+	if err := json.Unmarshal(msg.Data, &trades); err != nil {
+		return
+	}
+	var volume float64
+	for _, trade := range trades {
+		if trade.Coin != s.params.Coin || s.algo.engine.isOwnTrade(trade) {
+			continue
+		}
+		sz, _ := strconv.ParseFloat(trade.Sz, 64)
+		volume += sz
+	}
+	if volume == 0 {
+		return
+	}
+	s.mu.Lock()
+	s.volume += volume
+	s.mu.Unlock()
+}
+
+func (s *pov) step(_ context.Context) (time.Duration, Status, error) {
+	now := time.Now()
+	if now.Before(s.next) {
+		return s.next.Sub(now), "", nil
+	}
+	s.next = now.Add(s.params.Interval)
+
+	s.mu.Lock()
+	volume := s.volume
+	s.mu.Unlock()
+
+	a := s.algo
+	p := a.Progress()
+	target := min(volume*s.params.Rate/(1-s.params.Rate), s.params.Size)
+	shortfall := target - p.FilledSz - p.OpenSz
+	remaining := s.params.Size - p.FilledSz - p.OpenSz
+	if shortfall < s.params.MinSlice && shortfall < remaining {
+		return s.params.Interval, "", nil
+	}
+	if s.params.MaxSlice > 0 {
+		shortfall = min(shortfall, s.params.MaxSlice)
+	}
+	size, err := a.sliceSize(shortfall)
+	if err != nil {
+		return 0, "", err
+	}
+	if size > 0 {
+		if err := a.take(size, s.params.LimitPx, s.params.Slippage); err != nil {
+			return 0, "", err
+		}
+	}
+	return s.params.Interval, "", nil
+}
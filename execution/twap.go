@@ -0,0 +1,122 @@
+package execution
+
+import (
+	"context"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+type TWAPParams struct {
+	Coin     string
+	IsBuy    bool
+	Size     float64
+	Duration time.Duration
+	// Slices is the number of child orders, defaults to one every 30
+	// seconds. Whatever a slice did not fill is added to the next one.
+	Slices int
+	// PostOnly rests every slice at the touch until the next one is due,
+	// instead of sending IOC orders. Pricing follows IcebergParams.LimitPx.
+	PostOnly bool
+	// LimitPx caps the price of every slice; zero prices IOC slices
+	// Slippage away from the exchange's PriceSource.
+	LimitPx    float64
+	Slippage   float64 // defaults to DefaultSlippage
+	OnProgress ProgressFunc
+}
+
+func (p *TWAPParams) Validate() error {
+	if p.Size <= 0 {
+		return sdk.ValidationError{Field: "Size", Message: "must be positive"}
+	}
+	if p.Duration <= 0 {
+		return sdk.ValidationError{Field: "Duration", Message: "must be positive"}
+	}
+	if p.Slices < 0 {
+		return sdk.ValidationError{Field: "Slices", Message: "must not be negative"}
+	}
+	if p.LimitPx < 0 {
+		return sdk.ValidationError{Field: "LimitPx", Message: "must not be negative"}
+	}
+	return nil
+}
+
+// TWAP works Size in equal slices spread over Duration. Pausing shifts the
+// rest of the schedule by the time spent paused. A TWAP that did not fill by
+// the end of its schedule ends as StatusExpired.
+func (e *Engine) TWAP(ctx context.Context, params TWAPParams) (*Algo, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if params.PostOnly && params.LimitPx == 0 && e.cfg.Prices == nil {
+		return nil, sdk.ValidationError{Field: "LimitPx", Message: "required for post-only slices without Config.Prices"}
+	}
+	if params.Slices == 0 {
+		params.Slices = max(int(params.Duration/(30*time.Second)), 1)
+	}
+	if params.Slippage == 0 {
+		params.Slippage = DefaultSlippage
+	}
+	algo := newAlgo(e, params.Coin, params.IsBuy, params.Size, params.OnProgress)
+	e.register("twap", algo)
+	go algo.run(ctx, &twap{
+		algo:     algo,
+		params:   params,
+		interval: params.Duration / time.Duration(params.Slices),
+	})
+	return algo, nil
+}
+
+type twap struct {
+	algo     *Algo
+	params   TWAPParams
+	interval time.Duration
+	sent     int
+	next     time.Time
+}
+
+func (s *twap) step(_ context.Context) (time.Duration, Status, error) {
+	now := time.Now()
+	if now.Before(s.next) {
+		// woken by a fill
+		return s.next.Sub(now), "", nil
+	}
+	if s.sent == s.params.Slices {
+		return 0, StatusExpired, nil
+	}
+
+	a := s.algo
+	if s.params.PostOnly {
+		a.cancelOpen()
+	}
+	s.sent++
+	s.next = now.Add(s.interval)
+
+	p := a.Progress()
+	size := s.params.Size*float64(s.sent)/float64(s.params.Slices) - p.FilledSz - p.OpenSz
+	size, err := a.sliceSize(size)
+	if err != nil {
+		return 0, "", err
+	}
+	if size > 0 {
+		if s.params.PostOnly {
+			px, err := a.passivePrice(s.params.LimitPx)
+			if err != nil {
+				return 0, "", err
+			}
+			// a slice that would cross is skipped and carried over
+			if _, err := a.postOnly(size, px); err != nil {
+				return 0, "", err
+			}
+		} else if err := a.take(size, s.params.LimitPx, s.params.Slippage); err != nil {
+			return 0, "", err
+		}
+	}
+
+	if s.sent == s.params.Slices && !s.params.PostOnly {
+		// IOC results are final, no need to wait out the last interval
+		s.next = now
+		return 0, "", nil
+	}
+	return s.interval, "", nil
+}
@@ -0,0 +1,4 @@
+package sdk
+
+// AdjustPrice exposes adjustPrice to the sdk_test package.
+var AdjustPrice = adjustPrice
@@ -0,0 +1,214 @@
+package sdk
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxBatchSize is the number of orders or cancels sent per action by the
+// batching helpers. A batch of n costs 1 + n/40 rate limit weight, so larger
+// batches are charged extra.
+const MaxBatchSize = 40
+
+const (
+	SideBid = "B"
+	SideAsk = "A"
+)
+
+// CancelAllFilter narrows CancelAll down. Empty fields match everything.
+type CancelAllFilter struct {
+	Coin string
+	Side string // SideBid or SideAsk
+	// CloidPrefix matches the 0x-prefixed hex form of the cloid, e.g. the
+	// first 6 characters select a strategy tag of CloidGenerator.
+	CloidPrefix string
+}
+
+func (f CancelAllFilter) match(order FrontendOpenOrder) bool {
+	if f.Coin != "" && order.Coin != f.Coin {
+		return false
+	}
+	if f.Side != "" && order.Side != f.Side {
+		return false
+	}
+	if f.CloidPrefix != "" {
+		if order.Cloid == nil || !strings.HasPrefix(order.Cloid.String(), f.CloidPrefix) {
+			return false
+		}
+	}
+	return true
+}
+
+type CancelFailure struct {
+	Order FrontendOpenOrder
+	Err   error
+}
+
+type CancelAllResult struct {
+	Canceled []FrontendOpenOrder
+	Failed   []CancelFailure
+	// Remaining holds the matching orders still open after the cancels were sent.
+	Remaining []FrontendOpenOrder
+}
+
+type CloseFailure struct {
+	Coin string
+	Size float64
+	Err  error
+}
+
+type ClosedPosition struct {
+	Coin   string
+	Size   float64 // signed size that was closed
+	Status any     // same possible types as the results of BulkOrders
+}
+
+type CloseAllResult struct {
+	Closed []ClosedPosition
+	Failed []CloseFailure
+	// Remaining holds the positions still open after the close orders were sent.
+	Remaining []Position
+}
+
+// CancelAll cancels every open order of the account that matches filter,
+// including trigger orders. Cancels are sent in batches of MaxBatchSize; a
+// failing batch does not stop the remaining ones. The open orders are queried
+// again afterwards to report what is left.
+func (e *Exchange) CancelAll(filter CancelAllFilter) (*CancelAllResult, error) {
+	user := e.AccountAddress().Hex()
+	orders, err := e.info.FrontendOpenOrders(user)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]FrontendOpenOrder, 0, len(orders))
+	for _, order := range orders {
+		if filter.match(order) {
+			matched = append(matched, order)
+		}
+	}
+
+	result := new(CancelAllResult)
+	for batch := range slices.Chunk(matched, MaxBatchSize) {
+		reqs := make([]CancelRequest, len(batch))
+		for i, order := range batch {
+			reqs[i] = CancelRequest{Coin: order.Coin, Oid: uint64(order.Oid)}
+		}
+		statuses, err := e.BulkCancel(reqs)
+		for i, order := range batch {
+			failErr := err
+			if failErr == nil {
+				failErr, _ = StatusAt(statuses, i).(error)
+			}
+			if failErr != nil {
+				result.Failed = append(result.Failed, CancelFailure{Order: order, Err: failErr})
+				continue
+			}
+			result.Canceled = append(result.Canceled, order)
+		}
+	}
+
+	if len(matched) == 0 {
+		return result, nil
+	}
+	left, err := e.info.FrontendOpenOrders(user)
+	if err != nil {
+		return result, fmt.Errorf("query remaining orders: %w", err)
+	}
+	for _, order := range left {
+		if filter.match(order) {
+			result.Remaining = append(result.Remaining, order)
+		}
+	}
+	return result, nil
+}
+
+// CloseAllPositions closes every perp position of the account with
+// reduce-only IOC orders priced slippage away from the exchange's PriceSource.
+func (e *Exchange) CloseAllPositions(slippage float64) (*CloseAllResult, error) {
+	user := e.AccountAddress().Hex()
+	state, err := e.info.UserState(user)
+	if err != nil {
+		return nil, err
+	}
+	positions := openPositions(state)
+
+	result := new(CloseAllResult)
+	if len(positions) == 0 {
+		return result, nil
+	}
+
+	source, err := e.batchPriceSource(len(positions))
+	if err != nil {
+		return nil, err
+	}
+
+	type closing struct {
+		coin  string
+		szi   float64
+		order OrderRequest
+	}
+	pending := make([]closing, 0, len(positions))
+	for _, pos := range positions {
+		szi, _ := strconv.ParseFloat(pos.Szi, 64)
+		isBuy := szi < 0
+		px, err := e.marketPrice(source, pos.Coin, isBuy, slippage, 0)
+		if err != nil {
+			result.Failed = append(result.Failed, CloseFailure{Coin: pos.Coin, Size: szi, Err: err})
+			continue
+		}
+		pending = append(pending, closing{coin: pos.Coin, szi: szi, order: OrderRequest{
+			Coin:    pos.Coin,
+			IsBuy:   isBuy,
+			Size:    math.Abs(szi),
+			LimitPx: px,
+			OrderType: OrderType{
+				Limit: &LimitOrderType{Tif: TifIoc},
+			},
+			ReduceOnly: true,
+		}})
+	}
+
+	for batch := range slices.Chunk(pending, MaxBatchSize) {
+		orders := make([]OrderRequest, len(batch))
+		for i, p := range batch {
+			orders[i] = p.order
+		}
+		statuses, err := e.BulkOrders(orders, nil)
+		for i, p := range batch {
+			failErr := err
+			var status any
+			if failErr == nil {
+				status = StatusAt(statuses, i)
+				failErr, _ = status.(error)
+			}
+			if failErr != nil {
+				result.Failed = append(result.Failed, CloseFailure{Coin: p.coin, Size: p.szi, Err: failErr})
+				continue
+			}
+			result.Closed = append(result.Closed, ClosedPosition{Coin: p.coin, Size: p.szi, Status: status})
+		}
+	}
+
+	state, err = e.info.UserState(user)
+	if err != nil {
+		return result, fmt.Errorf("query remaining positions: %w", err)
+	}
+	result.Remaining = openPositions(state)
+	return result, nil
+}
+
+func openPositions(state *UserState) []Position {
+	positions := make([]Position, 0, len(state.AssetPositions))
+	for _, ap := range state.AssetPositions {
+		szi, err := strconv.ParseFloat(ap.Position.Szi, 64)
+		if err != nil || szi == 0 {
+			continue
+		}
+		positions = append(positions, ap.Position)
+	}
+	return positions
+}
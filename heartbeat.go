@@ -0,0 +1,236 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxScheduleCancelTriggers is the number of times a scheduled cancel may
+	// fire per UTC day before the exchange rejects further schedules.
+	MaxScheduleCancelTriggers = 10
+
+	minScheduleCancelDelay = 5 * time.Second
+)
+
+type HeartbeatConfig struct {
+	// Timeout is how far in the future the cancel is scheduled on every beat.
+	// It must be at least 5s, the minimum delay the exchange accepts, and
+	// defaults to 1 minute.
+	Timeout time.Duration
+	// Interval is how often the scheduled cancel is pushed forward.
+	Interval time.Duration
+	// Websocket is optional. When set, beats are only sent while it is
+	// connected and has received a frame within MaxWsSilence.
+	Websocket    *WebsocketClient
+	MaxWsSilence time.Duration
+
+	// OnBeat is called after the scheduled cancel has been pushed forward.
+	OnBeat func(HeartbeatStatus)
+	// OnLapse is called when a beat is skipped because the websocket is unhealthy.
+	OnLapse func(HeartbeatStatus)
+	// OnError is called when scheduling the cancel fails.
+	OnError func(error)
+}
+
+// HeartbeatStatus reports the state of the dead man's switch.
+type HeartbeatStatus struct {
+	CancelAt          time.Time     // zero if nothing is scheduled
+	Remaining         time.Duration // time left before CancelAt fires
+	TriggersUsed      int           // scheduled cancels observed to fire today (UTC)
+	TriggersRemaining int
+}
+
+// Heartbeat is a dead man's switch built on scheduleCancel. While running it
+// keeps pushing the cancel time forward; if the process dies, or the
+// configured websocket stops being healthy, the beats stop and the exchange
+// cancels all open orders once the last scheduled time passes.
+type Heartbeat struct {
+	exchange *Exchange
+	cfg      HeartbeatConfig
+
+	mu       sync.Mutex
+	cancelAt time.Time
+	day      string
+	triggers int
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewHeartbeat(exchange *Exchange, cfg HeartbeatConfig) (*Heartbeat, error) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Minute
+	}
+	if cfg.Timeout < minScheduleCancelDelay {
+		return nil, ValidationError{
+			Field:   "Timeout",
+			Message: fmt.Sprintf("must be at least %v, got %v", minScheduleCancelDelay, cfg.Timeout),
+		}
+	}
+	if cfg.Interval <= 0 || cfg.Interval >= cfg.Timeout {
+		cfg.Interval = cfg.Timeout / 4
+	}
+	if cfg.MaxWsSilence <= 0 {
+		cfg.MaxWsSilence = 75 * time.Second
+	}
+	return &Heartbeat{
+		exchange: exchange,
+		cfg:      cfg,
+	}, nil
+}
+
+// Start sends the first beat synchronously and then keeps beating in the
+// background until ctx is done or Stop is called.
+func (h *Heartbeat) Start(ctx context.Context) error {
+	h.mu.Lock()
+	if h.stop != nil {
+		h.mu.Unlock()
+		return fmt.Errorf("heartbeat already started")
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	h.stop, h.done = stop, done
+	h.mu.Unlock()
+
+	if err := h.beat(); err != nil {
+		h.mu.Lock()
+		h.stop, h.done = nil, nil
+		h.mu.Unlock()
+		return err
+	}
+
+	go h.run(ctx, stop, done)
+	return nil
+}
+
+// Stop stops pushing the cancel time forward. The last scheduled cancel stays
+// armed and fires when it is reached.
+func (h *Heartbeat) Stop() {
+	h.mu.Lock()
+	stop, done := h.stop, h.done
+	h.stop, h.done = nil, nil
+	h.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// Disarm stops the heartbeat and removes the scheduled cancel.
+func (h *Heartbeat) Disarm() error {
+	h.Stop()
+	if err := h.exchange.ScheduleCancel(nil); err != nil {
+		return fmt.Errorf("remove scheduled cancel: %w", err)
+	}
+	h.mu.Lock()
+	h.cancelAt = time.Time{}
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *Heartbeat) Status() HeartbeatStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.statusLocked(h.exchange.Clock().Now())
+}
+
+func (h *Heartbeat) run(ctx context.Context, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(h.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// release the heartbeat so it can be started again, unless
+			// Stop already took it
+			h.mu.Lock()
+			if h.stop == stop {
+				h.stop, h.done = nil, nil
+			}
+			h.mu.Unlock()
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+			_ = h.beat()
+		}
+	}
+}
+
+func (h *Heartbeat) beat() error {
+	// the deadline is checked against the server time, see WithServerClock
+	now := h.exchange.Clock().Now()
+
+	h.mu.Lock()
+	h.rollDayLocked(now)
+	if !h.cancelAt.IsZero() && !now.Before(h.cancelAt) {
+		// the previous schedule lapsed without being refreshed
+		h.triggers++
+		h.cancelAt = time.Time{}
+	}
+	h.mu.Unlock()
+
+	if !h.websocketHealthy(time.Now()) {
+		if h.cfg.OnLapse != nil {
+			h.cfg.OnLapse(h.Status())
+		}
+		return nil
+	}
+
+	cancelAt := now.Add(h.cfg.Timeout)
+	t := uint64(cancelAt.UnixMilli())
+	if err := h.exchange.ScheduleCancel(&t); err != nil {
+		err = fmt.Errorf("schedule cancel: %w", err)
+		if h.cfg.OnError != nil {
+			h.cfg.OnError(err)
+		}
+		return err
+	}
+
+	h.mu.Lock()
+	h.cancelAt = cancelAt
+	status := h.statusLocked(now)
+	h.mu.Unlock()
+
+	if h.cfg.OnBeat != nil {
+		h.cfg.OnBeat(status)
+	}
+	return nil
+}
+
+func (h *Heartbeat) websocketHealthy(now time.Time) bool {
+	ws := h.cfg.Websocket
+	if ws == nil {
+		return true
+	}
+	if !ws.IsConnected() {
+		return false
+	}
+	last := ws.LastMessageTime()
+	return !last.IsZero() && now.Sub(last) <= h.cfg.MaxWsSilence
+}
+
+func (h *Heartbeat) rollDayLocked(now time.Time) {
+	day := now.UTC().Format(time.DateOnly)
+	if day != h.day {
+		h.day = day
+		h.triggers = 0
+	}
+}
+
+func (h *Heartbeat) statusLocked(now time.Time) HeartbeatStatus {
+	status := HeartbeatStatus{
+		CancelAt:          h.cancelAt,
+		TriggersUsed:      h.triggers,
+		TriggersRemaining: max(MaxScheduleCancelTriggers-h.triggers, 0),
+	}
+	if !h.cancelAt.IsZero() {
+		status.Remaining = max(h.cancelAt.Sub(now), 0)
+	}
+	return status
+}
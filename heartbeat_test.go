@@ -0,0 +1,69 @@
+package sdk_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+func TestHeartbeat(t *testing.T) {
+	srv, signer := newServer(t, hltest.Config{})
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+
+	if _, err := sdk.NewHeartbeat(exchange, sdk.HeartbeatConfig{Timeout: 2 * time.Second}); err == nil {
+		t.Error("Expected a timeout below 5s to be rejected")
+	}
+
+	hb, err := sdk.NewHeartbeat(exchange, sdk.HeartbeatConfig{Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hb.Start(context.Background()); err != nil {
+		t.Fatalf("Heartbeat start failed: %v", err)
+	}
+	hb.Stop()
+	if remaining := hb.Status().Remaining; remaining <= 9*time.Second || remaining > 10*time.Second {
+		t.Errorf("Expected about 10s before the cancel, got %v", remaining)
+	}
+	requests := srv.Requests()
+	var action struct {
+		Action sdk.ScheduleCancelAction `json:"action"`
+	}
+	if len(requests) != 1 || json.Unmarshal(requests[0].Body, &action) != nil || action.Action.Time == nil {
+		t.Fatalf("Expected one scheduleCancel request, got %+v", requests)
+	}
+	if err := hb.Disarm(); err != nil {
+		t.Errorf("Disarm failed: %v", err)
+	}
+}
+
+func TestHeartbeatRestartAfterContext(t *testing.T) {
+	srv, signer := newServer(t, hltest.Config{})
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+	hb, err := sdk.NewHeartbeat(exchange, sdk.HeartbeatConfig{Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := hb.Start(ctx); err != nil {
+		t.Fatalf("Heartbeat start failed: %v", err)
+	}
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for {
+		err := hb.Start(context.Background())
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Heartbeat could not be restarted after its context ended: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	hb.Stop()
+}
@@ -0,0 +1,143 @@
+package hltest
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/paper"
+)
+
+// maxNonces is how many of the highest nonces of a signer are kept.
+const maxNonces = 100
+
+type account struct {
+	user  common.Address
+	paper *paper.Exchange
+
+	mu          sync.Mutex
+	fills       []sdk.WsFill
+	leverage    map[string]sdk.Leverage
+	cancelTimer *time.Timer
+}
+
+// account returns the account of user, creating it if create is set and it
+// does not exist yet, and nil otherwise.
+func (s *Server) account(user common.Address, create bool) *account {
+	s.mu.Lock()
+	a, ok := s.accounts[user]
+	if ok || !create {
+		s.mu.Unlock()
+		return a
+	}
+	a = &account{
+		user: user,
+		paper: paper.NewExchange(nil, paper.Config{
+			Meta:    s.cfg.Meta,
+			Account: user,
+			Fees:    s.cfg.Fees,
+		}),
+		leverage: make(map[string]sdk.Leverage),
+	}
+	s.accounts[user] = a
+	books := make([]*sdk.L2Book, 0, len(s.books))
+	for _, book := range s.books {
+		books = append(books, book)
+	}
+	s.mu.Unlock()
+
+	coins := make([]string, len(s.cfg.Meta.Universe))
+	for i, asset := range s.cfg.Meta.Universe {
+		coins[i] = asset.Name
+	}
+	_ = a.paper.Watch(coins...)
+	for _, book := range books {
+		data, _ := json.Marshal(book)
+		a.paper.HandleL2Book(sdk.WSMessage{Channel: sdk.SubTypeL2Book, Data: data})
+	}
+
+	userSub := func(typ string) sdk.Subscription {
+		return sdk.Subscription{Type: typ, User: user.Hex()}
+	}
+	a.paper.SubscribeToUserFills(func(msg sdk.WSMessage) {
+		var fills sdk.WsUserFills
+		if err := json.Unmarshal(msg.Data, &fills); err == nil {
+			a.mu.Lock()
+			a.fills = append(a.fills, fills.Fills...)
+			a.mu.Unlock()
+		}
+		s.hub.publish(userSub(sdk.SubTypeUserFills), msg)
+	})
+	a.paper.SubscribeToOrderUpdates(func(msg sdk.WSMessage) {
+		s.hub.publish(userSub(sdk.SubTypeOrderUpdates), msg)
+	})
+	return a
+}
+
+func (s *Server) accountsLocked() []*account {
+	accounts := make([]*account, 0, len(s.accounts))
+	for _, a := range s.accounts {
+		accounts = append(accounts, a)
+	}
+	return accounts
+}
+
+// resolveLocked returns the account a signer acts for: its own, or that of
+// the user who approved it as an agent.
+func (s *Server) resolveLocked(signer common.Address) (*account, error) {
+	if a, ok := s.accounts[signer]; ok {
+		return a, nil
+	}
+	if user, ok := s.agents[signer]; ok {
+		if a, ok := s.accounts[user]; ok {
+			return a, nil
+		}
+	}
+	return nil, fmt.Errorf("User or API Wallet %s does not exist.", signer.Hex())
+}
+
+func (a *account) userFills() []sdk.WsFill {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	fills := slices.Clone(a.fills)
+	slices.Reverse(fills) // most recent first
+	return fills
+}
+
+// nonceSet holds the highest nonces used by a signer, in increasing order.
+type nonceSet struct {
+	nonces []uint64
+}
+
+// useNonceLocked checks a nonce of signer against the server time and the
+// nonces used before, and records it.
+func (s *Server) useNonceLocked(signer common.Address, nonce uint64) error {
+	now := time.Now()
+	window := uint64(s.cfg.NonceWindow.Milliseconds())
+	ms := uint64(now.UnixMilli())
+	if nonce > ms+window || nonce+2*window < ms {
+		return fmt.Errorf("Invalid nonce: nonce %d is outside of the allowed time window", nonce)
+	}
+	set, ok := s.nonces[signer]
+	if !ok {
+		set = new(nonceSet)
+		s.nonces[signer] = set
+	}
+	i, found := slices.BinarySearch(set.nonces, nonce)
+	if found {
+		return fmt.Errorf("Invalid nonce: duplicate nonce %d", nonce)
+	}
+	if len(set.nonces) >= maxNonces {
+		if i == 0 {
+			return fmt.Errorf("Invalid nonce: nonce %d is smaller than the smallest of the last %d", nonce, maxNonces)
+		}
+		set.nonces = slices.Delete(set.nonces, 0, 1)
+		i--
+	}
+	set.nonces = slices.Insert(set.nonces, i, nonce)
+	return nil
+}
@@ -0,0 +1,382 @@
+package hltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+// userSigned lists the EIP-712 types of the user-signed actions supported.
+// Their nonce is the field of type uint64.
+var userSigned = map[string]struct {
+	primaryType string
+	types       []apitypes.Type
+}{
+	"usdSend": {"HyperliquidTransaction:UsdSend", []apitypes.Type{
+		{Name: "hyperliquidChain", Type: "string"},
+		{Name: "destination", Type: "string"},
+		{Name: "amount", Type: "string"},
+		{Name: "time", Type: "uint64"},
+	}},
+	"approveAgent": {"HyperliquidTransaction:ApproveAgent", []apitypes.Type{
+		{Name: "hyperliquidChain", Type: "string"},
+		{Name: "agentAddress", Type: "address"},
+		{Name: "agentName", Type: "string"},
+		{Name: "nonce", Type: "uint64"},
+	}},
+	"approveBuilderFee": {"HyperliquidTransaction:ApproveBuilderFee", []apitypes.Type{
+		{Name: "hyperliquidChain", Type: "string"},
+		{Name: "maxFeeRate", Type: "string"},
+		{Name: "builder", Type: "address"},
+		{Name: "nonce", Type: "uint64"},
+	}},
+}
+
+type exchangeRequest struct {
+	Action       json.RawMessage `json:"action"`
+	Nonce        uint64          `json:"nonce"`
+	Signature    *sdk.Signature  `json:"signature"`
+	VaultAddress *common.Address `json:"vaultAddress"`
+	ExpiresAfter *uint64         `json:"expiresAfter"`
+}
+
+func (s *Server) handleExchange(req *Request) (any, error) {
+	var body exchangeRequest
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		return nil, err
+	}
+	if body.Signature == nil {
+		return nil, errors.New("missing signature")
+	}
+	if body.ExpiresAfter != nil && *body.ExpiresAfter < uint64(time.Now().UnixMilli()) {
+		return exchangeErr(fmt.Sprintf("Action expired at %d", *body.ExpiresAfter)), nil
+	}
+
+	if spec, ok := userSigned[req.Type]; ok {
+		return s.handleUserSigned(req.Type, spec.primaryType, spec.types, &body)
+	}
+
+	action, err := decodeL1Action(req.Type, body.Action)
+	if err != nil {
+		return exchangeErr(err.Error()), nil
+	}
+	// the SDK only signs for mainnet when talking to MainnetAPIURL
+	signer, err := sdk.RecoverL1ActionSigner(action, body.VaultAddress, body.Nonce, false, body.Signature)
+	if err != nil {
+		return exchangeErr(err.Error()), nil
+	}
+
+	s.mu.Lock()
+	a, err := s.resolveLocked(signer)
+	if err == nil && body.VaultAddress != nil {
+		var ok bool
+		if a, ok = s.accounts[*body.VaultAddress]; !ok {
+			err = fmt.Errorf("Vault %s does not exist.", body.VaultAddress.Hex())
+		}
+	}
+	if err == nil {
+		err = s.useNonceLocked(signer, body.Nonce)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return exchangeErr(err.Error()), nil
+	}
+	return s.execute(a, action), nil
+}
+
+// decodeL1Action decodes an action into the SDK type it was signed as, so
+// that it hashes to the same bytes.
+func decodeL1Action(typ string, data json.RawMessage) (sdk.Action, error) {
+	var action sdk.Action
+	switch typ {
+	case "order":
+		action = new(sdk.OrderAction)
+	case "batchModify":
+		action = new(sdk.ModifyAction)
+	case "cancel":
+		action = new(sdk.CancelAction)
+	case "cancelByCloid":
+		action = new(sdk.CancelByCloidAction)
+	case "updateLeverage":
+		action = new(sdk.UpdateLeverageAction)
+	case "updateIsolatedMargin":
+		action = new(sdk.UpdateIsolatedMarginAction)
+	case "vaultTransfer":
+		action = new(sdk.VaultUsdTransferAction)
+	case "scheduleCancel":
+		action = new(sdk.ScheduleCancelAction)
+	case "twapOrder":
+		action = new(sdk.TwapOrderAction)
+	case "twapCancel":
+		action = new(sdk.TwapCancelAction)
+	default:
+		return nil, fmt.Errorf("Unknown action type %q", typ)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(action); err != nil {
+		return nil, fmt.Errorf("Invalid action: %v", err)
+	}
+	// a modify by oid is signed as an integer
+	if modify, ok := action.(*sdk.ModifyAction); ok {
+		for i, m := range modify.Modifies {
+			if n, ok := m.Oid.(json.Number); ok {
+				oid, err := strconv.ParseUint(n.String(), 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("Invalid oid %s", n)
+				}
+				modify.Modifies[i].Oid = oid
+			}
+		}
+	}
+	return action, nil
+}
+
+func (s *Server) handleUserSigned(typ, primaryType string, types []apitypes.Type, body *exchangeRequest) (any, error) {
+	var fields map[string]any
+	dec := json.NewDecoder(bytes.NewReader(body.Action))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+	msg := apitypes.TypedDataMessage{"signatureChainId": fields["signatureChainId"]}
+	for _, field := range types {
+		v := fields[field.Name]
+		if field.Type == "uint64" {
+			n, ok := new(big.Int).SetString(fmt.Sprint(v), 10)
+			if !ok {
+				return exchangeErr(fmt.Sprintf("Invalid %s", field.Name)), nil
+			}
+			msg[field.Name] = n
+			continue
+		}
+		msg[field.Name] = v
+	}
+	signer, err := sdk.RecoverUserSignedActionSigner(msg, types, primaryType, body.Signature)
+	if err != nil {
+		return exchangeErr(err.Error()), nil
+	}
+
+	s.mu.Lock()
+	a, ok := s.accounts[signer]
+	if !ok {
+		err = fmt.Errorf("User %s does not exist.", signer.Hex())
+	} else {
+		err = s.useNonceLocked(signer, body.Nonce)
+	}
+	if err == nil && typ == "approveAgent" {
+		s.agents[common.HexToAddress(fmt.Sprint(fields["agentAddress"]))] = signer
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return exchangeErr(err.Error()), nil
+	}
+
+	if typ == "usdSend" {
+		amount, err := strconv.ParseFloat(fmt.Sprint(fields["amount"]), 64)
+		if err != nil || amount <= 0 {
+			return exchangeErr("Invalid amount"), nil
+		}
+		if a.paper.Balance() < amount {
+			return exchangeErr("Insufficient balance for transfer"), nil
+		}
+		a.paper.Deposit(-amount)
+		s.Fund(common.HexToAddress(fmt.Sprint(fields["destination"])), amount)
+	}
+	return exchangeOk("default", nil), nil
+}
+
+// execute runs an L1 action on the paper exchange of an account.
+func (s *Server) execute(a *account, action sdk.Action) any {
+	var statuses []any
+	var err error
+	switch action := action.(type) {
+	case *sdk.OrderAction:
+		var orders []sdk.OrderRequest
+		if orders, err = s.orderRequests(action.Orders); err == nil {
+			statuses, err = a.paper.BulkOrdersWithGrouping(orders, action.Grouping, action.Builder)
+		}
+		return statusesReply("order", statuses, err)
+
+	case *sdk.ModifyAction:
+		var reqs []sdk.ModifyRequest
+		if reqs, err = s.modifyRequests(action.Modifies); err == nil {
+			statuses, err = a.paper.BulkModifyOrders(reqs)
+		}
+		return statusesReply("order", statuses, err)
+
+	case *sdk.CancelAction:
+		reqs := make([]sdk.CancelRequest, len(action.Cancels))
+		for i, c := range action.Cancels {
+			reqs[i] = sdk.CancelRequest{Coin: s.coin(c.Asset), Oid: c.Oid}
+		}
+		statuses, err = a.paper.BulkCancel(reqs)
+		return statusesReply("cancel", statuses, err)
+
+	case *sdk.CancelByCloidAction:
+		reqs := make([]sdk.CancelByCloidRequest, len(action.Cancels))
+		for i, c := range action.Cancels {
+			cloid, perr := sdk.ParseCloid(c.Cloid)
+			if perr != nil {
+				return exchangeErr(perr.Error())
+			}
+			reqs[i] = sdk.CancelByCloidRequest{Coin: s.coin(c.Asset), Cloid: cloid}
+		}
+		statuses, err = a.paper.BulkCancelByCloid(reqs)
+		return statusesReply("cancel", statuses, err)
+
+	case *sdk.UpdateLeverageAction:
+		coin := s.coin(action.Asset)
+		if coin == "" {
+			return exchangeErr(fmt.Sprintf("Invalid asset %d", action.Asset))
+		}
+		typ := "isolated"
+		if action.IsCross {
+			typ = "cross"
+		}
+		a.mu.Lock()
+		a.leverage[coin] = sdk.Leverage{Type: typ, Value: action.Leverage}
+		a.mu.Unlock()
+		return exchangeOk("default", nil)
+
+	case *sdk.ScheduleCancelAction:
+		if action.Time != nil && *action.Time < uint64(time.Now().Add(5*time.Second).UnixMilli()) {
+			return exchangeErr("Scheduled cancel time too early, must be at least 5 seconds from now.")
+		}
+		a.scheduleCancel(action.Time)
+		return exchangeOk("default", nil)
+
+	case *sdk.UpdateIsolatedMarginAction, *sdk.VaultUsdTransferAction:
+		return exchangeOk("default", nil)
+	}
+	return exchangeErr(fmt.Sprintf("%s is not supported by hltest", action.Tp()))
+}
+
+// orderRequests converts order wires back to requests.
+func (s *Server) orderRequests(wires []sdk.OrderWire) ([]sdk.OrderRequest, error) {
+	orders := make([]sdk.OrderRequest, len(wires))
+	for i, w := range wires {
+		coin := s.coin(w.Asset)
+		if coin == "" {
+			return nil, fmt.Errorf("Invalid asset %d", w.Asset)
+		}
+		px, err1 := strconv.ParseFloat(w.LimitPx, 64)
+		size, err2 := strconv.ParseFloat(w.Size, 64)
+		if err1 != nil || err2 != nil {
+			return nil, errors.New("Invalid price or size")
+		}
+		order := sdk.OrderRequest{
+			Coin:       coin,
+			IsBuy:      w.IsBuy,
+			Size:       size,
+			LimitPx:    px,
+			ReduceOnly: w.ReduceOnly,
+			OrderType:  sdk.OrderType{Limit: w.OrderType.Limit},
+		}
+		if t := w.OrderType.Trigger; t != nil {
+			order.OrderType.Trigger = &sdk.TriggerOrderType{TriggerPx: t.TriggerPx, IsMarket: t.IsMarket, Tpsl: t.Tpsl}
+		}
+		if w.Cloid != "" {
+			cloid, err := sdk.ParseCloid(w.Cloid)
+			if err != nil {
+				return nil, err
+			}
+			order.Cloid = &cloid
+		}
+		orders[i] = order
+	}
+	return orders, nil
+}
+
+func (s *Server) modifyRequests(wires []sdk.ModifyWire) ([]sdk.ModifyRequest, error) {
+	reqs := make([]sdk.ModifyRequest, len(wires))
+	for i, m := range wires {
+		order, err := s.orderRequests([]sdk.OrderWire{m.Order})
+		if err != nil {
+			return nil, err
+		}
+		reqs[i].OrderRequest = order[0]
+		switch oid := m.Oid.(type) {
+		case uint64:
+			reqs[i].Oid = oid
+		case string:
+			cloid, err := sdk.ParseCloid(oid)
+			if err != nil {
+				return nil, err
+			}
+			reqs[i].Cloid = &cloid
+		default:
+			return nil, fmt.Errorf("Invalid oid %v", m.Oid)
+		}
+	}
+	return reqs, nil
+}
+
+func (s *Server) coin(asset int) string {
+	if asset < 0 || asset >= len(s.cfg.Meta.Universe) {
+		return ""
+	}
+	return s.cfg.Meta.Universe[asset].Name
+}
+
+// scheduleCancel arms, or disarms with a nil time, the dead man's switch
+// canceling all open orders.
+func (a *account) scheduleCancel(at *uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.cancelTimer != nil {
+		a.cancelTimer.Stop()
+		a.cancelTimer = nil
+	}
+	if at == nil {
+		return
+	}
+	a.cancelTimer = time.AfterFunc(time.Until(time.UnixMilli(int64(*at))), func() {
+		var reqs []sdk.CancelRequest
+		for _, o := range a.paper.OpenOrders() {
+			reqs = append(reqs, sdk.CancelRequest{Coin: o.Coin, Oid: uint64(o.Oid)})
+		}
+		_, _ = a.paper.BulkCancel(reqs)
+	})
+}
+
+func exchangeErr(msg string) map[string]any {
+	return map[string]any{"status": "err", "response": msg}
+}
+
+func exchangeOk(typ string, data any) map[string]any {
+	response := map[string]any{"type": typ}
+	if data != nil {
+		response["data"] = data
+	}
+	return map[string]any{"status": "ok", "response": response}
+}
+
+// statusesReply encodes statuses as the exchange does.
+func statusesReply(typ string, statuses []any, err error) map[string]any {
+	if err != nil {
+		return exchangeErr(err.Error())
+	}
+	wire := make([]any, len(statuses))
+	for i, status := range statuses {
+		switch v := status.(type) {
+		case error:
+			wire[i] = map[string]any{"error": v.Error()}
+		case *sdk.ExchangeRestingOrder:
+			wire[i] = map[string]any{"resting": v}
+		case *sdk.ExchangeFilledOrder:
+			wire[i] = map[string]any{"filled": v}
+		default:
+			wire[i] = v
+		}
+	}
+	return exchangeOk(typ, map[string]any{"statuses": wire})
+}
@@ -0,0 +1,115 @@
+package hltest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+type infoRequest struct {
+	Type string          `json:"type"`
+	User string          `json:"user"`
+	Coin string          `json:"coin"`
+	Oid  json.RawMessage `json:"oid"`
+}
+
+func (s *Server) handleInfo(req *Request) (any, error) {
+	var body infoRequest
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		return nil, err
+	}
+
+	switch body.Type {
+	case "meta":
+		return s.cfg.Meta, nil
+	case "spotMeta":
+		return sdk.SpotMeta{Universe: []sdk.SpotAssetInfo{}, Tokens: []sdk.SpotTokenInfo{}}, nil
+	case "allMids":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.midsLocked(), nil
+	case "l2Book":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if book, ok := s.books[body.Coin]; ok {
+			return book, nil
+		}
+		return nil, nil
+	case "userFees":
+		if s.cfg.Fees != nil {
+			return s.cfg.Fees, nil
+		}
+		return sdk.UserFees{
+			DailyUserVolume: []sdk.UserVolume{},
+			UserAddRate:     "0.00015",
+			UserCrossRate:   "0.00045",
+		}, nil
+	}
+
+	if !common.IsHexAddress(body.User) {
+		return nil, fmt.Errorf("unsupported info request %q", body.Type)
+	}
+	a := s.account(common.HexToAddress(body.User), false)
+	switch body.Type {
+	case "clearinghouseState":
+		if a == nil {
+			return emptyUserState(), nil
+		}
+		return a.userState(), nil
+	case "spotClearinghouseState":
+		return sdk.SpotState{Balances: []sdk.SpotStateBalance{}}, nil
+	case "openOrders":
+		if a == nil {
+			return []sdk.OpenOrder{}, nil
+		}
+		return a.paper.OpenOrders(), nil
+	case "frontendOpenOrders":
+		if a == nil {
+			return []sdk.FrontendOpenOrder{}, nil
+		}
+		return a.paper.FrontendOpenOrders(), nil
+	case "userFills", "userFillsByTime":
+		if a == nil {
+			return []sdk.WsFill{}, nil
+		}
+		return a.userFills(), nil
+	case "orderStatus":
+		if a != nil {
+			oid := strings.Trim(string(body.Oid), `"`)
+			for _, o := range a.paper.OpenOrders() {
+				if oid == fmt.Sprint(o.Oid) || (o.Cloid != nil && strings.EqualFold(oid, o.Cloid.String())) {
+					return o, nil
+				}
+			}
+		}
+		return map[string]any{"status": "unknownOid"}, nil
+	}
+	return nil, fmt.Errorf("unsupported info request %q", body.Type)
+}
+
+// userState returns the state of the paper exchange with the leverage set
+// by updateLeverage.
+func (a *account) userState() *sdk.UserState {
+	state := a.paper.UserState()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i, pos := range state.AssetPositions {
+		if leverage, ok := a.leverage[pos.Position.Coin]; ok {
+			state.AssetPositions[i].Position.Leverage = leverage
+		}
+	}
+	return state
+}
+
+func emptyUserState() *sdk.UserState {
+	summary := sdk.MarginSummary{AccountValue: "0", TotalMarginUsed: "0", TotalNtlPos: "0", TotalRawUsd: "0"}
+	return &sdk.UserState{
+		AssetPositions:     []sdk.AssetPosition{},
+		MarginSummary:      summary,
+		CrossMarginSummary: summary,
+		Withdrawable:       "0",
+	}
+}
@@ -0,0 +1,319 @@
+// Package hltest runs an in-process fake of the Hyperliquid API for tests.
+//
+// A Server serves /info, /exchange and /ws on a local address that the SDK
+// clients are pointed at in place of MainnetAPIURL:
+//
+//	srv := hltest.NewServer(hltest.Config{})
+//	defer srv.Close()
+//	srv.Fund(signer.Address(), 10_000)
+//	srv.SetBook("BTC", []sdk.Level{{Px: 99_999, Sz: 1}}, []sdk.Level{{Px: 100_001, Sz: 1}})
+//	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+//
+// Actions are checked like on the exchange: L1 and user-signed actions are
+// verified against their EIP-712 signatures as the SDK produces them, and
+// nonces must be unique, recent and above the smallest of the last 100 a
+// signer used. Orders of each account are matched by a paper.Exchange
+// against the book set by the test, so accounts never trade with each other.
+// Responses can be replaced, delayed or dropped with Script and SetLatency.
+package hltest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/paper"
+)
+
+type Config struct {
+	// Meta is the perp universe, defaults to DefaultMeta.
+	Meta *sdk.Meta
+	// Fees are the fee rates of every account, defaults to the base tier.
+	Fees *sdk.UserFees
+	// NonceWindow is how far a nonce may be from the server time, defaults to
+	// one day. Later nonces are rejected, and so are nonces more than twice
+	// the window earlier, like on the exchange.
+	NonceWindow time.Duration
+}
+
+// DefaultMeta is the universe served when Config.Meta is nil.
+var DefaultMeta = sdk.Meta{Universe: []sdk.AssetInfo{
+	{Name: "BTC", SzDecimals: 5},
+	{Name: "ETH", SzDecimals: 4},
+	{Name: "SOL", SzDecimals: 2},
+}}
+
+// Request is a request received by the server.
+type Request struct {
+	Path string // "/info" or "/exchange"
+	// Type is the info request type or the action type.
+	Type string
+	Body json.RawMessage
+	Time time.Time
+}
+
+// Response is a scripted reply.
+type Response struct {
+	// Status is the HTTP status, defaults to 200.
+	Status int
+	// Body is marshaled to JSON unless it is a string or []byte. A nil Body
+	// with a successful Status lets the server handle the request as usual,
+	// e.g. to only add a delay.
+	Body any
+	// Delay is waited before replying, on top of the latency.
+	Delay time.Duration
+	// Drop closes the connection without replying.
+	Drop bool
+}
+
+// ExchangeError is the reply of the exchange to a rejected action.
+func ExchangeError(msg string) Response {
+	return Response{Body: map[string]any{"status": "err", "response": msg}}
+}
+
+type Server struct {
+	// URL is the base URL to give to the SDK clients.
+	URL string
+
+	cfg  Config
+	http *httptest.Server
+	hub  *hub
+
+	mu       sync.Mutex
+	latency  time.Duration
+	scripts  map[scriptKey][]Response
+	requests []Request
+	books    map[string]*sdk.L2Book
+	accounts map[common.Address]*account
+	agents   map[common.Address]common.Address // agent to user
+	nonces   map[common.Address]*nonceSet
+}
+
+type scriptKey struct {
+	path string
+	typ  string
+}
+
+func NewServer(cfg Config) *Server {
+	if cfg.Meta == nil {
+		meta := DefaultMeta
+		cfg.Meta = &meta
+	}
+	if cfg.NonceWindow <= 0 {
+		cfg.NonceWindow = 24 * time.Hour
+	}
+	s := &Server{
+		cfg:      cfg,
+		hub:      newHub(),
+		scripts:  make(map[scriptKey][]Response),
+		books:    make(map[string]*sdk.L2Book),
+		accounts: make(map[common.Address]*account),
+		agents:   make(map[common.Address]common.Address),
+		nonces:   make(map[common.Address]*nonceSet),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/info", s.serve(s.handleInfo))
+	mux.HandleFunc("/exchange", s.serve(s.handleExchange))
+	mux.HandleFunc("/ws", s.hub.serve(s))
+	s.http = httptest.NewServer(mux)
+	s.URL = s.http.URL
+	return s
+}
+
+func (s *Server) Close() {
+	s.hub.close()
+	s.http.Close()
+}
+
+// Meta returns the perp universe, to pass to sdk.NewExchange.
+func (s *Server) Meta() *sdk.Meta {
+	return s.cfg.Meta
+}
+
+// Fund adds usdc to the balance of user, creating the account if needed.
+// Only funded accounts and their approved agents can send actions.
+func (s *Server) Fund(user common.Address, usdc float64) {
+	s.account(user, true).paper.Deposit(usdc)
+}
+
+// SetBook replaces the book of coin and publishes it. Resting orders it
+// crosses are filled.
+func (s *Server) SetBook(coin string, bids, asks []sdk.Level) {
+	book := &sdk.L2Book{
+		Coin:   coin,
+		Levels: [][]sdk.Level{bids, asks},
+		Time:   time.Now().UnixMilli(),
+	}
+	data, _ := json.Marshal(book)
+	msg := sdk.WSMessage{Channel: sdk.SubTypeL2Book, Data: data}
+
+	s.mu.Lock()
+	s.books[coin] = book
+	accounts := s.accountsLocked()
+	mids := s.midsLocked()
+	s.mu.Unlock()
+
+	for _, a := range accounts {
+		a.paper.HandleL2Book(msg)
+	}
+	s.hub.publish(sdk.Subscription{Type: sdk.SubTypeL2Book, Coin: coin}, msg)
+	data, _ = json.Marshal(sdk.WsAllMids{Mids: mids})
+	s.hub.publish(sdk.Subscription{Type: sdk.SubTypeAllMids}, sdk.WSMessage{Channel: sdk.SubTypeAllMids, Data: data})
+}
+
+// AddTrades publishes trades of other users. Resting orders they print
+// through are filled.
+func (s *Server) AddTrades(trades ...sdk.Trade) {
+	byCoin := make(map[string][]sdk.Trade)
+	for i, trade := range trades {
+		if trade.Time == 0 {
+			trades[i].Time = time.Now().UnixMilli()
+		}
+		byCoin[trade.Coin] = append(byCoin[trade.Coin], trades[i])
+	}
+
+	s.mu.Lock()
+	accounts := s.accountsLocked()
+	s.mu.Unlock()
+
+	for coin, trades := range byCoin {
+		data, _ := json.Marshal(trades)
+		msg := sdk.WSMessage{Channel: sdk.SubTypeTrades, Data: data}
+		for _, a := range accounts {
+			a.paper.HandleTrades(msg)
+		}
+		s.hub.publish(sdk.Subscription{Type: sdk.SubTypeTrades, Coin: coin}, msg)
+	}
+}
+
+// Script queues replies for requests to path, "/info" or "/exchange", of
+// the given info request or action type; an empty type matches any. Each
+// reply is used once, in order, after which requests are handled as usual.
+// Scripted requests are not executed, unless the reply has a nil Body.
+func (s *Server) Script(path, typ string, responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := scriptKey{path, typ}
+	s.scripts[key] = append(s.scripts[key], responses...)
+}
+
+// SetLatency delays every reply.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Paper returns the simulated exchange of an account, nil if it was never
+// funded, to inspect or drive it directly.
+func (s *Server) Paper(user common.Address) *paper.Exchange {
+	if a := s.account(user, false); a != nil {
+		return a.paper
+	}
+	return nil
+}
+
+type handler func(req *Request) (any, error)
+
+// serve wraps a handler with logging, scripting and latency. Handler
+// errors are bad requests.
+func (s *Server) serve(h handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		var head struct {
+			Type   string `json:"type"`
+			Action struct {
+				Type string `json:"type"`
+			} `json:"action"`
+		}
+		_ = json.Unmarshal(body, &head)
+		req := &Request{Path: r.URL.Path, Type: head.Type, Body: body, Time: time.Now()}
+		if req.Path == "/exchange" {
+			req.Type = head.Action.Type
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, *req)
+		latency := s.latency
+		scripted, ok := s.nextScriptLocked(req)
+		s.mu.Unlock()
+
+		time.Sleep(latency + scripted.Delay)
+		if scripted.Drop {
+			if hj, ok := w.(http.Hijacker); ok {
+				if conn, _, err := hj.Hijack(); err == nil {
+					conn.Close()
+					return
+				}
+			}
+			panic(http.ErrAbortHandler)
+		}
+		status, reply := scripted.Status, scripted.Body
+		if ok && reply == nil && status >= http.StatusBadRequest {
+			reply = http.StatusText(status)
+		}
+		if reply == nil {
+			var err error
+			reply, err = h(req)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if status == 0 {
+			status = http.StatusOK
+		}
+		writeReply(w, status, reply)
+	}
+}
+
+func (s *Server) nextScriptLocked(req *Request) (Response, bool) {
+	for _, key := range []scriptKey{{req.Path, req.Type}, {req.Path, ""}} {
+		if queue := s.scripts[key]; len(queue) > 0 {
+			s.scripts[key] = queue[1:]
+			return queue[0], true
+		}
+	}
+	return Response{}, false
+}
+
+func writeReply(w http.ResponseWriter, status int, reply any) {
+	var data []byte
+	switch v := reply.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		data, _ = json.Marshal(v)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+func (s *Server) midsLocked() map[string]string {
+	mids := make(map[string]string, len(s.books))
+	for coin, book := range s.books {
+		if len(book.Levels) < 2 || len(book.Levels[0]) == 0 || len(book.Levels[1]) == 0 {
+			continue
+		}
+		mids[coin] = sdk.FloatToString((book.Levels[0][0].Px + book.Levels[1][0].Px) / 2)
+	}
+	return mids
+}
@@ -0,0 +1,160 @@
+package hltest_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/exchange_api"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+func newSigner(t *testing.T) *sdk.LocalSigner {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := sdk.NewLocalSignerFromHex(hex.EncodeToString(crypto.FromECDSA(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestServerOrders(t *testing.T) {
+	srv := hltest.NewServer(hltest.Config{})
+	defer srv.Close()
+	signer := newSigner(t)
+	srv.Fund(signer.Address(), 10_000)
+	srv.SetBook("BTC", []sdk.Level{{Px: 99_000, Sz: 1}}, []sdk.Level{{Px: 100_000, Sz: 1}})
+
+	ws := sdk.NewWebsocketClient(srv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+	fills := make(chan sdk.WsUserFills, 4)
+	if _, err := ws.SubscribeToUserFills(signer.Address().Hex(), func(msg sdk.WSMessage) {
+		var f sdk.WsUserFills
+		if json.Unmarshal(msg.Data, &f) == nil && f.IsSnapshot == nil {
+			fills <- f
+		}
+	}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+	limit := sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifGtc}}
+	status, err := exchange.Order(sdk.OrderRequest{Coin: "BTC", IsBuy: true, Size: 0.01, LimitPx: 98_000, OrderType: limit}, nil)
+	if err != nil {
+		t.Fatalf("Failed to place order: %v", err)
+	}
+	resting, ok := status.(*sdk.ExchangeRestingOrder)
+	if !ok {
+		t.Fatalf("Expected resting order, got %#v", status)
+	}
+	if _, err := exchange.ModifyOrder(sdk.NewModifyByOid(resting.Oid, sdk.OrderRequest{
+		Coin: "BTC", IsBuy: true, Size: 0.02, LimitPx: 98_500, OrderType: limit,
+	})); err != nil {
+		t.Fatalf("Failed to modify: %v", err)
+	}
+
+	status, err = exchange.MarketOrder(sdk.MarketRequest{Coin: "BTC", IsBuy: true, Size: 0.01, Slippage: 0.05, MarketPrice: 100_000}, nil)
+	if err != nil {
+		t.Fatalf("Failed to place market order: %v", err)
+	}
+	if filled, ok := status.(*sdk.ExchangeFilledOrder); !ok || filled.AveragePx != "100000" {
+		t.Fatalf("Expected fill at 100000, got %#v", status)
+	}
+	select {
+	case f := <-fills:
+		if len(f.Fills) != 1 || f.Fills[0].Sz != "0.01" {
+			t.Errorf("Unexpected fills %+v", f)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("No fill received over the websocket")
+	}
+
+	info, err := sdk.NewInfo(srv.URL)
+	if err != nil {
+		t.Fatalf("Failed to create info: %v", err)
+	}
+	state, err := info.UserState(signer.Address().Hex())
+	if err != nil {
+		t.Fatalf("Failed to get user state: %v", err)
+	}
+	if len(state.AssetPositions) != 1 || state.AssetPositions[0].Position.Szi != "0.01" {
+		t.Errorf("Unexpected positions %+v", state.AssetPositions)
+	}
+	orders, err := info.OpenOrders(signer.Address().Hex())
+	if err != nil || len(orders) != 1 || orders[0].LimitPx != 98_500 {
+		t.Errorf("Unexpected open orders %+v, %v", orders, err)
+	}
+}
+
+func TestServerVerification(t *testing.T) {
+	srv := hltest.NewServer(hltest.Config{})
+	defer srv.Close()
+	signer := newSigner(t)
+	srv.Fund(signer.Address(), 1_000)
+	srv.SetBook("ETH", []sdk.Level{{Px: 2_000, Sz: 10}}, []sdk.Level{{Px: 2_001, Sz: 10}})
+
+	// an unfunded signer, as a bad signature would recover
+	stranger := sdk.NewExchange(srv.URL, nil, srv.Meta(), newSigner(t))
+	if err := stranger.UpdateLeverage("ETH", true, 5); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected unknown user error, got %v", err)
+	}
+
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+	action := &sdk.UpdateLeverageAction{Type: "updateLeverage", Asset: 1, IsCross: true, Leverage: 5}
+	nonce, err := exchange.NextNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := sdk.SignL1Action(signer, action, nil, nonce, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := exchange.PostActionAndParseResponse(action, sig, nonce); err != nil {
+		t.Fatalf("Failed to post action: %v", err)
+	}
+	if _, _, err := exchange.PostActionAndParseResponse(action, sig, nonce); !sdk.IsNonceError(err) {
+		t.Errorf("Expected duplicate nonce error, got %v", err)
+	}
+
+	agent := newSigner(t)
+	if _, err := exchange_api.ApproveAgent(exchange, exchange_api.ApproveAgentRequest{
+		AgentAddress:     agent.Address(),
+		HyperliquidChain: "Testnet",
+		SignatureChainId: "0x66eee",
+	}); err != nil {
+		t.Fatalf("Failed to approve agent: %v", err)
+	}
+	agentExchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), agent)
+	if err := agentExchange.UpdateLeverage("ETH", false, 3); err != nil {
+		t.Errorf("Approved agent was rejected: %v", err)
+	}
+
+	srv.Script("/exchange", "order", hltest.ExchangeError("Too many requests"))
+	_, err = exchange.Order(sdk.OrderRequest{
+		Coin: "ETH", IsBuy: false, Size: 1, LimitPx: 2_100,
+		OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifAlo}},
+	}, nil)
+	if err == nil || err.Error() != "Too many requests" {
+		t.Errorf("Expected scripted error, got %v", err)
+	}
+
+	srv.SetLatency(1500 * time.Millisecond)
+	if _, err := exchange.Cancel(sdk.CancelRequest{Coin: "ETH", Oid: 1}); err == nil {
+		t.Error("Expected a timeout")
+	}
+}
@@ -0,0 +1,167 @@
+package hltest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/gorilla/websocket"
+)
+
+// hub tracks the websocket connections and their subscriptions.
+type hub struct {
+	upgrader websocket.Upgrader
+
+	mu     sync.Mutex
+	conns  map[*wsConn]struct{}
+	closed bool
+}
+
+type wsConn struct {
+	ws      *websocket.Conn
+	writeMu sync.Mutex
+	subs    map[sdk.Subscription]struct{} // guarded by hub.mu
+}
+
+func newHub() *hub {
+	return &hub{conns: make(map[*wsConn]struct{})}
+}
+
+func (h *hub) serve(s *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ws, err := h.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		c := &wsConn{ws: ws, subs: make(map[sdk.Subscription]struct{})}
+		h.mu.Lock()
+		if h.closed {
+			h.mu.Unlock()
+			ws.Close()
+			return
+		}
+		h.conns[c] = struct{}{}
+		h.mu.Unlock()
+		defer func() {
+			h.mu.Lock()
+			delete(h.conns, c)
+			h.mu.Unlock()
+			ws.Close()
+		}()
+
+		_ = c.write(websocket.TextMessage, []byte("Websocket connection established."))
+		for {
+			var cmd sdk.WsCommand
+			if err := ws.ReadJSON(&cmd); err != nil {
+				return
+			}
+			switch cmd.Method {
+			case "ping":
+				_ = c.writeJSON(map[string]any{"channel": "pong"})
+			case "subscribe", "unsubscribe":
+				if cmd.Subscription == nil {
+					continue
+				}
+				key := subKey(*cmd.Subscription)
+				h.mu.Lock()
+				if cmd.Method == "subscribe" {
+					c.subs[key] = struct{}{}
+				} else {
+					delete(c.subs, key)
+				}
+				h.mu.Unlock()
+				_ = c.writeJSON(map[string]any{
+					"channel": "subscriptionResponse",
+					"data":    map[string]any{"method": cmd.Method, "subscription": cmd.Subscription},
+				})
+				if cmd.Method == "subscribe" {
+					if msg, ok := s.snapshot(*cmd.Subscription); ok {
+						_ = c.writeJSON(msg)
+					}
+				}
+			}
+		}
+	}
+}
+
+// publish sends msg to the connections subscribed to sub.
+func (h *hub) publish(sub sdk.Subscription, msg sdk.WSMessage) {
+	key := subKey(sub)
+	h.mu.Lock()
+	var conns []*wsConn
+	for c := range h.conns {
+		if _, ok := c.subs[key]; ok {
+			conns = append(conns, c)
+		}
+	}
+	h.mu.Unlock()
+	for _, c := range conns {
+		_ = c.writeJSON(msg)
+	}
+}
+
+func (h *hub) close() {
+	h.mu.Lock()
+	h.closed = true
+	conns := make([]*wsConn, 0, len(h.conns))
+	for c := range h.conns {
+		conns = append(conns, c)
+	}
+	h.mu.Unlock()
+	for _, c := range conns {
+		c.ws.Close()
+	}
+}
+
+func (c *wsConn) writeJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.write(websocket.TextMessage, data)
+}
+
+func (c *wsConn) write(typ int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.ws.WriteMessage(typ, data)
+}
+
+// snapshot returns the message sent on subscribing, if the channel has one.
+func (s *Server) snapshot(sub sdk.Subscription) (sdk.WSMessage, bool) {
+	var data any
+	switch sub.Type {
+	case sdk.SubTypeL2Book:
+		s.mu.Lock()
+		book, ok := s.books[sub.Coin]
+		s.mu.Unlock()
+		if !ok {
+			return sdk.WSMessage{}, false
+		}
+		data = book
+	case sdk.SubTypeAllMids:
+		s.mu.Lock()
+		data = sdk.WsAllMids{Mids: s.midsLocked()}
+		s.mu.Unlock()
+	case sdk.SubTypeUserFills:
+		fills := []sdk.WsFill{}
+		if a := s.account(common.HexToAddress(sub.User), false); a != nil {
+			fills = a.userFills()
+		}
+		snapshot := true
+		data = sdk.WsUserFills{IsSnapshot: &snapshot, User: sub.User, Fills: fills}
+	default:
+		return sdk.WSMessage{}, false
+	}
+	raw, _ := json.Marshal(data)
+	return sdk.WSMessage{Channel: sub.Type, Data: raw}, true
+}
+
+// subKey normalizes a subscription for matching.
+func subKey(sub sdk.Subscription) sdk.Subscription {
+	sub.User = strings.ToLower(sub.User)
+	return sub
+}
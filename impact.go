@@ -0,0 +1,203 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// ImpactEstimate is the expected result of sweeping one side of an order book.
+type ImpactEstimate struct {
+	Coin           string
+	IsBuy          bool
+	Size           float64 // requested size
+	FilledSize     float64 // size available within the book
+	Unfilled       float64 // size left over once the visible book is exhausted
+	Vwap           float64 // average fill price of FilledSize
+	BestPx         float64
+	WorstPx        float64 // price of the last level touched
+	MidPx          float64 // zero if either side of the book is empty
+	LevelsConsumed int
+	// Slippage and WorstSlippage are the adverse distance of Vwap and WorstPx
+	// from MidPx, or from BestPx when there is no mid, as a fraction.
+	Slippage      float64
+	WorstSlippage float64
+}
+
+// EstimateImpact walks the asks of book for a buy, or the bids for a sell,
+// until size is filled or the book is exhausted.
+func EstimateImpact(book *L2Book, isBuy bool, size float64) (*ImpactEstimate, error) {
+	if size <= 0 {
+		return nil, ValidationError{Field: "Size", Message: "must be positive"}
+	}
+	if book == nil || len(book.Levels) < 2 {
+		return nil, fmt.Errorf("invalid order book")
+	}
+	bids, asks := book.Levels[0], book.Levels[1]
+	levels := bids
+	if isBuy {
+		levels = asks
+	}
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("empty order book side for %s", book.Coin)
+	}
+
+	est := &ImpactEstimate{
+		Coin:   book.Coin,
+		IsBuy:  isBuy,
+		Size:   size,
+		BestPx: levels[0].Px,
+	}
+	if len(bids) > 0 && len(asks) > 0 {
+		est.MidPx = (bids[0].Px + asks[0].Px) / 2
+	}
+
+	remaining := size
+	notional := 0.0
+	for _, level := range levels {
+		if remaining <= 0 {
+			break
+		}
+		take := math.Min(level.Sz, remaining)
+		notional += take * level.Px
+		remaining -= take
+		est.FilledSize += take
+		est.WorstPx = level.Px
+		est.LevelsConsumed++
+	}
+	est.Unfilled = math.Max(remaining, 0)
+	if est.FilledSize > 0 {
+		est.Vwap = notional / est.FilledSize
+	}
+
+	ref := est.MidPx
+	if ref == 0 {
+		ref = est.BestPx
+	}
+	est.Slippage = adverseFraction(isBuy, ref, est.Vwap)
+	est.WorstSlippage = adverseFraction(isBuy, ref, est.WorstPx)
+	return est, nil
+}
+
+func adverseFraction(isBuy bool, ref, px float64) float64 {
+	if ref == 0 {
+		return 0
+	}
+	if isBuy {
+		return (px - ref) / ref
+	}
+	return (ref - px) / ref
+}
+
+// BookSource provides order book snapshots. *Info implements it with the
+// REST snapshot and *BookCache with the latest websocket book.
+type BookSource interface {
+	L2Snapshot(coin string) (*L2Book, error)
+}
+
+// WithBookSource sets where market orders with PriceFromBook read the order
+// book from. Defaults to Info.L2Snapshot.
+func WithBookSource(source BookSource) ExchangeOption {
+	return func(e *Exchange) {
+		e.bookSource = source
+	}
+}
+
+// BookCache keeps the latest l2Book websocket snapshot of each subscribed coin.
+type BookCache struct {
+	ws *WebsocketClient
+
+	mu    sync.RWMutex
+	books map[string]*L2Book
+	subs  map[string]int
+}
+
+func NewBookCache(ws *WebsocketClient) *BookCache {
+	return &BookCache{
+		ws:    ws,
+		books: make(map[string]*L2Book),
+		subs:  make(map[string]int),
+	}
+}
+
+// Watch subscribes to the order book of coin.
+func (c *BookCache) Watch(coin string) error {
+	// reserve the coin with a zero id and subscribe without holding c.mu,
+	// which the websocket dispatch needs to deliver books to onMessage
+	c.mu.Lock()
+	if _, ok := c.subs[coin]; ok {
+		c.mu.Unlock()
+		return nil
+	}
+	c.subs[coin] = 0
+	c.mu.Unlock()
+
+	id, err := c.ws.SubscribeToOrderbook(coin, c.onMessage)
+	c.mu.Lock()
+	pending, ok := c.subs[coin]
+	switch {
+	case err != nil:
+		if ok && pending == 0 {
+			delete(c.subs, coin)
+		}
+	case ok && pending == 0:
+		c.subs[coin] = id
+		id = 0
+	}
+	c.mu.Unlock()
+	if id != 0 {
+		// unwatched while subscribing
+		return c.ws.Unsubscribe(Subscription{Type: SubTypeL2Book, Coin: coin}, id)
+	}
+	return err
+}
+
+func (c *BookCache) Unwatch(coin string) error {
+	c.mu.Lock()
+	id, ok := c.subs[coin]
+	delete(c.subs, coin)
+	delete(c.books, coin)
+	c.mu.Unlock()
+	if !ok || id == 0 {
+		return nil
+	}
+	return c.ws.Unsubscribe(Subscription{Type: SubTypeL2Book, Coin: coin}, id)
+}
+
+func (c *BookCache) L2Snapshot(coin string) (*L2Book, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	book, ok := c.books[coin]
+	if !ok {
+		return nil, fmt.Errorf("no order book for %s", coin)
+	}
+	return book, nil
+}
+
+func (c *BookCache) onMessage(msg WSMessage) {
+	book := new(L2Book)
+	if err := json.Unmarshal(msg.Data, book); err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// every l2Book subscription receives every book, keep the watched ones
+	if _, ok := c.subs[book.Coin]; ok {
+		c.books[book.Coin] = book
+	}
+}
+
+// bookPrice returns the limit price for a market order that sweeps the book:
+// the worst level needed to fill the size, moved a further slippage away.
+func (e *Exchange) bookPrice(coin string, isBuy bool, size, slippage float64) (float64, error) {
+	book, err := e.bookSource.L2Snapshot(coin)
+	if err != nil {
+		return 0, err
+	}
+	est, err := EstimateImpact(book, isBuy, size)
+	if err != nil {
+		return 0, err
+	}
+	return e.slippagePrice(coin, isBuy, slippage, est.WorstPx)
+}
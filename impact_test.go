@@ -0,0 +1,86 @@
+package sdk_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+func TestBookCacheWatchWhilePublishing(t *testing.T) {
+	srv, _ := newServer(t, hltest.Config{})
+	ws := sdk.NewWebsocketClient(srv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	cache := sdk.NewBookCache(ws)
+	if err := cache.Watch("BTC"); err != nil {
+		t.Fatalf("Failed to watch BTC: %v", err)
+	}
+	stop := make(chan struct{})
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for px := 100_000.0; ; px++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			srv.SetBook("BTC", []sdk.Level{{Px: px - 1, Sz: 1}}, []sdk.Level{{Px: px + 1, Sz: 1}})
+		}
+	}()
+
+	watched := make(chan error, 1)
+	go func() {
+		for i, end := 0, time.Now().Add(500*time.Millisecond); time.Now().Before(end); i++ {
+			coin := "ETH"
+			if i%2 == 1 {
+				coin = "SOL"
+			}
+			if err := cache.Watch(coin); err != nil {
+				watched <- err
+				return
+			}
+			if err := cache.Unwatch(coin); err != nil {
+				watched <- err
+				return
+			}
+		}
+		watched <- cache.Watch("ETH")
+	}()
+	select {
+	case err := <-watched:
+		if err != nil {
+			t.Fatalf("Watch failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		// a deadlocked client cannot be closed, leave it to the server
+		close(stop)
+		t.Fatal("Watch deadlocked with books arriving")
+	}
+	close(stop)
+	<-published
+
+	srv.SetBook("ETH", []sdk.Level{{Px: 2_999, Sz: 1}}, []sdk.Level{{Px: 3_001, Sz: 1}})
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		book, err := cache.L2Snapshot("ETH")
+		if err == nil {
+			if book.Levels[0][0].Px != 2_999 {
+				t.Errorf("Expected the ETH bid at 2999, got %+v", book.Levels)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("No ETH book after watching it: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	ws.Close()
+}
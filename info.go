@@ -4,6 +4,7 @@ import (
 	"context" // Import the context package
 	"encoding/json"
 	"fmt"
+	"net/http"
 )
 
 type Info struct {
@@ -12,14 +13,36 @@ type Info struct {
 	assetToDecimal map[int]int
 	perpCoins      []string
 	spotCoins      []string
+	serverClock    *ServerClock
 }
 
-func NewInfo(apiBaseURL string) (*Info, error) {
+type InfoOption func(*Info)
+
+// WithInfoTransport sets the transport of the HTTP client, e.g. to record or
+// replay traffic. The metadata fetched by NewInfo goes through it too.
+func WithInfoTransport(rt http.RoundTripper) InfoOption {
+	return func(i *Info) {
+		i.client.httpClient.Transport = rt
+	}
+}
+
+// WithInfoServerClock makes the timestamps of user states and book snapshots
+// update the offset estimate of clock.
+func WithInfoServerClock(clock *ServerClock) InfoOption {
+	return func(i *Info) {
+		i.serverClock = clock
+	}
+}
+
+func NewInfo(apiBaseURL string, opts ...InfoOption) (*Info, error) {
 	info := &Info{
 		client:         NewClient(context.Background(), apiBaseURL),
 		coinToAsset:    make(map[string]int),
 		assetToDecimal: make(map[int]int),
 	}
+	for _, opt := range opts {
+		opt(info)
+	}
 
 	// Always attempt to fetch meta and spotMeta as skipMeta is effectively false
 	// and meta/spotMeta are effectively nil at this point.
@@ -62,6 +85,16 @@ func NewInfo(apiBaseURL string) (*Info, error) {
 	return info, nil
 }
 
+// newInfoWithClient returns an Info sharing an existing client. It does not
+// fetch metadata, so only queries that take coin names or addresses work.
+func newInfoWithClient(client *Client) *Info {
+	return &Info{
+		client:         client,
+		coinToAsset:    make(map[string]int),
+		assetToDecimal: make(map[int]int),
+	}
+}
+
 func (i *Info) ApiBaseUrl() string {
 	return i.client.baseURL
 }
@@ -135,6 +168,7 @@ func (i *Info) UserState(address string) (*UserState, error) {
 	if err := json.Unmarshal(resp, &result); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal user state: %w", err)
 	}
+	i.observe(result.Time)
 	return &result, nil
 }
 
@@ -379,9 +413,42 @@ func (i *Info) L2Snapshot(coin string) (*L2Book, error) {
 	if err := json.Unmarshal(resp, &result); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal L2 snapshot: %w", err)
 	}
+	i.observe(result.Time)
 	return &result, nil
 }
 
+func (i *Info) TwapHistory(address string) ([]TwapHistory, error) {
+	resp, err := i.client.post("/info", map[string]any{
+		"type": "twapHistory",
+		"user": address,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch twap history: %w", err)
+	}
+
+	var result []TwapHistory
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal twap history: %w", err)
+	}
+	return result, nil
+}
+
+func (i *Info) UserTwapSliceFills(address string) ([]TwapSliceFill, error) {
+	resp, err := i.client.post("/info", map[string]any{
+		"type": "userTwapSliceFills",
+		"user": address,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user twap slice fills: %w", err)
+	}
+
+	var result []TwapSliceFill
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal user twap slice fills: %w", err)
+	}
+	return result, nil
+}
+
 func (i *Info) CandlesSnapshot(coin, interval string, startTime, endTime int64) ([]Candle, error) {
 	req := map[string]any{
 		"coin":      coin,
@@ -486,11 +553,11 @@ func (i *Info) QueryOrderByOid(user string, oid int64) (*OpenOrder, error) {
 	return &result, nil
 }
 
-func (i *Info) QueryOrderByCloid(user string, cloid string) (*OpenOrder, error) {
+func (i *Info) QueryOrderByCloid(user string, cloid Cloid) (*OpenOrder, error) {
 	resp, err := i.client.post("/info", map[string]any{
 		"type": "orderStatus",
 		"user": user,
-		"oid":  cloid,
+		"oid":  cloid.String(),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch order status by cloid: %w", err)
@@ -566,3 +633,9 @@ func (i *Info) ExtraAgents(user string) ([]ExtraAgent, error) {
 	}
 	return result, nil
 }
+
+func (i *Info) observe(serverMs int64) {
+	if i.serverClock != nil {
+		i.serverClock.Observe(serverMs)
+	}
+}
@@ -0,0 +1,193 @@
+package sdk
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/term"
+)
+
+// ErrSignerLocked is returned by KeystoreSigner.Sign while it is locked.
+var ErrSignerLocked = errors.New("keystore signer is locked")
+
+// PassphraseSource returns the passphrase of a keystore. The caller zeroes
+// the returned bytes after use.
+type PassphraseSource func() ([]byte, error)
+
+// PassphraseFromEnv reads the passphrase from the environment variable name.
+func PassphraseFromEnv(name string) PassphraseSource {
+	return func() ([]byte, error) {
+		passphrase, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set", name)
+		}
+		return []byte(passphrase), nil
+	}
+}
+
+// PassphraseFromFile reads the passphrase from the first line of a file.
+func PassphraseFromFile(path string) PassphraseSource {
+	return func() ([]byte, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		line, _, _ := bytes.Cut(data, []byte("\n"))
+		passphrase := bytes.Clone(bytes.TrimSuffix(line, []byte("\r")))
+		clear(data)
+		return passphrase, nil
+	}
+}
+
+// PassphraseFromPrompt asks for the passphrase on the terminal without
+// echoing it, or reads a line from stdin if it is not a terminal.
+func PassphraseFromPrompt(prompt string) PassphraseSource {
+	return func() ([]byte, error) {
+		fmt.Fprint(os.Stderr, prompt)
+		defer fmt.Fprintln(os.Stderr)
+		fd := int(os.Stdin.Fd())
+		if term.IsTerminal(fd) {
+			return term.ReadPassword(fd)
+		}
+		line, err := bufio.NewReader(os.Stdin).ReadBytes('\n')
+		if err != nil && len(line) == 0 {
+			return nil, err
+		}
+		return bytes.TrimRight(line, "\r\n"), nil
+	}
+}
+
+// KeystoreSigner signs with a key from an encrypted Ethereum V3 keystore
+// file. It starts locked: Unlock decrypts the key for a limited time, after
+// which it is zeroed and Sign fails with ErrSignerLocked until the next
+// Unlock.
+type KeystoreSigner struct {
+	keyJSON    []byte
+	address    common.Address
+	passphrase PassphraseSource
+
+	mu    sync.Mutex
+	key   *ecdsa.PrivateKey
+	timer *time.Timer
+	// gen counts the unlocks and locks; a timer only locks the signer if no
+	// other did since it was started, as a stopped timer may still fire.
+	gen uint64
+}
+
+// NewKeystoreSigner reads the keystore at path. The passphrase is only asked
+// for on Unlock.
+func NewKeystoreSigner(path string, passphrase PassphraseSource) (*KeystoreSigner, error) {
+	keyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var header struct {
+		Address string `json:"address"`
+	}
+	if err := json.Unmarshal(keyJSON, &header); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal keystore: %w", err)
+	}
+	if !common.IsHexAddress(header.Address) {
+		return nil, fmt.Errorf("keystore has no valid address: %q", header.Address)
+	}
+	return &KeystoreSigner{
+		keyJSON:    keyJSON,
+		address:    common.HexToAddress(header.Address),
+		passphrase: passphrase,
+	}, nil
+}
+
+func (s *KeystoreSigner) Address() common.Address {
+	return s.address
+}
+
+// Unlock decrypts the key and keeps it for d, or until Lock if d is zero.
+// Unlocking an unlocked signer only resets the duration.
+func (s *KeystoreSigner) Unlock(d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key == nil {
+		passphrase, err := s.passphrase()
+		if err != nil {
+			return fmt.Errorf("failed to get passphrase: %w", err)
+		}
+		key, err := keystore.DecryptKey(s.keyJSON, string(passphrase))
+		clear(passphrase)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt keystore: %w", err)
+		}
+		if key.Address != s.address {
+			zeroKey(key.PrivateKey)
+			return fmt.Errorf("keystore key is for %s, not %s", key.Address.Hex(), s.address.Hex())
+		}
+		s.key = key.PrivateKey
+	}
+	s.gen++
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if d > 0 {
+		gen := s.gen
+		s.timer = time.AfterFunc(d, func() { s.expire(gen) })
+	}
+	return nil
+}
+
+// Lock zeroes the decrypted key.
+func (s *KeystoreSigner) Lock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lockLocked()
+}
+
+func (s *KeystoreSigner) expire(gen uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if gen == s.gen {
+		s.lockLocked()
+	}
+}
+
+func (s *KeystoreSigner) lockLocked() {
+	s.gen++
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.key != nil {
+		zeroKey(s.key)
+		s.key = nil
+	}
+}
+
+func (s *KeystoreSigner) Unlocked() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.key != nil
+}
+
+func (s *KeystoreSigner) Sign(msg []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key == nil {
+		return nil, ErrSignerLocked
+	}
+	return crypto.Sign(msg, s.key)
+}
+
+// zeroKey overwrites the private scalar of key in place.
+func zeroKey(key *ecdsa.PrivateKey) {
+	clear(key.D.Bits())
+	key.D.SetInt64(0)
+}
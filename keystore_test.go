@@ -0,0 +1,98 @@
+package sdk_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+func writeKeystore(t *testing.T, passphrase string) string {
+	t.Helper()
+	priv, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := &keystore.Key{Address: crypto.PubkeyToAddress(priv.PublicKey), PrivateKey: priv}
+	keyJSON, err := keystore.EncryptKey(key, passphrase, keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(path, keyJSON, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestKeystoreSigner(t *testing.T) {
+	srv := hltest.NewServer(hltest.Config{})
+	defer srv.Close()
+	path := writeKeystore(t, "correct horse")
+	passphrase := filepath.Join(t.TempDir(), "passphrase")
+	if err := os.WriteFile(passphrase, []byte("correct horse\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := sdk.NewKeystoreSigner(path, sdk.PassphraseFromFile(passphrase))
+	if err != nil {
+		t.Fatalf("Failed to load keystore: %v", err)
+	}
+	srv.Fund(signer.Address(), 1_000)
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+	if err := exchange.UpdateLeverage("ETH", true, 5); !errors.Is(err, sdk.ErrSignerLocked) {
+		t.Errorf("Expected a locked signer, got %v", err)
+	}
+
+	if err := signer.Unlock(200 * time.Millisecond); err != nil {
+		t.Fatalf("Failed to unlock: %v", err)
+	}
+	if err := exchange.UpdateLeverage("ETH", true, 5); err != nil {
+		t.Errorf("Unlocked signer was rejected: %v", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if signer.Unlocked() {
+		t.Error("Signer still unlocked after the duration")
+	}
+
+	t.Setenv("HL_KEYSTORE_PASSPHRASE", "wrong")
+	wrong, err := sdk.NewKeystoreSigner(path, sdk.PassphraseFromEnv("HL_KEYSTORE_PASSPHRASE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wrong.Unlock(0); err == nil || !strings.Contains(err.Error(), "decrypt") {
+		t.Errorf("Expected a decryption error, got %v", err)
+	}
+}
+
+func TestKeystoreSignerExtendUnlock(t *testing.T) {
+	t.Setenv("HL_KEYSTORE_PASSPHRASE", "correct horse")
+	signer, err := sdk.NewKeystoreSigner(writeKeystore(t, "correct horse"), sdk.PassphraseFromEnv("HL_KEYSTORE_PASSPHRASE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer signer.Lock()
+
+	// Extend the unlock around the moment the old timer fires; the old
+	// timer must not lock the signer once the extension is in place.
+	for i := 0; i < 200; i++ {
+		if err := signer.Unlock(time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+		if err := signer.Unlock(time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+		if !signer.Unlocked() {
+			t.Fatalf("Extended unlock was locked by an earlier timer (iteration %d)", i)
+		}
+	}
+}
@@ -73,6 +73,7 @@ type OpenOrder struct {
 	Side      string  `json:"side"`
 	Size      float64 `json:"sz,string"`
 	Timestamp int64   `json:"timestamp"`
+	Cloid     *Cloid  `json:"cloid,omitempty"`
 }
 
 // FrontendOpenOrder represents an open order with additional frontend-specific fields
@@ -90,6 +91,7 @@ type FrontendOpenOrder struct {
 	Timestamp        int64   `json:"timestamp"`
 	TriggerCondition string  `json:"triggerCondition"`
 	TriggerPx        float64 `json:"triggerPx,string"`
+	Cloid            *Cloid  `json:"cloid,omitempty"`
 }
 
 type Fill struct {
@@ -108,6 +110,7 @@ type Fill struct {
 	FeeToken      string `json:"feeToken"`
 	BuilderFee    string `json:"builderFee"`
 	Tid           int64  `json:"tid"`
+	Cloid         *Cloid `json:"cloid,omitempty"`
 }
 
 type DepositWithdrawTx struct {
@@ -419,3 +422,33 @@ type SpotStateBalance struct {
 type SpotState struct {
 	Balances []SpotStateBalance `json:"balances"`
 }
+
+type TwapState struct {
+	Coin        string `json:"coin"`
+	User        string `json:"user"`
+	Side        string `json:"side"`
+	Sz          string `json:"sz"`
+	ExecutedSz  string `json:"executedSz"`
+	ExecutedNtl string `json:"executedNtl"`
+	Minutes     int    `json:"minutes"`
+	ReduceOnly  bool   `json:"reduceOnly"`
+	Randomize   bool   `json:"randomize"`
+	Timestamp   int64  `json:"timestamp"`
+}
+
+type TwapStatus struct {
+	Status      string `json:"status"` // Possible values: activated, finished, terminated, error
+	Description string `json:"description,omitempty"`
+}
+
+type TwapHistory struct {
+	Time   int64      `json:"time"`
+	State  TwapState  `json:"state"`
+	Status TwapStatus `json:"status"`
+	TwapID *TwapID    `json:"twapId,omitempty"`
+}
+
+type TwapSliceFill struct {
+	Fill   Fill   `json:"fill"`
+	TwapID TwapID `json:"twapId"`
+}
@@ -0,0 +1,38 @@
+//go:build !unix
+
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// lockStale is the age after which a lock file left by a crashed process
+// is taken over.
+const lockStale = 10 * time.Second
+
+// lockFile creates path exclusively, waiting while another process holds
+// it.
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(2 * lockStale)
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s", path)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
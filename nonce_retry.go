@@ -0,0 +1,133 @@
+package sdk
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+)
+
+// NonceError is returned when the exchange rejects the nonce of an action,
+// e.g. because it was used before or is too far from the server time.
+type NonceError struct {
+	Nonce   uint64
+	Message string
+}
+
+func (e *NonceError) Error() string {
+	return e.Message
+}
+
+// IsNonceError reports whether err is a nonce rejection.
+func IsNonceError(err error) bool {
+	var nonceErr *NonceError
+	return errors.As(err, &nonceErr)
+}
+
+// isNonceRejection reports whether msg is the error the exchange rejects a
+// nonce with: "Invalid nonce: duplicate nonce", "Invalid nonce: nonce is too
+// far from the server time" and the like.
+func isNonceRejection(msg string) bool {
+	return strings.HasPrefix(msg, "Invalid nonce")
+}
+
+// NonceBumper is implemented by nonce sources that can skip ahead after a
+// rejection.
+type NonceBumper interface {
+	// Bump makes the next nonce greater than floor.
+	Bump(floor uint64)
+}
+
+func (s *TimestampNonceSource) Bump(floor uint64) {
+	bumpAtomic(&s.nonce, floor)
+}
+
+func (s *SequenceNonceSource) Bump(floor uint64) {
+	bumpAtomic(&s.nonce, floor)
+}
+
+func (s *StoreNonceSource) Bump(floor uint64) {
+	s.local.Bump(floor)
+}
+
+func bumpAtomic(v *atomic.Uint64, floor uint64) {
+	for {
+		cur := v.Load()
+		if cur >= floor || v.CompareAndSwap(cur, floor) {
+			return
+		}
+	}
+}
+
+// PostOption configures a single PostActionAndParseResponse call.
+type PostOption func(*postConfig)
+
+type postConfig struct {
+	retry  *bool
+	resign func(nonce uint64) (Action, *Signature, error)
+}
+
+// RetryNonce sets whether a nonce rejection is retried once with a new
+// nonce, overriding WithNonceRetry.
+func RetryNonce(retry bool) PostOption {
+	return func(c *postConfig) {
+		c.retry = &retry
+	}
+}
+
+// Resign sets how the action is built and signed again for a new nonce, and
+// enables the retry as configured by WithNonceRetry. With RetryNonce but
+// without it, L1 actions are re-signed as they are with the exchange's
+// signer; user-signed actions carry their nonce and are not retried.
+func Resign(resign func(nonce uint64) (Action, *Signature, error)) PostOption {
+	return func(c *postConfig) {
+		c.resign = resign
+	}
+}
+
+// userSignedActions are the action types signed as EIP-712 user actions
+// rather than L1 actions.
+var userSignedActions = map[string]bool{
+	"usdSend":           true,
+	"usdClassTransfer":  true,
+	"spotSend":          true,
+	"withdraw3":         true,
+	"approveAgent":      true,
+	"approveBuilderFee": true,
+	"tokenDelegate":     true,
+	"sendAsset":         true,
+}
+
+// resignL1 re-signs an L1 action built by the exchange for a new nonce.
+func (e *Exchange) resignL1(action Action) PostOption {
+	return Resign(func(nonce uint64) (Action, *Signature, error) {
+		sig, err := e.signL1Action(action, nonce)
+		return action, sig, err
+	})
+}
+
+// retryNonce signs the action again with a nonce past the rejected one and
+// the current time, and posts it.
+func (e *Exchange) retryNonce(cfg postConfig, action Action, rejected *NonceError) (*ExchangeSuccessResponse, error) {
+	resign := cfg.resign
+	if resign == nil {
+		if userSignedActions[action.Tp()] {
+			return nil, rejected
+		}
+		resign = func(nonce uint64) (Action, *Signature, error) {
+			sig, err := e.signL1Action(action, nonce)
+			return action, sig, err
+		}
+	}
+	if bumper, ok := e.nonces.(NonceBumper); ok {
+		bumper.Bump(max(rejected.Nonce, timestamp(e.clock)))
+	}
+	nonce, err := e.NextNonce()
+	if err != nil {
+		return nil, err
+	}
+	action, sig, err := resign(nonce)
+	if err != nil {
+		return nil, err
+	}
+	return e.postAction(action, sig, nonce)
+}
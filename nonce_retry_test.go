@@ -0,0 +1,124 @@
+package sdk_test
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/exchange_api"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+func TestNonceRetry(t *testing.T) {
+	srv, signer := newServer(t, hltest.Config{})
+
+	// a second process with the same frozen clock reuses the nonces
+	clock := sdk.NewManualClock(time.Now())
+	first := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer, sdk.WithClock(clock))
+	second := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer, sdk.WithClock(clock))
+	if err := first.UpdateLeverage("ETH", true, 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.UpdateLeverage("ETH", true, 5); err != nil {
+		t.Errorf("Duplicate nonce was not retried: %v", err)
+	}
+	if _, err := first.Order(sdk.OrderRequest{
+		Coin: "ETH", IsBuy: true, Size: 1, LimitPx: 1_000,
+		OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifGtc}},
+	}, nil); err != nil {
+		t.Errorf("Duplicate nonce was not retried: %v", err)
+	}
+
+	// user-signed actions carry their nonce and are rebuilt by the helpers
+	transfer := exchange_api.TransferUSDRequest{
+		Amount: "1", Destination: "0x0000000000000000000000000000000000000001",
+		HyperliquidChain: "Testnet", SignatureChainId: "0x66eee",
+	}
+	if _, err := exchange_api.TansferUSD(second, transfer); err != nil {
+		t.Errorf("Duplicate nonce was not retried: %v", err)
+	}
+	if _, err := exchange_api.TansferUSD(first, transfer); err != nil {
+		t.Errorf("Duplicate nonce was not retried: %v", err)
+	}
+
+	if n := len(srv.Requests()); n != 9 {
+		t.Errorf("Expected 4 retries in 9 requests, got %d", n)
+	}
+}
+
+func TestNonceRetryCallerSigned(t *testing.T) {
+	srv, signer := newServer(t, hltest.Config{})
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+	action := &sdk.UpdateLeverageAction{Type: "updateLeverage", Asset: 1, IsCross: true, Leverage: 5}
+	nonce, err := exchange.NextNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := sdk.SignL1Action(signer, action, nil, nonce, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := exchange.PostActionAndParseResponse(action, sig, nonce); err != nil {
+		t.Fatalf("Failed to post action: %v", err)
+	}
+
+	// the exchange does not re-sign what the caller signed unless asked to
+	if _, _, err := exchange.PostActionAndParseResponse(action, sig, nonce); !sdk.IsNonceError(err) {
+		t.Errorf("Expected the duplicate nonce error, got %v", err)
+	}
+	if _, _, err := exchange.PostActionAndParseResponse(action, sig, nonce, sdk.RetryNonce(true)); err != nil {
+		t.Errorf("Duplicate nonce was not retried: %v", err)
+	}
+	if n := len(srv.Requests()); n != 4 {
+		t.Errorf("Expected 1 retry in 4 requests, got %d", n)
+	}
+
+	// only the exchange's nonce rejections are retried
+	srv.Script("/exchange", "updateLeverage", hltest.ExchangeError("Signature does not match the nonce of the agent"))
+	if err := exchange.UpdateLeverage("ETH", true, 5); err == nil || sdk.IsNonceError(err) {
+		t.Errorf("Expected a plain error, got %v", err)
+	}
+	if n := len(srv.Requests()); n != 5 {
+		t.Errorf("Expected no retry of a rejection that is not about the nonce, got %d requests", n)
+	}
+}
+
+func TestNonceRetryTwap(t *testing.T) {
+	srv, signer := newServer(t, hltest.Config{})
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+	rejected := hltest.ExchangeError("Invalid nonce: duplicate nonce")
+	srv.Script("/exchange", "twapOrder", rejected, hltest.Response{Body: map[string]any{
+		"status":   "ok",
+		"response": map[string]any{"type": "twapOrder", "data": map[string]any{"status": map[string]any{"running": map[string]any{"twapId": 7}}}},
+	}})
+	srv.Script("/exchange", "twapCancel", rejected, hltest.Response{Body: map[string]any{
+		"status":   "ok",
+		"response": map[string]any{"type": "twapCancel", "data": map[string]any{"status": "success"}},
+	}})
+
+	id, err := exchange.TwapOrder(sdk.TwapRequest{Coin: "ETH", IsBuy: true, Size: 1, Minutes: 10})
+	if err != nil || id != 7 {
+		t.Fatalf("Duplicate nonce was not retried: %v, %v", id, err)
+	}
+	if err := exchange.TwapCancel("ETH", id); err != nil {
+		t.Errorf("Duplicate nonce was not retried: %v", err)
+	}
+	if n := len(srv.Requests()); n != 4 {
+		t.Errorf("Expected 2 retries in 4 requests, got %d", n)
+	}
+
+	// a request refused before it is signed does not use a nonce
+	before, err := exchange.NextNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := exchange.TwapOrder(sdk.TwapRequest{Coin: "ETH", Size: 1}); err == nil {
+		t.Error("Expected a TWAP without minutes to be refused")
+	}
+	if err := exchange.TwapCancel("NOPE", id); err == nil {
+		t.Error("Expected an unknown coin to be refused")
+	}
+	if after, err := exchange.NextNonce(); err != nil || after != before+1 {
+		t.Errorf("Expected nonce %d after the refused requests, got %d: %v", before+1, after, err)
+	}
+}
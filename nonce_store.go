@@ -0,0 +1,136 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NonceStore keeps the highest nonce handed out per signer, so that every
+// Exchange signing with the same key, in this process or another, gets
+// increasing nonces, also across restarts.
+type NonceStore interface {
+	// Reserve returns a nonce of signer above every nonce reserved before,
+	// and at least floor.
+	Reserve(signer common.Address, floor uint64) (uint64, error)
+}
+
+// MemoryNonceStore shares nonces between the exchanges of one process.
+type MemoryNonceStore struct {
+	mu   sync.Mutex
+	high map[common.Address]uint64
+}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{high: make(map[common.Address]uint64)}
+}
+
+func (s *MemoryNonceStore) Reserve(signer common.Address, floor uint64) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nonce := max(s.high[signer]+1, floor)
+	s.high[signer] = nonce
+	return nonce, nil
+}
+
+// FileNonceStore keeps the high-water marks as JSON in a file, shared by the
+// processes that use the same path. A reservation holds an exclusive lock on
+// path.lock while it reads the file and replaces it through a renamed
+// temporary file.
+type FileNonceStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNonceStore(path string) *FileNonceStore {
+	return &FileNonceStore{path: path}
+}
+
+func (s *FileNonceStore) Reserve(signer common.Address, floor uint64) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock nonce store: %w", err)
+	}
+	defer unlock()
+
+	high, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+	nonce := max(high[signer]+1, floor)
+	high[signer] = nonce
+	if err := s.save(high); err != nil {
+		return 0, fmt.Errorf("failed to save nonce store: %w", err)
+	}
+	return nonce, nil
+}
+
+func (s *FileNonceStore) load() (map[common.Address]uint64, error) {
+	high := make(map[common.Address]uint64)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return high, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &high); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal nonce store: %w", err)
+	}
+	return high, nil
+}
+
+func (s *FileNonceStore) save(high map[common.Address]uint64) error {
+	data, err := json.Marshal(high)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// StoreNonceSource reserves the nonces of a signer in a NonceStore, at least
+// the time of its clock in milliseconds. Should the store fail, the action
+// fails too: a nonce not reserved in the store could collide with those of
+// the other processes sharing it.
+type StoreNonceSource struct {
+	store  NonceStore
+	signer common.Address
+	local  *TimestampNonceSource
+}
+
+func NewStoreNonceSource(store NonceStore, signer common.Address, clock Clock) *StoreNonceSource {
+	return &StoreNonceSource{store: store, signer: signer, local: NewTimestampNonceSource(clock)}
+}
+
+func (s *StoreNonceSource) NextNonce() (uint64, error) {
+	nonce, err := s.store.Reserve(s.signer, s.local.next())
+	if err != nil {
+		return 0, fmt.Errorf("reserve nonce: %w", err)
+	}
+	// keep the local floor above the nonces of the store
+	s.local.Bump(nonce)
+	return nonce, nil
+}
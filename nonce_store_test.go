@@ -0,0 +1,48 @@
+package sdk_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+func TestFileNonceStore(t *testing.T) {
+	srv, signer := newServer(t, hltest.Config{})
+
+	// two processes with a frozen clock start from the same nonce
+	clock := sdk.NewManualClock(time.Now())
+	path := filepath.Join(t.TempDir(), "nonces.json")
+	for i := range 2 {
+		first := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer,
+			sdk.WithClock(clock), sdk.WithNonceStore(sdk.NewFileNonceStore(path)))
+		second := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer,
+			sdk.WithClock(clock), sdk.WithNonceStore(sdk.NewFileNonceStore(path)))
+		for _, exchange := range []*sdk.Exchange{first, second, first} {
+			if err := exchange.UpdateLeverage("ETH", true, 5); err != nil {
+				t.Fatalf("Run %d: shared nonce store reused a nonce: %v", i, err)
+			}
+		}
+	}
+
+	unshared := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer, sdk.WithClock(clock), sdk.WithNonceRetry(false))
+	if err := unshared.UpdateLeverage("ETH", true, 5); err == nil || !strings.HasPrefix(err.Error(), "Invalid nonce:") {
+		t.Errorf("Expected a duplicate nonce without the store, got %v", err)
+	}
+}
+
+func TestNonceStoreFailure(t *testing.T) {
+	srv, signer := newServer(t, hltest.Config{})
+	// the directory of the store does not exist
+	path := filepath.Join(t.TempDir(), "missing", "nonces.json")
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer, sdk.WithNonceStore(sdk.NewFileNonceStore(path)))
+	if err := exchange.UpdateLeverage("ETH", true, 5); err == nil || !strings.Contains(err.Error(), "reserve nonce") {
+		t.Errorf("Expected the action to fail without the store, got %v", err)
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("Expected nothing to be sent, got %d requests", n)
+	}
+}
@@ -0,0 +1,617 @@
+package oms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+type Status string
+
+const (
+	StatusSubmitted       Status = "submitted"
+	StatusResting         Status = "resting"
+	StatusPartiallyFilled Status = "partiallyFilled"
+	StatusFilled          Status = "filled"
+	StatusCanceled        Status = "canceled"
+	StatusRejected        Status = "rejected"
+)
+
+// Terminal reports whether no further transitions can happen.
+func (s Status) Terminal() bool {
+	return s == StatusFilled || s == StatusCanceled || s == StatusRejected
+}
+
+// rank orders the states; an order only ever moves to a higher rank, so
+// late or reordered events cannot move it backwards.
+func (s Status) rank() int {
+	switch s {
+	case StatusSubmitted:
+		return 0
+	case StatusResting:
+		return 1
+	case StatusPartiallyFilled:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Order is the tracked state of an order.
+type Order struct {
+	Cloid    sdk.Cloid
+	Oid      uint64 // zero until the exchange acknowledges the order
+	Request  sdk.OrderRequest
+	Status   Status
+	FilledSz float64
+	AvgPx    float64
+	Fees     float64
+	Fills    []sdk.WsFill
+	// Reason holds the rejection error or the cancel status from the exchange.
+	Reason      string
+	SubmittedAt time.Time
+	UpdatedAt   time.Time
+
+	// unknown is set while a failed BulkOrders call leaves it open whether
+	// the exchange accepted the order, see Reconcile.
+	unknown bool
+	// the fills from the userFills stream and the fill reported by a filled
+	// BulkOrders status describe the same execution, see updateFilled
+	streamSz, streamNotional float64
+	statusSz, statusPx       float64
+}
+
+// RemainingSz returns the size not yet filled.
+func (o *Order) RemainingSz() float64 {
+	return max(o.Request.Size-o.FilledSz, 0)
+}
+
+// ChangeFunc is called after an order changed state or received a fill.
+type ChangeFunc func(order Order, prev Status)
+
+// OMS is the single source of truth for orders sent through it. It records
+// every order by cloid at submission and advances it through its lifecycle
+// from the BulkOrders results, the orderUpdates stream and the userFills
+// stream, whichever arrives first.
+type OMS struct {
+	cloids *sdk.CloidGenerator
+
+	mu           sync.RWMutex
+	orders       map[sdk.Cloid]*Order
+	byOid        map[uint64]sdk.Cloid
+	seenFills    map[int64]struct{} // trade ids of the fills of tracked orders
+	pendingFills map[uint64][]sdk.WsFill
+	listeners    []ChangeFunc
+}
+
+// maxPendingFills bounds the oids whose fills are kept until their order is
+// acknowledged. Fills of orders the OMS never learns about, e.g. manual
+// trades, are dropped oldest first beyond it.
+const maxPendingFills = 1024
+
+type event struct {
+	order Order
+	prev  Status
+}
+
+func New() *OMS {
+	return &OMS{
+		cloids:       sdk.NewCloidGenerator(sdk.RandomInstanceTag()),
+		orders:       make(map[sdk.Cloid]*Order),
+		byOid:        make(map[uint64]sdk.Cloid),
+		seenFills:    make(map[int64]struct{}),
+		pendingFills: make(map[uint64][]sdk.WsFill),
+	}
+}
+
+// OnChange registers a callback for order changes. Callbacks run outside the
+// OMS lock, so they may query the OMS.
+func (o *OMS) OnChange(fn ChangeFunc) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.listeners = append(o.listeners, fn)
+}
+
+// Attach feeds the OMS from the orderUpdates and userFills streams of user.
+func (o *OMS) Attach(ws *sdk.WebsocketClient, user string) error {
+	if _, err := ws.SubscribeToOrderUpdates(user, o.HandleOrderUpdates); err != nil {
+		return err
+	}
+	if _, err := ws.SubscribeToUserFills(user, o.HandleUserFills); err != nil {
+		return err
+	}
+	return nil
+}
+
+// PlaceOrders submits reqs, sends them with BulkOrders and applies the
+// results. Requests without a cloid are assigned one.
+func (o *OMS) PlaceOrders(exchange sdk.OrderPlacer, reqs []sdk.OrderRequest, builder *sdk.BuilderInfo) ([]any, error) {
+	reqs = append([]sdk.OrderRequest(nil), reqs...)
+	for i := range reqs {
+		cloid, err := o.Submit(reqs[i])
+		if err != nil {
+			return nil, err
+		}
+		reqs[i].Cloid = &cloid
+	}
+	statuses, err := exchange.BulkOrders(reqs, builder)
+	o.ApplyResults(reqs, statuses, err)
+	return statuses, err
+}
+
+// Submit records an order about to be sent and returns its cloid, assigning
+// one if the request has none. The request must be sent with that cloid.
+func (o *OMS) Submit(req sdk.OrderRequest) (sdk.Cloid, error) {
+	var cloid sdk.Cloid
+	if req.Cloid != nil {
+		cloid = *req.Cloid
+	} else {
+		cloid = o.cloids.Next()
+		req.Cloid = &cloid
+	}
+
+	now := time.Now()
+	order := &Order{
+		Cloid:       cloid,
+		Request:     req,
+		Status:      StatusSubmitted,
+		SubmittedAt: now,
+		UpdatedAt:   now,
+	}
+
+	o.mu.Lock()
+	if _, exist := o.orders[cloid]; exist {
+		o.mu.Unlock()
+		return cloid, fmt.Errorf("order %s already submitted", cloid)
+	}
+	o.orders[cloid] = order
+	events := []event{{order: snapshot(order), prev: ""}}
+	listeners := o.listeners
+	o.mu.Unlock()
+
+	notify(listeners, events)
+	return cloid, nil
+}
+
+// ApplyResults applies the result of a BulkOrders call for reqs. A non-nil
+// err rejects every order of the call, unless it is a timeout or a dropped
+// connection: the exchange may then have accepted the orders, and they stay
+// submitted until the streams or Reconcile resolve them.
+func (o *OMS) ApplyResults(reqs []sdk.OrderRequest, statuses []any, err error) {
+	o.mu.Lock()
+	var events []event
+	for i, req := range reqs {
+		if req.Cloid == nil {
+			continue
+		}
+		order, ok := o.orders[*req.Cloid]
+		if !ok {
+			continue
+		}
+		if err != nil {
+			if outcomeUnknown(err) {
+				order.unknown = order.Status == StatusSubmitted
+				order.Reason = err.Error()
+				continue
+			}
+			events = o.transitionLocked(events, order, StatusRejected, err.Error())
+			continue
+		}
+		if i >= len(statuses) {
+			continue
+		}
+		switch status := statuses[i].(type) {
+		case *sdk.ExchangeRestingOrder:
+			events = o.setOidLocked(events, order, status.Oid)
+			events = o.transitionLocked(events, order, StatusResting, "")
+		case *sdk.ExchangeFilledOrder:
+			events = o.setOidLocked(events, order, status.Oid)
+			events = o.applyStatusFillLocked(events, order, status)
+		case error:
+			events = o.transitionLocked(events, order, StatusRejected, status.Error())
+		}
+	}
+	listeners := o.listeners
+	o.mu.Unlock()
+
+	notify(listeners, events)
+}
+
+// ApplyOrderUpdates applies orderUpdates events. Orders not submitted through
+// the OMS are ignored.
+func (o *OMS) ApplyOrderUpdates(updates []sdk.WsOrder) {
+	o.mu.Lock()
+	var events []event
+	for _, update := range updates {
+		order := o.lookupLocked(update.Order.Cloid, uint64(update.Order.Oid))
+		if order == nil {
+			continue
+		}
+		events = o.setOidLocked(events, order, uint64(update.Order.Oid))
+
+		status := update.Status
+		switch {
+		case status == "open" || status == "triggered":
+			events = o.transitionLocked(events, order, StatusResting, "")
+		case status == "filled":
+			events = o.transitionLocked(events, order, StatusFilled, "")
+		case status == "rejected" || strings.HasSuffix(status, "Rejected"):
+			events = o.transitionLocked(events, order, StatusRejected, status)
+		case status == "canceled" || strings.HasSuffix(status, "Canceled"):
+			events = o.transitionLocked(events, order, StatusCanceled, status)
+		}
+	}
+	listeners := o.listeners
+	o.mu.Unlock()
+
+	notify(listeners, events)
+}
+
+// ApplyFills applies userFills events. Fills are deduplicated by trade id,
+// and fills that arrive before their order is acknowledged are kept until
+// the oid is known.
+func (o *OMS) ApplyFills(fills []sdk.WsFill) {
+	o.applyFills(fills, true)
+}
+
+func (o *OMS) applyFills(fills []sdk.WsFill, keepPending bool) {
+	o.mu.Lock()
+	var events []event
+	for _, fill := range fills {
+		if _, seen := o.seenFills[fill.Tid]; seen {
+			continue
+		}
+		order := o.lookupLocked(fill.Cloid, uint64(fill.Oid))
+		if order == nil {
+			if fill.Cloid == nil && keepPending {
+				o.addPendingLocked(fill)
+			}
+			continue
+		}
+		events = o.applyFillLocked(events, order, fill)
+	}
+	listeners := o.listeners
+	o.mu.Unlock()
+
+	notify(listeners, events)
+}
+
+// HandleOrderUpdates is a websocket callback for the orderUpdates channel.
+func (o *OMS) HandleOrderUpdates(msg sdk.WSMessage) {
+	var updates []sdk.WsOrder
+	if err := json.Unmarshal(msg.Data, &updates); err != nil {
+		return
+	}
+	o.ApplyOrderUpdates(updates)
+}
+
+// HandleUserFills is a websocket callback for the userFills channel. The
+// snapshot sent on subscribing only fills tracked orders; its fills of
+// other orders are history and are not kept for later acknowledgements.
+func (o *OMS) HandleUserFills(msg sdk.WSMessage) {
+	var data sdk.WsUserFills
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		return
+	}
+	o.applyFills(data.Fills, data.IsSnapshot == nil || !*data.IsSnapshot)
+}
+
+// Reconcile resolves the orders a failed BulkOrders call left submitted from
+// the open orders and fills of user. Open orders rest and fills are applied;
+// an order found in neither is rejected, or canceled if it partially filled.
+func (o *OMS) Reconcile(info sdk.AccountQuerier, user string) error {
+	o.mu.RLock()
+	pending := false
+	for _, order := range o.orders {
+		pending = pending || order.unknown
+	}
+	o.mu.RUnlock()
+	if !pending {
+		return nil
+	}
+
+	open, err := info.OpenOrders(user)
+	if err != nil {
+		return fmt.Errorf("open orders: %w", err)
+	}
+	fills, err := info.UserFills(user)
+	if err != nil {
+		return fmt.Errorf("fills: %w", err)
+	}
+
+	o.mu.Lock()
+	var unknown []*Order
+	for _, order := range o.orders {
+		if order.unknown {
+			unknown = append(unknown, order)
+		}
+	}
+	var events []event
+	resting := make(map[sdk.Cloid]bool)
+	for _, oo := range open {
+		if oo.Cloid == nil {
+			continue
+		}
+		if order, ok := o.orders[*oo.Cloid]; ok && order.unknown {
+			resting[order.Cloid] = true
+			events = o.setOidLocked(events, order, uint64(oo.Oid))
+			events = o.transitionLocked(events, order, StatusResting, "")
+		}
+	}
+	for _, fill := range fills {
+		if fill.Cloid == nil {
+			continue
+		}
+		if _, seen := o.seenFills[fill.Tid]; seen {
+			continue
+		}
+		if order, ok := o.orders[*fill.Cloid]; ok && order.unknown {
+			events = o.setOidLocked(events, order, uint64(fill.Oid))
+			events = o.applyFillLocked(events, order, wsFill(fill))
+		}
+	}
+	for _, order := range unknown {
+		order.unknown = false
+		switch {
+		case resting[order.Cloid] || order.Status.Terminal():
+		case order.FilledSz > 0:
+			events = o.transitionLocked(events, order, StatusCanceled, "not open on the exchange")
+		default:
+			events = o.transitionLocked(events, order, StatusRejected, "not found on the exchange: "+order.Reason)
+		}
+	}
+	listeners := o.listeners
+	o.mu.Unlock()
+
+	notify(listeners, events)
+	return nil
+}
+
+func (o *OMS) Get(cloid sdk.Cloid) (Order, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	order, ok := o.orders[cloid]
+	if !ok {
+		return Order{}, false
+	}
+	return snapshot(order), true
+}
+
+func (o *OMS) GetByOid(oid uint64) (Order, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	cloid, ok := o.byOid[oid]
+	if !ok {
+		return Order{}, false
+	}
+	return snapshot(o.orders[cloid]), true
+}
+
+// Orders returns the orders accepted by match, or all orders if match is nil.
+func (o *OMS) Orders(match func(Order) bool) []Order {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	result := make([]Order, 0, len(o.orders))
+	for _, order := range o.orders {
+		s := snapshot(order)
+		if match == nil || match(s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// Open returns the orders that have not reached a terminal state.
+func (o *OMS) Open() []Order {
+	return o.Orders(func(order Order) bool { return !order.Status.Terminal() })
+}
+
+// OpenOrderCount returns the number of open orders, making the OMS an
+// sdk.OpenOrderSource for the risk checks. Only orders sent through the OMS
+// are counted.
+func (o *OMS) OpenOrderCount() (int, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	count := 0
+	for _, order := range o.orders {
+		if !order.Status.Terminal() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Forget drops terminal orders last updated before cutoff with their fills,
+// and the fills before cutoff kept for orders not yet acknowledged.
+func (o *OMS) Forget(cutoff time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for cloid, order := range o.orders {
+		if order.Status.Terminal() && order.UpdatedAt.Before(cutoff) {
+			delete(o.orders, cloid)
+			delete(o.byOid, order.Oid)
+			for _, fill := range order.Fills {
+				delete(o.seenFills, fill.Tid)
+			}
+		}
+	}
+	ms := cutoff.UnixMilli()
+	for oid, fills := range o.pendingFills {
+		if fills[len(fills)-1].Time < ms {
+			delete(o.pendingFills, oid)
+		}
+	}
+}
+
+func (o *OMS) lookupLocked(cloid *sdk.Cloid, oid uint64) *Order {
+	if cloid != nil {
+		if order, ok := o.orders[*cloid]; ok {
+			return order
+		}
+	}
+	if c, ok := o.byOid[oid]; ok {
+		return o.orders[c]
+	}
+	return nil
+}
+
+func (o *OMS) setOidLocked(events []event, order *Order, oid uint64) []event {
+	if oid == 0 || order.Oid == oid {
+		return events
+	}
+	order.Oid = oid
+	order.unknown = false
+	o.byOid[oid] = order.Cloid
+	pending := o.pendingFills[oid]
+	delete(o.pendingFills, oid)
+	for _, fill := range pending {
+		events = o.applyFillLocked(events, order, fill)
+	}
+	return events
+}
+
+func (o *OMS) addPendingLocked(fill sdk.WsFill) {
+	oid := uint64(fill.Oid)
+	if _, ok := o.pendingFills[oid]; !ok && len(o.pendingFills) >= maxPendingFills {
+		var oldest uint64
+		oldestTime := int64(math.MaxInt64)
+		for pendingOid, fills := range o.pendingFills {
+			if t := fills[len(fills)-1].Time; t < oldestTime {
+				oldest, oldestTime = pendingOid, t
+			}
+		}
+		delete(o.pendingFills, oldest)
+	}
+	o.pendingFills[oid] = append(o.pendingFills[oid], fill)
+}
+
+func (o *OMS) applyFillLocked(events []event, order *Order, fill sdk.WsFill) []event {
+	o.seenFills[fill.Tid] = struct{}{}
+	px, _ := strconv.ParseFloat(fill.Px, 64)
+	sz, _ := strconv.ParseFloat(fill.Sz, 64)
+	fee, _ := strconv.ParseFloat(fill.Fee, 64)
+
+	order.streamSz += sz
+	order.streamNotional += px * sz
+	order.Fees += fee
+	order.Fills = append(order.Fills, fill)
+	order.updateFilled()
+
+	next := StatusPartiallyFilled
+	if order.RemainingSz() <= sizeEpsilon {
+		next = StatusFilled
+	}
+	prev := order.Status
+	before := len(events)
+	events = o.transitionLocked(events, order, next, "")
+	if len(events) == before {
+		// no state change, still report the fill
+		order.UpdatedAt = time.Now()
+		events = append(events, event{order: snapshot(order), prev: prev})
+	}
+	return events
+}
+
+// applyStatusFillLocked applies the fill a BulkOrders status reports for an
+// order that filled at once. What did not fill was canceled.
+func (o *OMS) applyStatusFillLocked(events []event, order *Order, status *sdk.ExchangeFilledOrder) []event {
+	filled := order.FilledSz
+	order.statusSz, _ = strconv.ParseFloat(status.TotalSize, 64)
+	order.statusPx, _ = strconv.ParseFloat(status.AveragePx, 64)
+	order.updateFilled()
+
+	next, reason := StatusFilled, ""
+	if order.RemainingSz() > sizeEpsilon {
+		next, reason = StatusCanceled, "canceled"
+	}
+	prev := order.Status
+	before := len(events)
+	events = o.transitionLocked(events, order, next, reason)
+	if len(events) == before && order.FilledSz != filled {
+		order.UpdatedAt = time.Now()
+		events = append(events, event{order: snapshot(order), prev: prev})
+	}
+	return events
+}
+
+// updateFilled sets FilledSz and AvgPx from the userFills stream or from
+// the BulkOrders status, whichever reports more, as both cover the same
+// execution of the order.
+func (order *Order) updateFilled() {
+	if order.streamSz > 0 && order.streamSz >= order.statusSz-sizeEpsilon {
+		order.FilledSz = order.streamSz
+		order.AvgPx = order.streamNotional / order.streamSz
+		return
+	}
+	order.FilledSz, order.AvgPx = order.statusSz, order.statusPx
+}
+
+const sizeEpsilon = 1e-9
+
+func (o *OMS) transitionLocked(events []event, order *Order, next Status, reason string) []event {
+	prev := order.Status
+	if prev.Terminal() || next.rank() < prev.rank() || next == prev {
+		return events
+	}
+	order.Status = next
+	order.unknown = false
+	if reason != "" {
+		order.Reason = reason
+	}
+	order.UpdatedAt = time.Now()
+	return append(events, event{order: snapshot(order), prev: prev})
+}
+
+// outcomeUnknown reports whether err leaves it open whether the exchange
+// received the action, as for a timeout or a dropped connection.
+func outcomeUnknown(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func wsFill(f sdk.Fill) sdk.WsFill {
+	fill := sdk.WsFill{
+		Coin:          f.Coin,
+		Px:            f.Price,
+		Sz:            f.Size,
+		Side:          f.Side,
+		Time:          f.Time,
+		StartPosition: f.StartPosition,
+		Dir:           f.Dir,
+		ClosedPnl:     f.ClosedPnl,
+		Hash:          f.Hash,
+		Oid:           f.Oid,
+		Crossed:       f.Crossed,
+		Fee:           f.Fee,
+		Tid:           f.Tid,
+		FeeToken:      f.FeeToken,
+		Cloid:         f.Cloid,
+	}
+	if f.BuilderFee != "" {
+		fill.BuilderFee = &f.BuilderFee
+	}
+	return fill
+}
+
+func snapshot(order *Order) Order {
+	s := *order
+	s.Fills = append([]sdk.WsFill(nil), order.Fills...)
+	return s
+}
+
+func notify(listeners []ChangeFunc, events []event) {
+	for _, ev := range events {
+		for _, fn := range listeners {
+			fn(ev.order, ev.prev)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package oms_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/oms"
+)
+
+func TestOMSLifecycle(t *testing.T) {
+	srv := hltest.NewServer(hltest.Config{})
+	defer srv.Close()
+	key, _ := crypto.GenerateKey()
+	signer, err := sdk.NewLocalSignerFromHex(hex.EncodeToString(crypto.FromECDSA(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Fund(signer.Address(), 10_000)
+	srv.SetBook("SOL", []sdk.Level{{Px: 149, Sz: 100}}, []sdk.Level{{Px: 151, Sz: 100}})
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+
+	ws := sdk.NewWebsocketClient(srv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+
+	orders := oms.New()
+	changes := make(chan oms.Order, 16)
+	orders.OnChange(func(order oms.Order, _ oms.Status) { changes <- order })
+	if err := orders.Attach(ws, signer.Address().Hex()); err != nil {
+		t.Fatalf("Failed to attach OMS: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := orders.PlaceOrders(exchange, []sdk.OrderRequest{{
+		Coin:      "SOL",
+		IsBuy:     true,
+		Size:      1,
+		LimitPx:   150,
+		OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifAlo}},
+	}}, nil); err != nil {
+		t.Fatalf("Place orders failed: %v", err)
+	}
+	srv.SetBook("SOL", []sdk.Level{{Px: 148, Sz: 100}}, []sdk.Level{{Px: 149, Sz: 100}})
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case order := <-changes:
+			// the orderUpdates and userFills streams arrive in either order
+			if order.Status != oms.StatusFilled || len(order.Fills) == 0 {
+				continue
+			}
+			if order.FilledSz != 1 || order.AvgPx != 150 || order.Oid == 0 {
+				t.Errorf("Unexpected filled order: %+v", order)
+			}
+			if open, _ := orders.OpenOrderCount(); open != 0 {
+				t.Errorf("Expected no open orders, got %d", open)
+			}
+			orders.Forget(time.Now().Add(time.Minute))
+			if n := len(orders.Orders(nil)); n != 0 {
+				t.Errorf("Expected the filled order to be forgotten, got %d orders", n)
+			}
+			return
+		case <-deadline:
+			t.Fatalf("Order not filled, tracked: %+v", orders.Orders(nil))
+		}
+	}
+}
+
+func TestOMSPendingFills(t *testing.T) {
+	now := time.Now().UnixMilli()
+	fill := func(oid, tid int64, at int64) sdk.WsFill {
+		return sdk.WsFill{Coin: "SOL", Px: "150", Sz: "1", Oid: oid, Tid: tid, Time: at, Fee: "0"}
+	}
+	resting := func(o *oms.OMS, oid uint64) oms.Order {
+		req := sdk.OrderRequest{Coin: "SOL", IsBuy: true, Size: 1, LimitPx: 150}
+		cloid, err := o.Submit(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Cloid = &cloid
+		o.ApplyResults([]sdk.OrderRequest{req}, []any{&sdk.ExchangeRestingOrder{Oid: oid}}, nil)
+		order, _ := o.Get(cloid)
+		return order
+	}
+
+	t.Run("early fill", func(t *testing.T) {
+		o := oms.New()
+		o.ApplyFills([]sdk.WsFill{fill(7, 1, now)})
+		if order := resting(o, 7); order.Status != oms.StatusFilled {
+			t.Errorf("Expected the early fill to be applied, got %+v", order)
+		}
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		o := oms.New()
+		isSnapshot := true
+		data, _ := json.Marshal(sdk.WsUserFills{IsSnapshot: &isSnapshot, Fills: []sdk.WsFill{fill(7, 1, now)}})
+		o.HandleUserFills(sdk.WSMessage{Channel: sdk.SubTypeUserFills, Data: data})
+		if order := resting(o, 7); order.Status != oms.StatusResting {
+			t.Errorf("Expected snapshot fills not to be kept, got %+v", order)
+		}
+	})
+
+	t.Run("forget", func(t *testing.T) {
+		o := oms.New()
+		o.ApplyFills([]sdk.WsFill{fill(7, 1, now-60_000), fill(8, 2, now)})
+		o.Forget(time.Now().Add(-time.Second))
+		if order := resting(o, 7); order.Status != oms.StatusResting {
+			t.Errorf("Expected the old pending fill to be forgotten, got %+v", order)
+		}
+		if order := resting(o, 8); order.Status != oms.StatusFilled {
+			t.Errorf("Expected the recent pending fill to be kept, got %+v", order)
+		}
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		o := oms.New()
+		fills := make([]sdk.WsFill, 2000)
+		for i := range fills {
+			fills[i] = fill(int64(i+1), int64(i+1), now+int64(i))
+		}
+		o.ApplyFills(fills)
+		if order := resting(o, 1); order.Status != oms.StatusResting {
+			t.Errorf("Expected the oldest pending fill to be dropped, got %+v", order)
+		}
+		if order := resting(o, 2000); order.Status != oms.StatusFilled {
+			t.Errorf("Expected the latest pending fill to be kept, got %+v", order)
+		}
+	})
+}
+
+func TestOMSFilledStatus(t *testing.T) {
+	place := func(o *oms.OMS, size float64, status any) oms.Order {
+		req := sdk.OrderRequest{Coin: "SOL", IsBuy: true, Size: size, LimitPx: 151}
+		cloid, err := o.Submit(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Cloid = &cloid
+		o.ApplyResults([]sdk.OrderRequest{req}, []any{status}, nil)
+		order, _ := o.Get(cloid)
+		return order
+	}
+
+	o := oms.New()
+	order := place(o, 1, &sdk.ExchangeFilledOrder{Oid: 7, TotalSize: "1", AveragePx: "150.5"})
+	if order.Status != oms.StatusFilled || order.FilledSz != 1 || order.AvgPx != 150.5 {
+		t.Errorf("Expected the status fill to be applied, got %+v", order)
+	}
+	if open, _ := o.OpenOrderCount(); open != 0 {
+		t.Errorf("Expected no open orders, got %d", open)
+	}
+	o.ApplyFills([]sdk.WsFill{{Coin: "SOL", Px: "150.5", Sz: "1", Oid: 7, Tid: 1, Fee: "0.05"}})
+	if order, _ = o.Get(order.Cloid); order.FilledSz != 1 || order.Fees != 0.05 || len(order.Fills) != 1 {
+		t.Errorf("Expected the fill of the filled status not to count twice, got %+v", order)
+	}
+
+	order = place(o, 2, &sdk.ExchangeFilledOrder{Oid: 8, TotalSize: "0.5", AveragePx: "150"})
+	if order.Status != oms.StatusCanceled || order.FilledSz != 0.5 {
+		t.Errorf("Expected the unfilled rest to be canceled, got %+v", order)
+	}
+}
+
+func TestOMSReconcile(t *testing.T) {
+	srv := hltest.NewServer(hltest.Config{})
+	defer srv.Close()
+	key, _ := crypto.GenerateKey()
+	signer, err := sdk.NewLocalSignerFromHex(hex.EncodeToString(crypto.FromECDSA(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Fund(signer.Address(), 10_000)
+	srv.SetBook("SOL", []sdk.Level{{Px: 149, Sz: 100}}, []sdk.Level{{Px: 151, Sz: 100}})
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+	info, err := sdk.NewInfo(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := []sdk.OrderRequest{
+		{Coin: "SOL", IsBuy: true, Size: 1, LimitPx: 140, OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifAlo}}},
+		{Coin: "SOL", IsBuy: true, Size: 1, LimitPx: 152, OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifIoc}}},
+		{Coin: "SOL", IsBuy: true, Size: 1, LimitPx: 141, OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifAlo}}},
+	}
+	o := oms.New()
+	for i := range reqs {
+		cloid, err := o.Submit(reqs[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		reqs[i].Cloid = &cloid
+	}
+	// the first two reach the exchange, then the reply of the call is lost
+	if _, err := exchange.BulkOrders(reqs[:2], nil); err != nil {
+		t.Fatal(err)
+	}
+	timeout := &url.Error{Op: "Post", URL: srv.URL, Err: context.DeadlineExceeded}
+	o.ApplyResults(reqs, nil, timeout)
+	if open, _ := o.OpenOrderCount(); open != 3 {
+		t.Fatalf("Expected the orders to stay open after a timeout, got %+v", o.Orders(nil))
+	}
+
+	if err := o.Reconcile(info, signer.Address().Hex()); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	want := []oms.Status{oms.StatusResting, oms.StatusFilled, oms.StatusRejected}
+	for i, req := range reqs {
+		order, _ := o.Get(*req.Cloid)
+		if order.Status != want[i] {
+			t.Errorf("Order %d: expected %s, got %+v", i, want[i], order)
+		}
+	}
+	if order, _ := o.Get(*reqs[1].Cloid); order.FilledSz != 1 || order.AvgPx != 151 {
+		t.Errorf("Expected the reconciled fill to be applied, got %+v", order)
+	}
+}
+
+// restingPlacer is a mock sdk.OrderPlacer resting every order.
+type restingPlacer struct {
+	sdk.OrderPlacer // methods not overridden panic if called
+	sent            []sdk.OrderRequest
+}
+
+func (p *restingPlacer) BulkOrders(orders []sdk.OrderRequest, _ *sdk.BuilderInfo) ([]any, error) {
+	statuses := make([]any, len(orders))
+	for i, order := range orders {
+		p.sent = append(p.sent, order)
+		statuses[i] = &sdk.ExchangeRestingOrder{Oid: uint64(len(p.sent))}
+	}
+	return statuses, nil
+}
+
+func TestOMSMockPlacer(t *testing.T) {
+	placer := &restingPlacer{}
+	o := oms.New()
+	limit := sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifGtc}}
+	_, err := o.PlaceOrders(placer, []sdk.OrderRequest{
+		{Coin: "SOL", IsBuy: true, Size: 1, LimitPx: 100, OrderType: limit},
+		{Coin: "SOL", IsBuy: false, Size: 1, LimitPx: 110, OrderType: limit},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to place orders: %v", err)
+	}
+	if len(placer.sent) != 2 {
+		t.Fatalf("Expected 2 orders sent, got %d", len(placer.sent))
+	}
+	for _, req := range placer.sent {
+		order, ok := o.Get(*req.Cloid)
+		if !ok || order.Status != oms.StatusResting || order.Oid == 0 {
+			t.Errorf("Order %s not tracked as open: %+v", req.Cloid, order)
+		}
+	}
+}
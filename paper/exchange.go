@@ -0,0 +1,590 @@
+package paper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+type Config struct {
+	// Meta lists the perp coins that can be traded.
+	Meta *sdk.Meta
+	// Account is returned by AccountAddress and is the user of the events.
+	Account common.Address
+	// Balance is the starting USDC balance.
+	Balance float64
+	// Fees is the fee schedule and volume of the account being simulated,
+	// as returned by Info.UserFees. Nil uses the base tier rates.
+	Fees *sdk.UserFees
+}
+
+// Exchange is a simulated exchange implementing sdk.Trader. Orders match
+// against the live l2Book and trades streams of the coins being watched:
+// crossing orders take the liquidity of the current book, resting orders
+// fill when the book crosses them or a trade prints through their price.
+// Only perps are simulated, and neither margin nor liquidations are.
+//
+// The userFills and orderUpdates a real account would receive are delivered
+// to the callbacks given to SubscribeToUserFills and SubscribeToOrderUpdates
+// in the format of the websocket, so consumers such as the oms package can
+// be attached unchanged.
+type Exchange struct {
+	ws         *sdk.WebsocketClient
+	cfg        Config
+	assets     map[string]int
+	szDecimals map[string]int
+
+	mu        sync.Mutex
+	books     map[string]*sdk.L2Book
+	consumed  map[string]map[float64]float64 // liquidity taken from the current book
+	watched   map[string][]subscription
+	orders    map[uint64]*order
+	cloids    map[sdk.Cloid]uint64
+	positions map[string]*position
+	balance   float64
+	fees      *feeModel
+	nextOid   uint64
+	nextTid   int64
+
+	listenMu  sync.Mutex
+	fillSubs  map[int]func(sdk.WSMessage)
+	orderSubs map[int]func(sdk.WSMessage)
+	nextSubID int
+}
+
+type subscription struct {
+	sub sdk.Subscription
+	id  int
+}
+
+var _ sdk.Trader = (*Exchange)(nil)
+
+func NewExchange(ws *sdk.WebsocketClient, cfg Config) *Exchange {
+	x := &Exchange{
+		ws:         ws,
+		cfg:        cfg,
+		assets:     make(map[string]int),
+		szDecimals: make(map[string]int),
+		books:      make(map[string]*sdk.L2Book),
+		consumed:   make(map[string]map[float64]float64),
+		watched:    make(map[string][]subscription),
+		orders:     make(map[uint64]*order),
+		cloids:     make(map[sdk.Cloid]uint64),
+		positions:  make(map[string]*position),
+		balance:    cfg.Balance,
+		fees:       newFeeModel(cfg.Fees),
+		nextOid:    1,
+		nextTid:    1,
+		fillSubs:   make(map[int]func(sdk.WSMessage)),
+		orderSubs:  make(map[int]func(sdk.WSMessage)),
+	}
+	if cfg.Meta != nil {
+		for asset, info := range cfg.Meta.Universe {
+			x.assets[info.Name] = asset
+			x.szDecimals[info.Name] = info.SzDecimals
+		}
+	}
+	return x
+}
+
+// Watch subscribes to the l2Book and trades streams of coins. Orders on a
+// coin fail until its first book arrives. Without a websocket client the
+// coins are only marked watched and the caller feeds HandleL2Book and
+// HandleTrades itself.
+func (x *Exchange) Watch(coins ...string) error {
+	for _, coin := range coins {
+		if _, ok := x.assets[coin]; !ok {
+			return fmt.Errorf("coin %s does not exist", coin)
+		}
+		x.mu.Lock()
+		_, ok := x.watched[coin]
+		if !ok && x.ws == nil {
+			x.watched[coin] = nil
+			ok = true
+		}
+		x.mu.Unlock()
+		if ok {
+			continue
+		}
+		bookID, err := x.ws.SubscribeToOrderbook(coin, x.HandleL2Book)
+		if err != nil {
+			return err
+		}
+		tradesID, err := x.ws.SubscribeToTrades(coin, x.HandleTrades)
+		if err != nil {
+			_ = x.ws.Unsubscribe(sdk.Subscription{Type: sdk.SubTypeL2Book, Coin: coin}, bookID)
+			return err
+		}
+		x.mu.Lock()
+		x.watched[coin] = []subscription{
+			{sdk.Subscription{Type: sdk.SubTypeL2Book, Coin: coin}, bookID},
+			{sdk.Subscription{Type: sdk.SubTypeTrades, Coin: coin}, tradesID},
+		}
+		x.mu.Unlock()
+	}
+	return nil
+}
+
+// Close unsubscribes from the market data streams.
+func (x *Exchange) Close() {
+	x.mu.Lock()
+	watched := x.watched
+	x.watched = make(map[string][]subscription)
+	x.mu.Unlock()
+	for _, subs := range watched {
+		for _, s := range subs {
+			_ = x.ws.Unsubscribe(s.sub, s.id)
+		}
+	}
+}
+
+// SubscribeToUserFills registers a callback for simulated fills, delivered
+// as userFills messages.
+func (x *Exchange) SubscribeToUserFills(callback func(sdk.WSMessage)) int {
+	x.listenMu.Lock()
+	defer x.listenMu.Unlock()
+	x.nextSubID++
+	x.fillSubs[x.nextSubID] = callback
+	return x.nextSubID
+}
+
+// SubscribeToOrderUpdates registers a callback for simulated order updates,
+// delivered as orderUpdates messages.
+func (x *Exchange) SubscribeToOrderUpdates(callback func(sdk.WSMessage)) int {
+	x.listenMu.Lock()
+	defer x.listenMu.Unlock()
+	x.nextSubID++
+	x.orderSubs[x.nextSubID] = callback
+	return x.nextSubID
+}
+
+func (x *Exchange) Unsubscribe(id int) {
+	x.listenMu.Lock()
+	defer x.listenMu.Unlock()
+	delete(x.fillSubs, id)
+	delete(x.orderSubs, id)
+}
+
+func (x *Exchange) AccountAddress() common.Address {
+	return x.cfg.Account
+}
+
+func (x *Exchange) RoundPrice(coin string, px float64) (float64, error) {
+	szDec, ok := x.szDecimals[coin]
+	if !ok {
+		return 0, fmt.Errorf("coin %s does not exist", coin)
+	}
+	// perp prices have 5 significant figures and at most 6-szDecimals decimals
+	return sdk.RoundToSignificantAndDecimal(px, 5, 6-szDec), nil
+}
+
+func (x *Exchange) RoundSize(coin string, size float64) (float64, error) {
+	szDec, ok := x.szDecimals[coin]
+	if !ok {
+		return 0, fmt.Errorf("coin %s does not exist", coin)
+	}
+	return sdk.RoundToDecimal(size, szDec), nil
+}
+
+func (x *Exchange) Order(req sdk.OrderRequest, builder *sdk.BuilderInfo) (any, error) {
+	statuses, err := x.BulkOrders([]sdk.OrderRequest{req}, builder)
+	if err != nil {
+		return nil, err
+	}
+	return statuses[0], nil
+}
+
+func (x *Exchange) BulkOrders(orders []sdk.OrderRequest, builder *sdk.BuilderInfo) ([]any, error) {
+	return x.BulkOrdersWithGrouping(orders, sdk.GroupingNa, builder)
+}
+
+// BulkOrdersWithGrouping places orders in sequence. With normalTpsl
+// grouping, trigger orders following an entry only become active once the
+// entry filled, like on the exchange, and are rejected or canceled with an
+// entry that is rejected or canceled unfilled. The builder is ignored.
+func (x *Exchange) BulkOrdersWithGrouping(orders []sdk.OrderRequest, grouping string, _ *sdk.BuilderInfo) ([]any, error) {
+	if err := x.checkCoins(orders); err != nil {
+		return nil, err
+	}
+
+	ev := new(events)
+	x.mu.Lock()
+	statuses := make([]any, len(orders))
+	var (
+		parent   *order
+		hasEntry bool
+	)
+	for i, req := range orders {
+		var waitFor uint64
+		if grouping == sdk.GroupingNormalTpsl && req.OrderType.Trigger != nil && hasEntry {
+			if parent == nil || !x.activeLocked(parent) {
+				statuses[i] = errors.New("Order rejected because its entry order was not placed.")
+				continue
+			}
+			if parent.filled == 0 {
+				waitFor = parent.oid
+			}
+		}
+		o, status := x.submitLocked(ev, req, waitFor)
+		if req.OrderType.Trigger == nil && !hasEntry {
+			parent, hasEntry = o, true
+		}
+		statuses[i] = status
+	}
+	x.mu.Unlock()
+
+	x.emit(ev)
+	return statuses, nil
+}
+
+func (x *Exchange) MarketOrder(req sdk.MarketRequest, builder *sdk.BuilderInfo) (any, error) {
+	statuses, err := x.BulkMarketOrders([]sdk.MarketRequest{req}, builder)
+	if err != nil {
+		return nil, err
+	}
+	return statuses[0], nil
+}
+
+// BulkMarketOrders sends IOC orders priced Slippage away from MarketPrice,
+// or from the mid of the simulated book when it is zero.
+func (x *Exchange) BulkMarketOrders(reqs []sdk.MarketRequest, builder *sdk.BuilderInfo) ([]any, error) {
+	orders := make([]sdk.OrderRequest, len(reqs))
+	for i, r := range reqs {
+		px := r.MarketPrice
+		if px == 0 {
+			x.mu.Lock()
+			mid, err := x.midLocked(r.Coin)
+			x.mu.Unlock()
+			if err != nil {
+				return nil, err
+			}
+			px = mid
+		}
+		if r.IsBuy {
+			px *= 1 + r.Slippage
+		} else {
+			px *= 1 - r.Slippage
+		}
+		px, err := x.RoundPrice(r.Coin, px)
+		if err != nil {
+			return nil, err
+		}
+		orders[i] = sdk.OrderRequest{
+			Coin:       r.Coin,
+			IsBuy:      r.IsBuy,
+			Size:       r.Size,
+			LimitPx:    px,
+			OrderType:  sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifIoc}},
+			ReduceOnly: r.ReduceOnly,
+			Cloid:      r.Cloid,
+		}
+	}
+	return x.BulkOrders(orders, builder)
+}
+
+func (x *Exchange) Cancel(req sdk.CancelRequest) (any, error) {
+	statuses, err := x.BulkCancel([]sdk.CancelRequest{req})
+	if err != nil {
+		return nil, err
+	}
+	return statuses[0], nil
+}
+
+func (x *Exchange) CancelByCloid(req sdk.CancelByCloidRequest) (any, error) {
+	statuses, err := x.BulkCancelByCloid([]sdk.CancelByCloidRequest{req})
+	if err != nil {
+		return nil, err
+	}
+	return statuses[0], nil
+}
+
+func (x *Exchange) BulkCancel(reqs []sdk.CancelRequest) ([]any, error) {
+	ev := new(events)
+	x.mu.Lock()
+	statuses := make([]any, len(reqs))
+	for i, req := range reqs {
+		asset, ok := x.assets[req.Coin]
+		if !ok {
+			x.mu.Unlock()
+			return nil, fmt.Errorf("coin %s does not exist", req.Coin)
+		}
+		statuses[i] = x.cancelLocked(ev, x.orders[req.Oid], req.Coin, asset)
+	}
+	x.mu.Unlock()
+
+	x.emit(ev)
+	return statuses, nil
+}
+
+func (x *Exchange) BulkCancelByCloid(reqs []sdk.CancelByCloidRequest) ([]any, error) {
+	ev := new(events)
+	x.mu.Lock()
+	statuses := make([]any, len(reqs))
+	for i, req := range reqs {
+		asset, ok := x.assets[req.Coin]
+		if !ok {
+			x.mu.Unlock()
+			return nil, fmt.Errorf("coin %s does not exist", req.Coin)
+		}
+		var o *order
+		if oid, ok := x.cloids[req.Cloid]; ok {
+			o = x.orders[oid]
+		}
+		statuses[i] = x.cancelLocked(ev, o, req.Coin, asset)
+	}
+	x.mu.Unlock()
+
+	x.emit(ev)
+	return statuses, nil
+}
+
+func (x *Exchange) ModifyOrder(req sdk.ModifyRequest) (any, error) {
+	statuses, err := x.BulkModifyOrders([]sdk.ModifyRequest{req})
+	if err != nil {
+		return nil, err
+	}
+	return statuses[0], nil
+}
+
+// BulkModifyOrders replaces orders. As on the exchange, the replacement gets
+// a new oid.
+func (x *Exchange) BulkModifyOrders(reqs []sdk.ModifyRequest) ([]any, error) {
+	for _, req := range reqs {
+		if err := req.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	orders := make([]sdk.OrderRequest, len(reqs))
+	for i, req := range reqs {
+		orders[i] = req.OrderRequest
+	}
+	if err := x.checkCoins(orders); err != nil {
+		return nil, err
+	}
+
+	ev := new(events)
+	x.mu.Lock()
+	statuses := make([]any, len(reqs))
+	for i, req := range reqs {
+		oid := req.Oid
+		if req.Cloid != nil {
+			oid = x.cloids[*req.Cloid]
+		}
+		o, ok := x.orders[oid]
+		if !ok || o.req.Coin != req.OrderRequest.Coin {
+			statuses[i] = errors.New("Cannot modify canceled or filled order")
+			continue
+		}
+		x.removeLocked(ev, o, statusCanceled)
+		// the tp/sl of an unfilled entry wait for the order replacing it
+		children := x.childrenLocked(o)
+		for _, child := range children {
+			child.waitFor = x.nextOid
+		}
+		var replaced *order
+		replaced, statuses[i] = x.submitLocked(ev, req.OrderRequest, o.waitFor)
+		if replaced == nil {
+			for _, child := range children {
+				x.removeLocked(ev, child, statusCanceled)
+			}
+		}
+	}
+	x.mu.Unlock()
+
+	x.emit(ev)
+	return statuses, nil
+}
+
+// UserState returns the simulated account in the format of Info.UserState,
+// with positions marked at the mid of the simulated books.
+func (x *Exchange) UserState() *sdk.UserState {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	state := &sdk.UserState{Time: time.Now().UnixMilli()}
+	var upnl, ntl float64
+	for coin, pos := range x.positions {
+		if pos.szi == 0 {
+			continue
+		}
+		mark, err := x.midLocked(coin)
+		if err != nil {
+			mark = pos.entry
+		}
+		value := math.Abs(pos.szi) * mark
+		pnl := pos.szi * (mark - pos.entry)
+		upnl += pnl
+		ntl += value
+		entry := sdk.FloatToString(pos.entry)
+		state.AssetPositions = append(state.AssetPositions, sdk.AssetPosition{
+			Type: "oneWay",
+			Position: sdk.Position{
+				Coin:          coin,
+				EntryPx:       &entry,
+				Leverage:      sdk.Leverage{Type: "cross", Value: 1},
+				PositionValue: sdk.FloatToString(value),
+				Szi:           sdk.FloatToString(pos.szi),
+				UnrealizedPnl: sdk.FloatToString(pnl),
+			},
+		})
+	}
+	summary := sdk.MarginSummary{
+		AccountValue: sdk.FloatToString(x.balance + upnl),
+		TotalNtlPos:  sdk.FloatToString(ntl),
+		TotalRawUsd:  sdk.FloatToString(x.balance),
+	}
+	state.MarginSummary = summary
+	state.CrossMarginSummary = summary
+	state.Withdrawable = sdk.FloatToString(x.balance)
+	return state
+}
+
+// OpenOrders returns the resting and untriggered orders in the format of
+// Info.OpenOrders.
+func (x *Exchange) OpenOrders() []sdk.OpenOrder {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	orders := make([]sdk.OpenOrder, 0, len(x.orders))
+	for _, o := range x.sortedOrdersLocked("") {
+		orders = append(orders, sdk.OpenOrder{
+			Coin:      o.req.Coin,
+			LimitPx:   o.req.LimitPx,
+			Oid:       int64(o.oid),
+			Side:      side(o.req.IsBuy),
+			Size:      o.remaining,
+			Timestamp: o.timestamp,
+			Cloid:     o.req.Cloid,
+		})
+	}
+	return orders
+}
+
+// FrontendOpenOrders returns the open orders in the format of
+// Info.FrontendOpenOrders.
+func (x *Exchange) FrontendOpenOrders() []sdk.FrontendOpenOrder {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	orders := make([]sdk.FrontendOpenOrder, 0, len(x.orders))
+	for _, o := range x.sortedOrdersLocked("") {
+		order := sdk.FrontendOpenOrder{
+			Coin:             o.req.Coin,
+			LimitPx:          o.req.LimitPx,
+			Oid:              int64(o.oid),
+			OrderType:        "Limit",
+			OrigSz:           o.req.Size,
+			ReduceOnly:       o.req.ReduceOnly,
+			Side:             side(o.req.IsBuy),
+			Size:             o.remaining,
+			Timestamp:        o.timestamp,
+			TriggerCondition: "N/A",
+			Cloid:            o.req.Cloid,
+		}
+		if trigger := o.req.OrderType.Trigger; trigger != nil {
+			order.IsTrigger = !o.triggered
+			order.TriggerPx, _ = strconv.ParseFloat(trigger.TriggerPx, 64)
+			order.OrderType = orderTypeName(trigger)
+		}
+		orders = append(orders, order)
+	}
+	return orders
+}
+
+// Balance returns the USDC balance, which moves with realized PnL and fees.
+func (x *Exchange) Balance() float64 {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.balance
+}
+
+// Deposit adds usdc to the balance, or withdraws it if negative.
+func (x *Exchange) Deposit(usdc float64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.balance += usdc
+}
+
+// HandleL2Book is a websocket callback for the l2Book channel.
+func (x *Exchange) HandleL2Book(msg sdk.WSMessage) {
+	book := new(sdk.L2Book)
+	if err := json.Unmarshal(msg.Data, book); err != nil {
+		return
+	}
+	ev := new(events)
+	x.mu.Lock()
+	if _, ok := x.watched[book.Coin]; ok {
+		x.onBookLocked(ev, book)
+	}
+	x.mu.Unlock()
+	x.emit(ev)
+}
+
+// HandleTrades is a websocket callback for the trades channel.
+func (x *Exchange) HandleTrades(msg sdk.WSMessage) {
+	var trades []sdk.Trade
+	if err := json.Unmarshal(msg.Data, &trades); err != nil {
+		return
+	}
+	ev := new(events)
+	x.mu.Lock()
+	for _, trade := range trades {
+		if _, ok := x.watched[trade.Coin]; ok {
+			x.onTradeLocked(ev, trade)
+		}
+	}
+	x.mu.Unlock()
+	x.emit(ev)
+}
+
+func (x *Exchange) checkCoins(orders []sdk.OrderRequest) error {
+	for _, order := range orders {
+		if _, ok := x.assets[order.Coin]; !ok {
+			return fmt.Errorf("coin %s does not exist", order.Coin)
+		}
+	}
+	return nil
+}
+
+// events collects what a call produced, to be delivered after the lock is
+// released.
+type events struct {
+	fills   []sdk.WsFill
+	updates []sdk.WsOrder
+}
+
+func (x *Exchange) emit(ev *events) {
+	if len(ev.fills) == 0 && len(ev.updates) == 0 {
+		return
+	}
+	user := x.cfg.Account.Hex()
+	x.listenMu.Lock()
+	fillSubs := make([]func(sdk.WSMessage), 0, len(x.fillSubs))
+	for _, fn := range x.fillSubs {
+		fillSubs = append(fillSubs, fn)
+	}
+	orderSubs := make([]func(sdk.WSMessage), 0, len(x.orderSubs))
+	for _, fn := range x.orderSubs {
+		orderSubs = append(orderSubs, fn)
+	}
+	x.listenMu.Unlock()
+
+	if len(ev.updates) > 0 && len(orderSubs) > 0 {
+		data, _ := json.Marshal(ev.updates)
+		msg := sdk.WSMessage{Channel: sdk.SubTypeOrderUpdates, Data: data}
+		for _, fn := range orderSubs {
+			fn(msg)
+		}
+	}
+	if len(ev.fills) > 0 && len(fillSubs) > 0 {
+		data, _ := json.Marshal(sdk.WsUserFills{User: user, Fills: ev.fills})
+		msg := sdk.WSMessage{Channel: sdk.SubTypeUserFills, Data: data}
+		for _, fn := range fillSubs {
+			fn(msg)
+		}
+	}
+}
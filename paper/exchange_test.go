@@ -0,0 +1,156 @@
+package paper_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/paper"
+)
+
+func newExchange(t *testing.T) *paper.Exchange {
+	t.Helper()
+	x := paper.NewExchange(nil, paper.Config{
+		Meta:    &sdk.Meta{Universe: []sdk.AssetInfo{{Name: "BTC", SzDecimals: 5}}},
+		Account: common.HexToAddress("0x1"),
+		Balance: 10_000,
+	})
+	if err := x.Watch("BTC"); err != nil {
+		t.Fatal(err)
+	}
+	setBook(x, 99_990, 100_010)
+	return x
+}
+
+func setBook(x *paper.Exchange, bid, ask float64) {
+	data, _ := json.Marshal(sdk.L2Book{Coin: "BTC", Levels: [][]sdk.Level{{{Px: bid, Sz: 1}}, {{Px: ask, Sz: 1}}}})
+	x.HandleL2Book(sdk.WSMessage{Channel: sdk.SubTypeL2Book, Data: data})
+}
+
+func TestPaperExchange(t *testing.T) {
+	x := newExchange(t)
+	var fills []sdk.WsFill
+	x.SubscribeToUserFills(func(msg sdk.WSMessage) {
+		var data sdk.WsUserFills
+		if json.Unmarshal(msg.Data, &data) == nil {
+			fills = append(fills, data.Fills...)
+		}
+	})
+
+	var trader sdk.Trader = x
+	status, err := trader.MarketOrder(sdk.MarketRequest{Coin: "BTC", IsBuy: true, Size: 0.01, Slippage: 0.01}, nil)
+	if err != nil {
+		t.Fatalf("Failed to place market order: %v", err)
+	}
+	if _, ok := status.(*sdk.ExchangeFilledOrder); !ok {
+		t.Fatalf("Expected filled order, got %#v", status)
+	}
+	if len(fills) != 1 || fills[0].Px != "100010" {
+		t.Errorf("Expected one fill at the ask, got %+v", fills)
+	}
+
+	status, err = trader.Order(sdk.OrderRequest{
+		Coin:       "BTC",
+		IsBuy:      false,
+		Size:       0.01,
+		LimitPx:    90_000,
+		ReduceOnly: true,
+		OrderType: sdk.OrderType{Trigger: &sdk.TriggerOrderType{
+			TriggerPx: "90000",
+			IsMarket:  true,
+			Tpsl:      sdk.StopLose,
+		}},
+	}, nil)
+	if _, ok := status.(*sdk.ExchangeRestingOrder); err != nil || !ok {
+		t.Fatalf("Failed to place stop: %#v, %v", status, err)
+	}
+	setBook(x, 89_000, 89_010)
+	if len(fills) != 2 || len(x.OpenOrders()) != 0 || len(x.UserState().AssetPositions) != 0 {
+		t.Errorf("Expected the stop to close the position, got fills %+v, orders %+v", fills, x.OpenOrders())
+	}
+}
+
+func TestPaperNormalTpsl(t *testing.T) {
+	tpsl := func(entry sdk.OrderRequest) []sdk.OrderRequest {
+		trigger := func(px float64, tpsl string) sdk.OrderRequest {
+			return sdk.OrderRequest{
+				Coin: "BTC", IsBuy: false, Size: 0.01, LimitPx: px, ReduceOnly: true,
+				OrderType: sdk.OrderType{Trigger: &sdk.TriggerOrderType{
+					TriggerPx: sdk.FloatToString(px), IsMarket: true, Tpsl: tpsl,
+				}},
+			}
+		}
+		return []sdk.OrderRequest{entry, trigger(110_000, sdk.TakeProfit), trigger(90_000, sdk.StopLose)}
+	}
+	entry := func(px float64, tif string) sdk.OrderRequest {
+		return sdk.OrderRequest{
+			Coin: "BTC", IsBuy: true, Size: 0.01, LimitPx: px,
+			OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: tif}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		entry    sdk.OrderRequest
+		open     int
+		rejected bool
+	}{
+		{"filled", entry(100_010, sdk.TifIoc), 2, false},
+		{"resting", entry(99_000, sdk.TifGtc), 3, false},
+		{"ioc unfilled", entry(99_000, sdk.TifIoc), 0, true},
+		{"rejected", entry(100_010, sdk.TifAlo), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := newExchange(t)
+			statuses, err := x.BulkOrdersWithGrouping(tpsl(tt.entry), sdk.GroupingNormalTpsl, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, status := range statuses[1:] {
+				if _, isErr := status.(error); isErr != tt.rejected {
+					t.Errorf("Unexpected tp/sl status %#v", status)
+				}
+			}
+			if open := x.FrontendOpenOrders(); len(open) != tt.open {
+				t.Errorf("Expected %d open orders, got %+v", tt.open, open)
+			}
+		})
+	}
+
+	t.Run("canceled unfilled", func(t *testing.T) {
+		x := newExchange(t)
+		statuses, _ := x.BulkOrdersWithGrouping(tpsl(entry(99_000, sdk.TifGtc)), sdk.GroupingNormalTpsl, nil)
+		resting, ok := statuses[0].(*sdk.ExchangeRestingOrder)
+		if !ok {
+			t.Fatalf("Expected resting entry, got %#v", statuses[0])
+		}
+		if _, err := x.Cancel(sdk.CancelRequest{Coin: "BTC", Oid: resting.Oid}); err != nil {
+			t.Fatal(err)
+		}
+		if open := x.FrontendOpenOrders(); len(open) != 0 {
+			t.Errorf("Expected the tp/sl to be canceled with the entry, got %+v", open)
+		}
+	})
+	t.Run("modified entry", func(t *testing.T) {
+		x := newExchange(t)
+		statuses, _ := x.BulkOrdersWithGrouping(tpsl(entry(99_000, sdk.TifGtc)), sdk.GroupingNormalTpsl, nil)
+		resting, ok := statuses[0].(*sdk.ExchangeRestingOrder)
+		if !ok {
+			t.Fatalf("Expected resting entry, got %#v", statuses[0])
+		}
+		if _, err := x.ModifyOrder(sdk.NewModifyByOid(resting.Oid, entry(99_500, sdk.TifGtc))); err != nil {
+			t.Fatal(err)
+		}
+		setBook(x, 99_400, 99_450)
+		open := x.FrontendOpenOrders()
+		if len(open) != 2 || len(x.UserState().AssetPositions) != 1 {
+			t.Fatalf("Expected the replaced entry to fill and leave its tp/sl, got %+v", open)
+		}
+		setBook(x, 89_000, 89_010)
+		if positions := x.UserState().AssetPositions; len(positions) != 0 {
+			t.Errorf("Expected the stop to close the position, got %+v", positions)
+		}
+	})
+}
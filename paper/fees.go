@@ -0,0 +1,78 @@
+package paper
+
+import (
+	"strconv"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+// Base tier rates of the exchange, used when no fee schedule is given.
+const (
+	DefaultMakerRate = 0.00015
+	DefaultTakerRate = 0.00045
+)
+
+type vipTier struct {
+	cutoff float64
+	maker  float64
+	taker  float64
+}
+
+// feeModel starts at the account's current rates and moves to better VIP
+// tiers as simulated volume adds to the account's rolling volume.
+type feeModel struct {
+	maker  float64
+	taker  float64
+	tiers  []vipTier
+	volume float64
+}
+
+func newFeeModel(fees *sdk.UserFees) *feeModel {
+	m := &feeModel{maker: DefaultMakerRate, taker: DefaultTakerRate}
+	if fees == nil {
+		return m
+	}
+	if rate, err := strconv.ParseFloat(fees.UserAddRate, 64); err == nil {
+		m.maker = rate
+	}
+	if rate, err := strconv.ParseFloat(fees.UserCrossRate, 64); err == nil {
+		m.taker = rate
+	}
+	for _, tier := range fees.FeeSchedule.Tiers.VIP {
+		cutoff, err1 := strconv.ParseFloat(tier.NtlCutoff, 64)
+		maker, err2 := strconv.ParseFloat(tier.Add, 64)
+		taker, err3 := strconv.ParseFloat(tier.Cross, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		m.tiers = append(m.tiers, vipTier{cutoff: cutoff, maker: maker, taker: taker})
+	}
+	for _, day := range fees.DailyUserVolume {
+		add, _ := strconv.ParseFloat(day.UserAdd, 64)
+		cross, _ := strconv.ParseFloat(day.UserCross, 64)
+		m.volume += add + cross
+	}
+	return m
+}
+
+// rates returns the maker and taker rates for the current volume.
+func (m *feeModel) rates() (float64, float64) {
+	maker, taker := m.maker, m.taker
+	for _, tier := range m.tiers {
+		if m.volume >= tier.cutoff {
+			maker = min(maker, tier.maker)
+			taker = min(taker, tier.taker)
+		}
+	}
+	return maker, taker
+}
+
+// charge returns the fee of a fill and adds it to the volume.
+func (m *feeModel) charge(notional float64, crossed bool) float64 {
+	maker, taker := m.rates()
+	m.volume += notional
+	if crossed {
+		return notional * taker
+	}
+	return notional * maker
+}
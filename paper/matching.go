@@ -0,0 +1,521 @@
+package paper
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+// TriggerSlippage is how far from the trigger price a market trigger order
+// is priced, like on the exchange.
+const TriggerSlippage = 0.1
+
+const sizeEpsilon = 1e-9
+
+// Order statuses of orderUpdates.
+const (
+	statusOpen      = "open"
+	statusFilled    = "filled"
+	statusCanceled  = "canceled"
+	statusTriggered = "triggered"
+)
+
+type order struct {
+	oid       uint64
+	req       sdk.OrderRequest
+	remaining float64
+	filled    float64
+	notional  float64
+	timestamp int64
+	// waitFor is the oid of the normalTpsl entry the order waits on.
+	waitFor   uint64
+	triggered bool
+}
+
+// resting reports whether the order is a limit order on the book, as
+// opposed to a trigger order waiting for its trigger or its entry.
+func (o *order) resting() bool {
+	return o.waitFor == 0 && (o.req.OrderType.Trigger == nil || o.triggered)
+}
+
+type position struct {
+	szi   float64
+	entry float64
+}
+
+// submitLocked places an order and returns it, nil if it was rejected,
+// together with its status.
+func (x *Exchange) submitLocked(ev *events, req sdk.OrderRequest, waitFor uint64) (*order, any) {
+	asset := x.assets[req.Coin]
+	if req.Size <= 0 {
+		return nil, errors.New("Order has zero size.")
+	}
+	if req.LimitPx <= 0 {
+		return nil, fmt.Errorf("Order has invalid price. asset=%d", asset)
+	}
+	trigger := req.OrderType.Trigger
+	if trigger == nil && req.OrderType.Limit == nil {
+		return nil, errors.New("Order has no order type.")
+	}
+	if trigger != nil {
+		if _, err := strconv.ParseFloat(trigger.TriggerPx, 64); err != nil {
+			return nil, fmt.Errorf("Order has invalid trigger price. asset=%d", asset)
+		}
+	} else if _, ok := x.books[req.Coin]; !ok {
+		return nil, fmt.Errorf("no book for %s yet, call Watch first", req.Coin)
+	}
+	if req.ReduceOnly && trigger == nil && x.reducibleLocked(req.Coin, req.IsBuy) == 0 {
+		return nil, fmt.Errorf("Reduce only order would increase position. asset=%d", asset)
+	}
+
+	o := &order{
+		oid:       x.nextOid,
+		req:       req,
+		remaining: req.Size,
+		timestamp: time.Now().UnixMilli(),
+		waitFor:   waitFor,
+	}
+	x.nextOid++
+
+	if trigger != nil {
+		x.restLocked(ev, o)
+		if waitFor != 0 {
+			return o, "waitingForFill"
+		}
+		return o, &sdk.ExchangeRestingOrder{Oid: o.oid}
+	}
+
+	tif := req.OrderType.Limit.Tif
+	if tif == sdk.TifAlo {
+		if bbo, ok := x.bestLocked(req.Coin, !req.IsBuy); ok && crosses(req.IsBuy, req.LimitPx, bbo) {
+			return nil, fmt.Errorf("Post only order would have immediately matched, bbo was %s. asset=%d", sdk.FloatToString(bbo), asset)
+		}
+		x.restLocked(ev, o)
+		return o, &sdk.ExchangeRestingOrder{Oid: o.oid}
+	}
+	return o, x.executeLocked(ev, o, tif)
+}
+
+// executeLocked takes liquidity for a new or triggered order and rests what
+// is left of a GTC order.
+func (x *Exchange) executeLocked(ev *events, o *order, tif string) any {
+	asset := x.assets[o.req.Coin]
+	x.takeLocked(ev, o)
+	if o.remaining < sizeEpsilon {
+		return filledStatus(o)
+	}
+	if tif == sdk.TifGtc {
+		if _, ok := x.orders[o.oid]; !ok {
+			x.restLocked(ev, o)
+		}
+		return &sdk.ExchangeRestingOrder{Oid: o.oid}
+	}
+	if _, ok := x.orders[o.oid]; ok {
+		x.removeLocked(ev, o, statusCanceled)
+	}
+	if o.filled > 0 {
+		return filledStatus(o)
+	}
+	x.cancelChildrenLocked(ev, o)
+	return fmt.Errorf("Order could not immediately match against any resting orders. asset=%d", asset)
+}
+
+// takeLocked fills an order against the opposite side of the book, level by
+// level up to its limit price, as a taker.
+func (x *Exchange) takeLocked(ev *events, o *order) {
+	book := x.books[o.req.Coin]
+	if book == nil || len(book.Levels) < 2 {
+		return
+	}
+	levels := book.Levels[0]
+	if o.req.IsBuy {
+		levels = book.Levels[1]
+	}
+	for _, level := range levels {
+		if o.remaining < sizeEpsilon || !crosses(o.req.IsBuy, o.req.LimitPx, level.Px) {
+			return
+		}
+		size := min(o.remaining, x.availableLocked(o.req.Coin, level))
+		if size < sizeEpsilon {
+			continue
+		}
+		if !x.fillLocked(ev, o, level.Px, size, true) {
+			return
+		}
+		x.consumed[o.req.Coin][level.Px] += size
+	}
+}
+
+// fillLocked fills size of an order at px, capping reduce-only orders to the
+// position. It reports whether anything was filled.
+func (x *Exchange) fillLocked(ev *events, o *order, px, size float64, crossed bool) bool {
+	coin := o.req.Coin
+	if o.req.ReduceOnly {
+		size = min(size, x.reducibleLocked(coin, o.req.IsBuy))
+		if size < sizeEpsilon {
+			if _, ok := x.orders[o.oid]; ok {
+				x.removeLocked(ev, o, statusCanceled)
+				x.cancelChildrenLocked(ev, o)
+			}
+			return false
+		}
+	}
+
+	pos := x.positions[coin]
+	if pos == nil {
+		pos = new(position)
+		x.positions[coin] = pos
+	}
+	signed := size
+	if !o.req.IsBuy {
+		signed = -size
+	}
+	start := pos.szi
+	next := start + signed
+	if math.Abs(next) < sizeEpsilon {
+		next = 0
+	}
+
+	var closedPnl float64
+	if start != 0 && (start > 0) != o.req.IsBuy {
+		closing := min(math.Abs(start), size)
+		closedPnl = closing * (px - pos.entry)
+		if start < 0 {
+			closedPnl = -closedPnl
+		}
+	}
+	switch {
+	case next == 0:
+		pos.entry = 0
+	case start == 0 || (start > 0) != (next > 0):
+		pos.entry = px
+	case math.Abs(next) > math.Abs(start):
+		pos.entry = (pos.entry*math.Abs(start) + px*size) / math.Abs(next)
+	}
+	pos.szi = next
+
+	fee := x.fees.charge(px*size, crossed)
+	x.balance += closedPnl - fee
+
+	o.remaining -= size
+	if o.remaining < sizeEpsilon {
+		o.remaining = 0
+	}
+	o.filled += size
+	o.notional += px * size
+
+	tid := x.nextTid
+	x.nextTid++
+	ev.fills = append(ev.fills, sdk.WsFill{
+		Coin:          coin,
+		Px:            sdk.FloatToString(px),
+		Sz:            sdk.FloatToString(size),
+		Side:          side(o.req.IsBuy),
+		Time:          time.Now().UnixMilli(),
+		StartPosition: sdk.FloatToString(start),
+		Dir:           direction(start, next, o.req.IsBuy),
+		ClosedPnl:     sdk.FloatToString(closedPnl),
+		Hash:          common.Hash{}.Hex(),
+		Oid:           int64(o.oid),
+		Crossed:       crossed,
+		Fee:           sdk.FloatToString(fee),
+		Tid:           tid,
+		FeeToken:      "USDC",
+		Cloid:         o.req.Cloid,
+	})
+
+	if o.remaining == 0 {
+		if _, ok := x.orders[o.oid]; ok {
+			x.removeLocked(ev, o, statusFilled)
+		} else {
+			ev.updates = append(ev.updates, x.updateLocked(o, statusFilled))
+		}
+	}
+	// the tp/sl of an entry become active on its first fill
+	for _, child := range x.orders {
+		if child.waitFor == o.oid {
+			child.waitFor = 0
+		}
+	}
+	return true
+}
+
+func (x *Exchange) restLocked(ev *events, o *order) {
+	x.orders[o.oid] = o
+	if o.req.Cloid != nil {
+		x.cloids[*o.req.Cloid] = o.oid
+	}
+	ev.updates = append(ev.updates, x.updateLocked(o, statusOpen))
+}
+
+func (x *Exchange) removeLocked(ev *events, o *order, status string) {
+	delete(x.orders, o.oid)
+	if o.req.Cloid != nil && x.cloids[*o.req.Cloid] == o.oid {
+		delete(x.cloids, *o.req.Cloid)
+	}
+	ev.updates = append(ev.updates, x.updateLocked(o, status))
+}
+
+func (x *Exchange) cancelLocked(ev *events, o *order, coin string, asset int) any {
+	if o == nil || o.req.Coin != coin {
+		return fmt.Errorf("Order was never placed, already canceled, or filled. asset=%d", asset)
+	}
+	x.removeLocked(ev, o, statusCanceled)
+	x.cancelChildrenLocked(ev, o)
+	return "success"
+}
+
+// childrenLocked returns the tp/sl orders waiting for the entry o to fill.
+func (x *Exchange) childrenLocked(o *order) []*order {
+	var children []*order
+	for _, child := range x.sortedOrdersLocked(o.req.Coin) {
+		if child.waitFor == o.oid {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// cancelChildrenLocked cancels the tp/sl of an entry removed unfilled.
+func (x *Exchange) cancelChildrenLocked(ev *events, o *order) {
+	for _, child := range x.childrenLocked(o) {
+		x.removeLocked(ev, child, statusCanceled)
+	}
+}
+
+// activeLocked reports whether the entry o is resting or has filled.
+func (x *Exchange) activeLocked(o *order) bool {
+	_, resting := x.orders[o.oid]
+	return resting || o.filled > 0
+}
+
+func (x *Exchange) updateLocked(o *order, status string) sdk.WsOrder {
+	return sdk.WsOrder{
+		Order: sdk.WsBasicOrder{
+			Coin:      o.req.Coin,
+			Side:      side(o.req.IsBuy),
+			LimitPx:   sdk.FloatToString(o.req.LimitPx),
+			Sz:        sdk.FloatToString(o.remaining),
+			Oid:       int64(o.oid),
+			Timestamp: o.timestamp,
+			OrigSz:    sdk.FloatToString(o.req.Size),
+			Cloid:     o.req.Cloid,
+		},
+		Status:          status,
+		StatusTimestamp: time.Now().UnixMilli(),
+	}
+}
+
+// onBookLocked replaces the book of a coin, fills the resting orders it
+// crosses and fires the trigger orders its mid reaches.
+func (x *Exchange) onBookLocked(ev *events, book *sdk.L2Book) {
+	x.books[book.Coin] = book
+	x.consumed[book.Coin] = make(map[float64]float64)
+	if len(book.Levels) < 2 {
+		return
+	}
+
+	for _, o := range x.sortedOrdersLocked(book.Coin) {
+		if !o.resting() {
+			continue
+		}
+		levels := book.Levels[0]
+		if o.req.IsBuy {
+			levels = book.Levels[1]
+		}
+		for _, level := range levels {
+			if o.remaining < sizeEpsilon || !crosses(o.req.IsBuy, o.req.LimitPx, level.Px) {
+				break
+			}
+			size := min(o.remaining, x.availableLocked(book.Coin, level))
+			if size < sizeEpsilon {
+				continue
+			}
+			// the market moved through the order, which filled at its price
+			if !x.fillLocked(ev, o, o.req.LimitPx, size, false) {
+				break
+			}
+			x.consumed[book.Coin][level.Px] += size
+		}
+	}
+
+	if mid, err := x.midLocked(book.Coin); err == nil {
+		x.triggerLocked(ev, book.Coin, mid)
+	}
+}
+
+// onTradeLocked fills the resting orders a trade printed through and fires
+// the trigger orders it reaches.
+func (x *Exchange) onTradeLocked(ev *events, trade sdk.Trade) {
+	px, err1 := strconv.ParseFloat(trade.Px, 64)
+	left, err2 := strconv.ParseFloat(trade.Sz, 64)
+	if err1 != nil || err2 != nil {
+		return
+	}
+	for _, o := range x.sortedOrdersLocked(trade.Coin) {
+		if left < sizeEpsilon {
+			break
+		}
+		if !o.resting() {
+			continue
+		}
+		// a trade at the order's price may have been ahead of it in the queue
+		if (o.req.IsBuy && px >= o.req.LimitPx) || (!o.req.IsBuy && px <= o.req.LimitPx) {
+			continue
+		}
+		size := min(o.remaining, left)
+		if x.fillLocked(ev, o, o.req.LimitPx, size, false) {
+			left -= size
+		}
+	}
+	x.triggerLocked(ev, trade.Coin, px)
+}
+
+// triggerLocked fires the trigger orders of a coin reached by px. A take
+// profit fires when the price moves in favour of the position it closes, a
+// stop loss when it moves against it.
+func (x *Exchange) triggerLocked(ev *events, coin string, px float64) {
+	if _, ok := x.books[coin]; !ok {
+		return
+	}
+	for _, o := range x.sortedOrdersLocked(coin) {
+		trigger := o.req.OrderType.Trigger
+		if trigger == nil || o.triggered || o.waitFor != 0 {
+			continue
+		}
+		triggerPx, _ := strconv.ParseFloat(trigger.TriggerPx, 64)
+		above := (trigger.Tpsl == sdk.TakeProfit) != o.req.IsBuy
+		if (above && px < triggerPx) || (!above && px > triggerPx) {
+			continue
+		}
+
+		o.triggered = true
+		ev.updates = append(ev.updates, x.updateLocked(o, statusTriggered))
+		tif := sdk.TifGtc
+		if trigger.IsMarket {
+			tif = sdk.TifIoc
+			limit := triggerPx * (1 - TriggerSlippage)
+			if o.req.IsBuy {
+				limit = triggerPx * (1 + TriggerSlippage)
+			}
+			o.req.LimitPx, _ = x.RoundPrice(coin, limit)
+		}
+		if o.req.ReduceOnly && x.reducibleLocked(coin, o.req.IsBuy) == 0 {
+			x.removeLocked(ev, o, statusCanceled)
+			continue
+		}
+		x.executeLocked(ev, o, tif)
+	}
+}
+
+// reducibleLocked returns how much an order on the given side can reduce the
+// position.
+func (x *Exchange) reducibleLocked(coin string, isBuy bool) float64 {
+	pos := x.positions[coin]
+	if pos == nil || pos.szi == 0 || (pos.szi > 0) == isBuy {
+		return 0
+	}
+	return math.Abs(pos.szi)
+}
+
+func (x *Exchange) availableLocked(coin string, level sdk.Level) float64 {
+	return level.Sz - x.consumed[coin][level.Px]
+}
+
+func (x *Exchange) bestLocked(coin string, bid bool) (float64, bool) {
+	book := x.books[coin]
+	if book == nil || len(book.Levels) < 2 {
+		return 0, false
+	}
+	levels := book.Levels[1]
+	if bid {
+		levels = book.Levels[0]
+	}
+	if len(levels) == 0 {
+		return 0, false
+	}
+	return levels[0].Px, true
+}
+
+func (x *Exchange) midLocked(coin string) (float64, error) {
+	bid, ok1 := x.bestLocked(coin, true)
+	ask, ok2 := x.bestLocked(coin, false)
+	if !ok1 || !ok2 {
+		return 0, fmt.Errorf("no book for %s yet, call Watch first", coin)
+	}
+	return (bid + ask) / 2, nil
+}
+
+// sortedOrdersLocked returns the open orders of coin, or of all coins if it
+// is empty, in the order they were placed.
+func (x *Exchange) sortedOrdersLocked(coin string) []*order {
+	orders := make([]*order, 0, len(x.orders))
+	for _, o := range x.orders {
+		if coin == "" || o.req.Coin == coin {
+			orders = append(orders, o)
+		}
+	}
+	slices.SortFunc(orders, func(a, b *order) int {
+		return int(a.oid) - int(b.oid)
+	})
+	return orders
+}
+
+func filledStatus(o *order) *sdk.ExchangeFilledOrder {
+	return &sdk.ExchangeFilledOrder{
+		Oid:       o.oid,
+		TotalSize: sdk.FloatToString(o.filled),
+		AveragePx: sdk.FloatToString(o.notional / o.filled),
+	}
+}
+
+// crosses reports whether an order on the given side at limit would trade
+// against px.
+func crosses(isBuy bool, limit, px float64) bool {
+	if isBuy {
+		return px <= limit
+	}
+	return px >= limit
+}
+
+func side(isBuy bool) string {
+	if isBuy {
+		return sdk.SideBid
+	}
+	return sdk.SideAsk
+}
+
+func direction(start, next float64, isBuy bool) string {
+	switch {
+	case isBuy && start < 0 && next > 0:
+		return "Short > Long"
+	case isBuy && start < 0:
+		return "Close Short"
+	case isBuy:
+		return "Open Long"
+	case start > 0 && next < 0:
+		return "Long > Short"
+	case start > 0:
+		return "Close Long"
+	default:
+		return "Open Short"
+	}
+}
+
+// orderTypeName names a trigger order like the frontend does.
+func orderTypeName(trigger *sdk.TriggerOrderType) string {
+	name := "Stop"
+	if trigger.Tpsl == sdk.TakeProfit {
+		name = "Take Profit"
+	}
+	if trigger.IsMarket {
+		return name + " Market"
+	}
+	return name + " Limit"
+}
@@ -0,0 +1,238 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// PriceSource provides the reference price that self-pricing market orders
+// apply their slippage to.
+type PriceSource interface {
+	ReferencePrice(coin string, isBuy bool) (float64, error)
+}
+
+// WithPriceSource sets where market orders without a MarketPrice get their
+// reference price from. Defaults to MidPriceSource.
+func WithPriceSource(source PriceSource) ExchangeOption {
+	return func(e *Exchange) {
+		e.priceSource = source
+	}
+}
+
+// MidPriceSource prices from the mid returned by Info.AllMids.
+type MidPriceSource struct {
+	info MarketQuerier
+}
+
+func NewMidPriceSource(info MarketQuerier) *MidPriceSource {
+	return &MidPriceSource{info: info}
+}
+
+func (s *MidPriceSource) ReferencePrice(coin string, _ bool) (float64, error) {
+	mids, err := s.info.AllMids()
+	if err != nil {
+		return 0, err
+	}
+	return parseMid(mids, coin)
+}
+
+// Snapshot fetches all mids once, for pricing a batch of orders.
+func (s *MidPriceSource) Snapshot() (PriceSource, error) {
+	mids, err := s.info.AllMids()
+	if err != nil {
+		return nil, err
+	}
+	return midSnapshot(mids), nil
+}
+
+// PriceSnapshotter is implemented by price sources that can price every coin
+// from a single request. Batches of market orders are priced from one
+// snapshot rather than a request per coin.
+type PriceSnapshotter interface {
+	Snapshot() (PriceSource, error)
+}
+
+// midSnapshot prices from a fixed set of mids.
+type midSnapshot map[string]string
+
+func (m midSnapshot) ReferencePrice(coin string, _ bool) (float64, error) {
+	return parseMid(m, coin)
+}
+
+// BookPriceSource prices buys from the best ask and sells from the best bid
+// of Info.L2Snapshot.
+type BookPriceSource struct {
+	info MarketQuerier
+}
+
+func NewBookPriceSource(info MarketQuerier) *BookPriceSource {
+	return &BookPriceSource{info: info}
+}
+
+func (s *BookPriceSource) ReferencePrice(coin string, isBuy bool) (float64, error) {
+	book, err := s.info.L2Snapshot(coin)
+	if err != nil {
+		return 0, err
+	}
+	return topOfBook(book, isBuy)
+}
+
+// MidCache keeps the latest mids from the allMids websocket channel.
+type MidCache struct {
+	ws     *WebsocketClient
+	maxAge time.Duration
+
+	mu      sync.RWMutex
+	mids    map[string]float64
+	updated time.Time
+	subID   int
+}
+
+// NewMidCache creates a cache fed by ws. Prices older than maxAge are treated
+// as missing; zero disables the check.
+func NewMidCache(ws *WebsocketClient, maxAge time.Duration) *MidCache {
+	return &MidCache{
+		ws:     ws,
+		maxAge: maxAge,
+		mids:   make(map[string]float64),
+	}
+}
+
+func (c *MidCache) Start() error {
+	id, err := c.ws.SubscribeToAllMids(c.onMessage)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.subID = id
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *MidCache) Stop() error {
+	c.mu.Lock()
+	id := c.subID
+	c.subID = 0
+	c.mu.Unlock()
+	if id == 0 {
+		return nil
+	}
+	return c.ws.Unsubscribe(Subscription{Type: SubTypeAllMids}, id)
+}
+
+// Mid returns the cached mid of coin.
+func (c *MidCache) Mid(coin string) (float64, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.maxAge > 0 && time.Since(c.updated) > c.maxAge {
+		return 0, false
+	}
+	mid, ok := c.mids[coin]
+	return mid, ok
+}
+
+func (c *MidCache) ReferencePrice(coin string, _ bool) (float64, error) {
+	mid, ok := c.Mid(coin)
+	if !ok {
+		return 0, fmt.Errorf("no fresh mid price for %s", coin)
+	}
+	return mid, nil
+}
+
+func (c *MidCache) onMessage(msg WSMessage) {
+	var data WsAllMids
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for coin, px := range data.Mids {
+		if mid, err := strconv.ParseFloat(px, 64); err == nil {
+			c.mids[coin] = mid
+		}
+	}
+	c.updated = time.Now()
+}
+
+// MarketClose closes the whole position in coin with a reduce-only IOC order
+// sized from UserState and priced from the exchange's PriceSource.
+func (e *Exchange) MarketClose(coin string, slippage float64, builder *BuilderInfo) (any, error) {
+	state, err := e.info.UserState(e.AccountAddress().Hex())
+	if err != nil {
+		return nil, err
+	}
+	var szi float64
+	for _, pos := range openPositions(state) {
+		if pos.Coin == coin {
+			szi, _ = strconv.ParseFloat(pos.Szi, 64)
+			break
+		}
+	}
+	if szi == 0 {
+		return nil, fmt.Errorf("no open position in %s", coin)
+	}
+
+	isBuy := szi < 0
+	return e.MarketOrder(MarketRequest{
+		Coin:       coin,
+		IsBuy:      isBuy,
+		ReduceOnly: true,
+		Size:       math.Abs(szi),
+		Slippage:   slippage,
+	}, builder)
+}
+
+// batchPriceSource returns the source to price n market orders from: a
+// snapshot of the exchange's PriceSource if it can take one and n > 1.
+func (e *Exchange) batchPriceSource(n int) (PriceSource, error) {
+	snapshotter, ok := e.priceSource.(PriceSnapshotter)
+	if !ok || n < 2 {
+		return e.priceSource, nil
+	}
+	source, err := snapshotter.Snapshot()
+	if err != nil {
+		return nil, fmt.Errorf("reference prices: %w", err)
+	}
+	return source, nil
+}
+
+// marketPrice returns the slippage-bounded limit price of a market order,
+// fetching the reference price from source when the caller did not supply
+// one.
+func (e *Exchange) marketPrice(source PriceSource, coin string, isBuy bool, slippage, refPx float64) (float64, error) {
+	if refPx <= 0 {
+		var err error
+		refPx, err = source.ReferencePrice(coin, isBuy)
+		if err != nil {
+			return 0, fmt.Errorf("reference price for %s: %w", coin, err)
+		}
+	}
+	return e.slippagePrice(coin, isBuy, slippage, refPx)
+}
+
+func parseMid(mids map[string]string, coin string) (float64, error) {
+	px, ok := mids[coin]
+	if !ok {
+		return 0, fmt.Errorf("no mid price for %s", coin)
+	}
+	mid, err := strconv.ParseFloat(px, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mid price for %s: %w", coin, err)
+	}
+	return mid, nil
+}
+
+func topOfBook(book *L2Book, isBuy bool) (float64, error) {
+	side := 0 // bids
+	if isBuy {
+		side = 1 // asks
+	}
+	if len(book.Levels) <= side || len(book.Levels[side]) == 0 {
+		return 0, fmt.Errorf("empty order book side for %s", book.Coin)
+	}
+	return book.Levels[side][0].Px, nil
+}
@@ -0,0 +1,119 @@
+package sdk_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+func TestMarketOrdersPriceBatchOnce(t *testing.T) {
+	srv, signer := newServer(t, hltest.Config{})
+	srv.SetBook("BTC", []sdk.Level{{Px: 99_990, Sz: 1}}, []sdk.Level{{Px: 100_010, Sz: 1}})
+	srv.SetBook("ETH", []sdk.Level{{Px: 2_999, Sz: 10}}, []sdk.Level{{Px: 3_001, Sz: 10}})
+	srv.SetBook("SOL", []sdk.Level{{Px: 149.9, Sz: 100}}, []sdk.Level{{Px: 150.1, Sz: 100}})
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+
+	allMids := func() int {
+		n := 0
+		for _, req := range srv.Requests() {
+			if req.Type == "allMids" {
+				n++
+			}
+		}
+		return n
+	}
+
+	statuses, err := exchange.BulkMarketOrders([]sdk.MarketRequest{
+		{Coin: "BTC", IsBuy: true, Size: 0.01, Slippage: 0.01},
+		{Coin: "ETH", IsBuy: true, Size: 0.1, Slippage: 0.01},
+		{Coin: "SOL", IsBuy: false, Size: 1, Slippage: 0.01},
+	}, nil)
+	if err != nil {
+		t.Fatalf("BulkMarketOrders failed: %v", err)
+	}
+	for i, status := range statuses {
+		if err, ok := status.(error); ok {
+			t.Errorf("Order %d failed: %v", i, err)
+		}
+	}
+	if n := allMids(); n != 1 {
+		t.Errorf("Expected the batch to fetch mids once, got %d requests", n)
+	}
+
+	result, err := exchange.CloseAllPositions(0.01)
+	if err != nil {
+		t.Fatalf("CloseAllPositions failed: %v", err)
+	}
+	if len(result.Closed) != 3 || len(result.Failed) != 0 || len(result.Remaining) != 0 {
+		t.Errorf("Expected 3 positions closed, got %+v", result)
+	}
+	if n := allMids(); n != 2 {
+		t.Errorf("Expected flattening to fetch mids once, got %d requests", n-1)
+	}
+}
+
+func TestAdjustPrice(t *testing.T) {
+	tests := []struct {
+		name       string
+		price      float64
+		asset      int
+		szDecimals int
+		want       float64
+	}{
+		{"perp five significant figures", 3030.2345, 1, 4, 3030.2},
+		{"perp large price", 100_123.456, 0, 5, 100_120},
+		{"perp decimals bind", 12.345678, 0, 5, 12.3},
+		{"perp six decimals", 0.00123456, 2, 0, 0.001235},
+		{"spot eight decimals", 0.00123456, 10_001, 0, 0.0012346},
+		{"spot five significant figures", 1.23456789, 10_002, 2, 1.2346},
+		{"spot decimals bind", 0.000123456, 10_003, 2, 0.000123},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sdk.AdjustPrice(tt.price, tt.asset, tt.szDecimals); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("AdjustPrice(%v, %d, %d) = %v, want %v", tt.price, tt.asset, tt.szDecimals, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarketOrdersRoundPrices(t *testing.T) {
+	srv, signer := newServer(t, hltest.Config{})
+	srv.SetBook("SOL", []sdk.Level{{Px: 1.2, Sz: 100}}, []sdk.Level{{Px: 1.3, Sz: 100}})
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer)
+
+	if _, err := exchange.BulkMarketOrders([]sdk.MarketRequest{
+		{Coin: "BTC", IsBuy: true, Size: 0.01, MarketPrice: 100_000, Slippage: 0.0123},
+		{Coin: "ETH", IsBuy: false, Size: 0.1, MarketPrice: 3000.123, Slippage: 0.01},
+		{Coin: "SOL", IsBuy: true, Size: 1, MarketPrice: 1.234567, Slippage: 0.05},
+		{Coin: "BTC", IsBuy: true, Size: 0.01, MarketPrice: 12.345678},
+		{Coin: "SOL", IsBuy: true, Size: 1, Slippage: 0.01}, // priced from the 1.25 mid
+	}, nil); err != nil {
+		t.Fatalf("BulkMarketOrders failed: %v", err)
+	}
+
+	var action struct {
+		Action struct {
+			Orders []sdk.OrderWire `json:"orders"`
+		} `json:"action"`
+	}
+	for _, req := range srv.Requests() {
+		if req.Type == "order" {
+			if err := json.Unmarshal(req.Body, &action); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	want := []string{"101230", "2970.1", "1.2963", "12.3", "1.2625"}
+	if len(action.Action.Orders) != len(want) {
+		t.Fatalf("Expected %d orders, got %+v", len(want), action.Action.Orders)
+	}
+	for i, order := range action.Action.Orders {
+		if order.LimitPx != want[i] {
+			t.Errorf("Order %d: expected price %s, got %s", i, want[i], order.LimitPx)
+		}
+	}
+}
@@ -0,0 +1,501 @@
+package quoter
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+// Level is a desired quote.
+type Level struct {
+	Px float64
+	Sz float64
+}
+
+// Quotes is the desired ladder of a coin. Levels are matched to resting
+// orders by price, so their order does not matter.
+type Quotes struct {
+	Bids []Level
+	Asks []Level
+}
+
+type Config struct {
+	// User is the account whose orderUpdates are followed.
+	User string
+	// Tif of the quotes, defaults to sdk.TifAlo.
+	Tif string
+	// Tag is embedded in the cloid of every quote, see sdk.CloidGenerator.
+	Tag uint16
+	// Instance of the cloid generator, random if zero.
+	Instance uint16
+	// MinInterval is the minimum time between two actions sent to the
+	// exchange, shared by all coins.
+	MinInterval time.Duration
+	// OnError is called when an action fails as a whole.
+	OnError func(coin string, err error)
+}
+
+// Order is a quote tracked by the manager.
+type Order struct {
+	Cloid sdk.Cloid
+	Oid   uint64 // zero while the order is being placed
+	IsBuy bool
+	Px    float64
+	Sz    float64 // remaining size
+	// InFlight is set while a place or modify of the order awaits its result.
+	InFlight bool
+}
+
+type coinState struct {
+	desired Quotes
+	orders  map[sdk.Cloid]*Order
+	dirty   bool
+	syncing bool
+}
+
+// Manager keeps the resting quotes of each coin converged to a desired
+// ladder. Every change is diffed against the tracked orders and applied with
+// the fewest BulkCancel, BulkModifyOrders and BulkOrders calls. Orders are
+// tracked by cloid and kept current from the orderUpdates stream.
+type Manager struct {
+	exchange sdk.Trader
+	ws       *sdk.WebsocketClient
+	cfg      Config
+	cloids   *sdk.CloidGenerator
+
+	mu    sync.Mutex
+	coins map[string]*coinState
+	subID int
+	wg    sync.WaitGroup
+
+	limitMu    sync.Mutex
+	nextAction time.Time
+}
+
+func NewManager(exchange sdk.Trader, ws *sdk.WebsocketClient, cfg Config) *Manager {
+	if cfg.Tif == "" {
+		cfg.Tif = sdk.TifAlo
+	}
+	if cfg.User == "" {
+		cfg.User = exchange.AccountAddress().Hex()
+	}
+	instance := cfg.Instance
+	if instance == 0 {
+		instance = sdk.RandomInstanceTag()
+	}
+	return &Manager{
+		exchange: exchange,
+		ws:       ws,
+		cfg:      cfg,
+		cloids:   sdk.NewCloidGenerator(instance),
+		coins:    make(map[string]*coinState),
+	}
+}
+
+// Start subscribes to the order updates of the configured user.
+func (m *Manager) Start() error {
+	id, err := m.ws.SubscribeToOrderUpdates(m.cfg.User, m.onOrderUpdates)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.subID = id
+	m.mu.Unlock()
+	return nil
+}
+
+// Stop unsubscribes from order updates. Resting quotes are left in place;
+// set empty Quotes first to pull them.
+func (m *Manager) Stop() error {
+	m.mu.Lock()
+	id := m.subID
+	m.subID = 0
+	m.mu.Unlock()
+	if id == 0 {
+		return nil
+	}
+	return m.ws.Unsubscribe(sdk.Subscription{Type: sdk.SubTypeOrderUpdates, User: m.cfg.User}, id)
+}
+
+// SetQuotes replaces the desired ladder of coin and starts converging to it
+// in the background. Calls made while a previous change is still being
+// applied are merged; only the latest ladder is applied afterwards.
+func (m *Manager) SetQuotes(coin string, quotes Quotes) error {
+	normalized, err := m.normalize(coin, quotes)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stateLocked(coin).desired = normalized
+	m.triggerLocked(coin)
+	return nil
+}
+
+// Wait blocks until no coin has changes being applied.
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// Orders returns the quotes currently tracked for coin.
+func (m *Manager) Orders(coin string) []Order {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok := m.coins[coin]
+	if !ok {
+		return nil
+	}
+	orders := make([]Order, 0, len(st.orders))
+	for _, order := range st.orders {
+		orders = append(orders, *order)
+	}
+	return orders
+}
+
+func (m *Manager) normalize(coin string, quotes Quotes) (Quotes, error) {
+	var out Quotes
+	var err error
+	if out.Bids, err = m.normalizeLevels(coin, quotes.Bids); err != nil {
+		return out, err
+	}
+	if out.Asks, err = m.normalizeLevels(coin, quotes.Asks); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+func (m *Manager) normalizeLevels(coin string, levels []Level) ([]Level, error) {
+	out := make([]Level, 0, len(levels))
+	for _, level := range levels {
+		px, err := m.exchange.RoundPrice(coin, level.Px)
+		if err != nil {
+			return nil, err
+		}
+		sz, err := m.exchange.RoundSize(coin, level.Sz)
+		if err != nil {
+			return nil, err
+		}
+		if px <= 0 || sz <= 0 {
+			continue
+		}
+		out = append(out, Level{Px: px, Sz: sz})
+	}
+	return out, nil
+}
+
+func (m *Manager) stateLocked(coin string) *coinState {
+	st, ok := m.coins[coin]
+	if !ok {
+		st = &coinState{orders: make(map[sdk.Cloid]*Order)}
+		m.coins[coin] = st
+	}
+	return st
+}
+
+func (m *Manager) triggerLocked(coin string) {
+	st := m.stateLocked(coin)
+	st.dirty = true
+	if st.syncing {
+		return
+	}
+	st.syncing = true
+	m.wg.Add(1)
+	go m.sync(coin)
+}
+
+func (m *Manager) sync(coin string) {
+	defer m.wg.Done()
+	for {
+		m.mu.Lock()
+		st := m.coins[coin]
+		if !st.dirty {
+			st.syncing = false
+			m.mu.Unlock()
+			return
+		}
+		st.dirty = false
+		p := m.planLocked(st)
+		m.mu.Unlock()
+
+		m.execute(coin, p)
+	}
+}
+
+type modification struct {
+	order *Order
+	level Level
+}
+
+type plan struct {
+	cancels  []*Order
+	modifies []modification
+	places   []*Order
+}
+
+// planLocked diffs the desired ladder against the tracked orders and marks
+// the orders that are about to change as in flight.
+func (m *Manager) planLocked(st *coinState) plan {
+	var bids, asks []*Order
+	for _, order := range st.orders {
+		if order.IsBuy {
+			bids = append(bids, order)
+		} else {
+			asks = append(asks, order)
+		}
+	}
+
+	var p plan
+	m.planSide(&p, st, true, bids, st.desired.Bids)
+	m.planSide(&p, st, false, asks, st.desired.Asks)
+	return p
+}
+
+func (m *Manager) planSide(p *plan, st *coinState, isBuy bool, live []*Order, desired []Level) {
+	// keep orders that already match a desired level exactly
+	used := make([]bool, len(live))
+	var unmatched []Level
+	for _, level := range desired {
+		found := false
+		for i, order := range live {
+			if !used[i] && order.Px == level.Px && order.Sz == level.Sz {
+				used[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, level)
+		}
+	}
+	var spare []*Order
+	for i, order := range live {
+		if !used[i] {
+			spare = append(spare, order)
+		}
+	}
+
+	// pair the rest best price first, so modifies move orders the least
+	better := func(a, b float64) int {
+		if isBuy {
+			return cmp.Compare(b, a)
+		}
+		return cmp.Compare(a, b)
+	}
+	slices.SortFunc(unmatched, func(a, b Level) int { return better(a.Px, b.Px) })
+	slices.SortFunc(spare, func(a, b *Order) int { return better(a.Px, b.Px) })
+
+	n := min(len(unmatched), len(spare))
+	for i := 0; i < n; i++ {
+		spare[i].InFlight = true
+		p.modifies = append(p.modifies, modification{order: spare[i], level: unmatched[i]})
+	}
+	for _, order := range spare[n:] {
+		order.InFlight = true
+		p.cancels = append(p.cancels, order)
+	}
+	for _, level := range unmatched[n:] {
+		order := &Order{
+			Cloid:    m.cloids.NextWithTag(m.cfg.Tag),
+			IsBuy:    isBuy,
+			Px:       level.Px,
+			Sz:       level.Sz,
+			InFlight: true,
+		}
+		st.orders[order.Cloid] = order
+		p.places = append(p.places, order)
+	}
+}
+
+func (m *Manager) execute(coin string, p plan) {
+	for batch := range slices.Chunk(p.cancels, sdk.MaxBatchSize) {
+		reqs := make([]sdk.CancelRequest, len(batch))
+		for i, order := range batch {
+			reqs[i] = sdk.CancelRequest{Coin: coin, Oid: order.Oid}
+		}
+		m.wait()
+		statuses, err := m.exchange.BulkCancel(reqs)
+		m.reportErrors(coin, m.applyCancels(coin, batch, statuses, err))
+	}
+
+	for batch := range slices.Chunk(p.modifies, sdk.MaxBatchSize) {
+		reqs := make([]sdk.ModifyRequest, len(batch))
+		for i, mod := range batch {
+			reqs[i] = sdk.NewModifyByOid(mod.order.Oid, m.orderRequest(coin, mod.order.IsBuy, mod.level, mod.order.Cloid))
+		}
+		m.wait()
+		statuses, err := m.exchange.BulkModifyOrders(reqs)
+		m.reportErrors(coin, m.applyModifies(coin, batch, statuses, err))
+	}
+
+	for batch := range slices.Chunk(p.places, sdk.MaxBatchSize) {
+		reqs := make([]sdk.OrderRequest, len(batch))
+		for i, order := range batch {
+			reqs[i] = m.orderRequest(coin, order.IsBuy, Level{Px: order.Px, Sz: order.Sz}, order.Cloid)
+		}
+		m.wait()
+		statuses, err := m.exchange.BulkOrders(reqs, nil)
+		m.reportErrors(coin, m.applyPlaces(coin, batch, statuses, err))
+	}
+}
+
+func (m *Manager) orderRequest(coin string, isBuy bool, level Level, cloid sdk.Cloid) sdk.OrderRequest {
+	return sdk.OrderRequest{
+		Coin:    coin,
+		IsBuy:   isBuy,
+		Size:    level.Sz,
+		LimitPx: level.Px,
+		OrderType: sdk.OrderType{
+			Limit: &sdk.LimitOrderType{Tif: m.cfg.Tif},
+		},
+		Cloid: &cloid,
+	}
+}
+
+func (m *Manager) applyCancels(coin string, batch []*Order, statuses []any, err error) []error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := m.coins[coin]
+	var errs []error
+	for i, order := range batch {
+		order.InFlight = false
+		if err != nil {
+			continue
+		}
+		if status, ok := sdk.StatusAt(statuses, i).(error); ok && !sdk.IsOrderGone(status) {
+			// still resting, the next update cancels it again
+			errs = append(errs, fmt.Errorf("cancel quote %v@%v: %w", order.Sz, order.Px, status))
+			continue
+		}
+		delete(st.orders, order.Cloid)
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("cancel quotes: %w", err))
+	}
+	return errs
+}
+
+func (m *Manager) applyModifies(coin string, batch []modification, statuses []any, err error) []error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := m.coins[coin]
+	for i, mod := range batch {
+		order := mod.order
+		order.InFlight = false
+		if err != nil {
+			continue
+		}
+		if _, tracked := st.orders[order.Cloid]; !tracked {
+			continue
+		}
+		switch status := sdk.StatusAt(statuses, i).(type) {
+		case *sdk.ExchangeRestingOrder:
+			order.Oid = status.Oid
+			order.Px, order.Sz = mod.level.Px, mod.level.Sz
+		default:
+			// filled, rejected or already gone
+			delete(st.orders, order.Cloid)
+		}
+	}
+	if err != nil {
+		return []error{fmt.Errorf("modify quotes: %w", err)}
+	}
+	return nil
+}
+
+func (m *Manager) applyPlaces(coin string, batch []*Order, statuses []any, err error) []error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := m.coins[coin]
+	var errs []error
+	for i, order := range batch {
+		order.InFlight = false
+		if _, tracked := st.orders[order.Cloid]; !tracked {
+			continue
+		}
+		if err != nil {
+			delete(st.orders, order.Cloid)
+			continue
+		}
+		switch status := sdk.StatusAt(statuses, i).(type) {
+		case *sdk.ExchangeRestingOrder:
+			order.Oid = status.Oid
+		case error:
+			delete(st.orders, order.Cloid)
+			errs = append(errs, fmt.Errorf("place quote %v@%v: %w", order.Sz, order.Px, status))
+		default:
+			delete(st.orders, order.Cloid)
+		}
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("place quotes: %w", err))
+	}
+	return errs
+}
+
+func (m *Manager) onOrderUpdates(msg sdk.WSMessage) {
+	var updates []sdk.WsOrder
+	if err := json.Unmarshal(msg.Data, &updates); err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, update := range updates {
+		if update.Order.Cloid == nil {
+			continue
+		}
+		st, ok := m.coins[update.Order.Coin]
+		if !ok {
+			continue
+		}
+		order, ok := st.orders[*update.Order.Cloid]
+		if !ok {
+			continue
+		}
+		switch update.Status {
+		case "open", "triggered":
+			order.Oid = uint64(update.Order.Oid)
+			if sz, err := strconv.ParseFloat(update.Order.Sz, 64); err == nil {
+				order.Sz = sz
+			}
+		default:
+			// a modify replaces the order; its result settles the state
+			if order.InFlight {
+				continue
+			}
+			delete(st.orders, order.Cloid)
+			m.triggerLocked(update.Order.Coin)
+		}
+	}
+}
+
+// wait spaces out actions by MinInterval.
+func (m *Manager) wait() {
+	if m.cfg.MinInterval <= 0 {
+		return
+	}
+	m.limitMu.Lock()
+	now := time.Now()
+	at := m.nextAction
+	if at.Before(now) {
+		at = now
+	}
+	m.nextAction = at.Add(m.cfg.MinInterval)
+	m.limitMu.Unlock()
+	time.Sleep(time.Until(at))
+}
+
+func (m *Manager) reportErrors(coin string, errs []error) {
+	if m.cfg.OnError == nil {
+		return
+	}
+	for _, err := range errs {
+		m.cfg.OnError(coin, err)
+	}
+}
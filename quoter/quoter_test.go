@@ -0,0 +1,31 @@
+package quoter
+
+import (
+	"errors"
+	"testing"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+func TestApplyCancels(t *testing.T) {
+	gen := sdk.NewCloidGenerator(1)
+	gone := &Order{Cloid: gen.Next(), Oid: 1, InFlight: true}
+	resting := &Order{Cloid: gen.Next(), Oid: 2, InFlight: true}
+	canceled := &Order{Cloid: gen.Next(), Oid: 3, InFlight: true}
+	m := &Manager{coins: map[string]*coinState{"BTC": {orders: map[sdk.Cloid]*Order{
+		gone.Cloid: gone, resting.Cloid: resting, canceled.Cloid: canceled,
+	}}}}
+
+	errs := m.applyCancels("BTC", []*Order{gone, resting, canceled}, []any{
+		errors.New("Order was never placed, already canceled, or filled. asset=0"),
+		errors.New("Too many cumulative requests sent"),
+		"success",
+	}, nil)
+	if len(errs) != 1 {
+		t.Errorf("Expected the failed cancel to be reported, got %v", errs)
+	}
+	orders := m.coins["BTC"].orders
+	if len(orders) != 1 || orders[resting.Cloid] != resting || resting.InFlight {
+		t.Errorf("Expected only the order that failed to cancel to remain, got %+v", orders)
+	}
+}
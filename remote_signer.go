@@ -0,0 +1,221 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// The remote signing protocol: GET /address returns a SignerAddress, and
+// POST /sign takes a SignRequest and returns a SignResponse. Requests carry
+// the shared secret as a bearer token; errors are plain text with an error
+// status.
+const (
+	SignerAddressPath = "/address"
+	SignerSignPath    = "/sign"
+)
+
+type SignerAddress struct {
+	Address common.Address `json:"address"`
+}
+
+// SignRequest asks for the signature of Digest. TypedData is the EIP-712
+// data it is the hash of, for the signer to review and check against the
+// digest; it is omitted when a digest is signed through Sign.
+type SignRequest struct {
+	Address   common.Address      `json:"address"`
+	Digest    hexutil.Bytes       `json:"digest"`
+	TypedData *apitypes.TypedData `json:"typedData,omitempty"`
+}
+
+type SignResponse struct {
+	Signature hexutil.Bytes `json:"signature"` // 65 bytes, V is 0 or 1
+}
+
+// RemoteSignerConfig configures a RemoteSigner.
+type RemoteSignerConfig struct {
+	// URL of the signing service: http://host:port, https://host:port or
+	// unix:///path/to/socket.
+	URL string
+	// Secret is sent as a bearer token, if set.
+	Secret string
+	// TLS is the client configuration for https, e.g. with a certificate
+	// for mutual TLS.
+	TLS *tls.Config
+	// Timeout of a request. Defaults to 5s.
+	Timeout time.Duration
+}
+
+// RemoteSigner is a Signer that forwards digests with their typed data to a
+// signing service, see the signd package, so keys stay off trading hosts.
+type RemoteSigner struct {
+	baseURL    string
+	secret     string
+	httpClient *http.Client
+	address    common.Address
+}
+
+// NewRemoteSigner connects to the signing service and fetches the address
+// it signs for.
+func NewRemoteSigner(cfg RemoteSignerConfig) (*RemoteSigner, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signer URL: %w", err)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	transport := &http.Transport{TLSClientConfig: cfg.TLS}
+	baseURL := strings.TrimSuffix(cfg.URL, "/")
+	switch u.Scheme {
+	case "http", "https":
+	case "unix":
+		socket := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+		baseURL = "http://unix"
+	default:
+		return nil, fmt.Errorf("unsupported signer URL scheme %q", u.Scheme)
+	}
+
+	s := &RemoteSigner{
+		baseURL:    baseURL,
+		secret:     cfg.Secret,
+		httpClient: &http.Client{Transport: transport, Timeout: cfg.Timeout},
+	}
+	var addr SignerAddress
+	if err := s.do(http.MethodGet, SignerAddressPath, nil, &addr); err != nil {
+		return nil, fmt.Errorf("failed to get signer address: %w", err)
+	}
+	s.address = addr.Address
+	return s, nil
+}
+
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+// Sign asks for the signature of a bare digest, which a signing service may
+// refuse to sign without its typed data.
+func (s *RemoteSigner) Sign(msg []byte) ([]byte, error) {
+	return s.sign(SignRequest{Address: s.address, Digest: msg})
+}
+
+func (s *RemoteSigner) SignTypedData(data apitypes.TypedData, digest []byte) ([]byte, error) {
+	data.Message = portableMessage(data.Message)
+	return s.sign(SignRequest{Address: s.address, Digest: digest, TypedData: &data})
+}
+
+func (s *RemoteSigner) sign(req SignRequest) ([]byte, error) {
+	var resp SignResponse
+	if err := s.do(http.MethodPost, SignerSignPath, req, &resp); err != nil {
+		return nil, fmt.Errorf("remote signer: %w", err)
+	}
+	if len(resp.Signature) != 65 {
+		return nil, fmt.Errorf("remote signer: signature length is %d, expected 65", len(resp.Signature))
+	}
+	// a signature of another digest or by another key would otherwise only
+	// show as an action rejected by the exchange
+	raw := bytes.Clone(resp.Signature)
+	if raw[64] >= 27 {
+		raw[64] -= 27
+	}
+	pub, err := crypto.SigToPub(req.Digest, raw)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer: %w", err)
+	}
+	if signer := crypto.PubkeyToAddress(*pub); signer != s.address {
+		return nil, fmt.Errorf("remote signer: signature recovers to %s, expected %s", signer.Hex(), s.address.Hex())
+	}
+	return resp.Signature, nil
+}
+
+func (s *RemoteSigner) do(method, path string, payload, result any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, s.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.secret != "" {
+		req.Header.Set("Authorization", "Bearer "+s.secret)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, result)
+}
+
+// portableMessage converts the values of a typed data message to the
+// strings apitypes parses, so that it hashes the same after a JSON round
+// trip: bytes to hex and integers to decimal.
+func portableMessage(msg apitypes.TypedDataMessage) apitypes.TypedDataMessage {
+	out := make(apitypes.TypedDataMessage, len(msg))
+	for k, v := range msg {
+		out[k] = portableValue(v)
+	}
+	return out
+}
+
+func portableValue(v any) any {
+	switch v := v.(type) {
+	case []byte:
+		return hexutil.Encode(v)
+	case *big.Int:
+		return v.String()
+	case map[string]any:
+		return portableMessage(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = portableValue(e)
+		}
+		return out
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return hexutil.Encode(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprint(v)
+	}
+	return v
+}
@@ -0,0 +1,109 @@
+package sdk_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/exchange_api"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/signd"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	srv, local := newServer(t, hltest.Config{})
+
+	// unix socket paths are short, so not in t.TempDir
+	dir, err := os.MkdirTemp("", "hlsignd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requestLog bytes.Buffer
+	daemon := &http.Server{Handler: signd.New(signd.Config{Signer: local, Secret: "s3cret", Log: &requestLog})}
+	go daemon.Serve(ln)
+	defer daemon.Close()
+
+	if _, err := sdk.NewRemoteSigner(sdk.RemoteSignerConfig{URL: "unix://" + socket, Secret: "wrong"}); err == nil {
+		t.Error("Expected a wrong secret to be refused")
+	}
+	remote, err := sdk.NewRemoteSigner(sdk.RemoteSignerConfig{URL: "unix://" + socket, Secret: "s3cret"})
+	if err != nil {
+		t.Fatalf("Failed to connect to the signer: %v", err)
+	}
+	if remote.Address() != local.Address() {
+		t.Fatalf("Expected address %s, got %s", local.Address(), remote.Address())
+	}
+	if _, err := remote.Sign(make([]byte, 32)); err == nil || !strings.Contains(err.Error(), "typed data is required") {
+		t.Errorf("Expected a bare digest to be refused, got %v", err)
+	}
+
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), remote)
+	if err := exchange.UpdateLeverage("ETH", true, 5); err != nil {
+		t.Errorf("L1 action signed remotely was rejected: %v", err)
+	}
+	if _, err := exchange_api.TansferUSD(exchange, exchange_api.TransferUSDRequest{
+		Amount: "1", Destination: "0x0000000000000000000000000000000000000001",
+		HyperliquidChain: "Testnet", SignatureChainId: "0x66eee",
+	}); err != nil {
+		t.Errorf("User-signed action signed remotely was rejected: %v", err)
+	}
+
+	var primaryTypes []string
+	for _, line := range strings.Split(strings.TrimSpace(requestLog.String()), "\n") {
+		var entry signd.LogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		if entry.PrimaryType != "" {
+			primaryTypes = append(primaryTypes, entry.PrimaryType)
+		}
+	}
+	if want := []string{"Agent", "HyperliquidTransaction:UsdSend"}; !slices.Equal(primaryTypes, want) {
+		t.Errorf("Expected logged requests %v, got %v", want, primaryTypes)
+	}
+}
+
+// impostor claims the address of one key and signs with another.
+type impostor struct {
+	*sdk.LocalSigner
+	address common.Address
+}
+
+func (s impostor) Address() common.Address {
+	return s.address
+}
+
+func TestRemoteSignerChecksSignature(t *testing.T) {
+	srv, local := newServer(t, hltest.Config{})
+	daemon := httptest.NewServer(signd.New(signd.Config{
+		Signer: impostor{LocalSigner: newSigner(t), address: local.Address()},
+	}))
+	defer daemon.Close()
+
+	remote, err := sdk.NewRemoteSigner(sdk.RemoteSignerConfig{URL: daemon.URL})
+	if err != nil {
+		t.Fatalf("Failed to connect to the signer: %v", err)
+	}
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), remote)
+	if err := exchange.UpdateLeverage("ETH", true, 5); err == nil || !strings.Contains(err.Error(), "recovers to") {
+		t.Errorf("Expected a signature by another key to be refused, got %v", err)
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("Expected nothing sent to the exchange, got %d requests", n)
+	}
+}
@@ -0,0 +1,381 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RiskChecker vets order-submitting actions before they are signed. Returning
+// an error rejects the whole action. Cancels are never checked.
+type RiskChecker interface {
+	CheckOrders(orders []OrderRequest) error
+	CheckModifies(modifies []ModifyRequest) error
+	CheckTwap(req TwapRequest) error
+}
+
+// WithRiskChecker puts checker in front of BulkOrdersWithGrouping,
+// BulkModifyOrders and TwapOrder, and so of every method built on them.
+func WithRiskChecker(checker RiskChecker) ExchangeOption {
+	return func(e *Exchange) {
+		e.risk = checker
+	}
+}
+
+// ErrKillSwitch is returned for orders blocked by an engaged kill switch.
+var ErrKillSwitch = errors.New("kill switch engaged")
+
+// RiskError reports the limit an action breached.
+type RiskError struct {
+	Rule    string
+	Coin    string
+	Message string
+}
+
+func (e RiskError) Error() string {
+	return fmt.Sprintf("risk check %s failed for %s: %s", e.Rule, e.Coin, e.Message)
+}
+
+// PositionSource provides the signed position size of a coin.
+type PositionSource interface {
+	PositionSize(coin string) (float64, error)
+}
+
+// OpenOrderSource provides the number of open orders of the account.
+type OpenOrderSource interface {
+	OpenOrderCount() (int, error)
+}
+
+// RiskLimits configures RiskManager. Zero values disable a limit.
+type RiskLimits struct {
+	// MaxOrderNotional caps size times limit price of a single order.
+	MaxOrderNotional float64
+	// MaxPosition caps the absolute position per coin after all orders of
+	// an action fill. DefaultMaxPosition applies to coins not listed.
+	MaxPosition        map[string]float64
+	DefaultMaxPosition float64
+	// MaxOpenOrders caps the open orders after an action rests.
+	MaxOpenOrders int
+	// PriceCollar is the largest allowed distance between a limit price and
+	// the mid, as a fraction of the mid. Trigger orders are not collared.
+	PriceCollar float64
+	// MaxOrdersPerMinute caps orders and modifies over a sliding minute.
+	MaxOrdersPerMinute int
+}
+
+type RiskConfig struct {
+	Limits RiskLimits
+	// Prices provides the mid for the price collar and the price TWAPs are
+	// valued at against MaxOrderNotional, e.g. a MidCache.
+	Prices PriceSource
+	// Positions and OpenOrders feed the position and open order limits,
+	// which are skipped when not set. See AccountRiskSource.
+	Positions  PositionSource
+	OpenOrders OpenOrderSource
+	// OnReject is called with every rejected action's error.
+	OnReject func(err error)
+}
+
+// RiskManager is a RiskChecker enforcing RiskLimits and a kill switch.
+// Reduce-only orders are exempt from the position limit and pass the kill
+// switch, so positions can still be flattened while it is engaged.
+type RiskManager struct {
+	cfg RiskConfig
+
+	mu         sync.Mutex
+	killed     bool
+	killReason string
+	sent       []time.Time // submissions within the last minute
+}
+
+func NewRiskManager(cfg RiskConfig) *RiskManager {
+	return &RiskManager{cfg: cfg}
+}
+
+// Kill engages the kill switch. New orders, modifies and TWAPs are rejected
+// with ErrKillSwitch until Reset.
+func (m *RiskManager) Kill(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.killed = true
+	m.killReason = reason
+}
+
+// KillAndCancel engages the kill switch and cancels every open order of
+// exchange.
+func (m *RiskManager) KillAndCancel(reason string, exchange Flattener) (*CancelAllResult, error) {
+	m.Kill(reason)
+	return exchange.CancelAll(CancelAllFilter{})
+}
+
+// Reset disengages the kill switch.
+func (m *RiskManager) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.killed = false
+	m.killReason = ""
+}
+
+// Killed reports whether the kill switch is engaged and why.
+func (m *RiskManager) Killed() (bool, string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.killed, m.killReason
+}
+
+func (m *RiskManager) CheckOrders(orders []OrderRequest) error {
+	return m.reject(m.checkOrders(orders))
+}
+
+func (m *RiskManager) CheckModifies(modifies []ModifyRequest) error {
+	orders := make([]OrderRequest, len(modifies))
+	for i, modify := range modifies {
+		orders[i] = modify.OrderRequest
+	}
+	if err := m.checkKill(orders); err != nil {
+		return m.reject(err)
+	}
+	for _, order := range orders {
+		if err := m.checkOrder(order); err != nil {
+			return m.reject(err)
+		}
+	}
+	if err := m.checkPositions(orders); err != nil {
+		return m.reject(err)
+	}
+	return m.reject(m.checkRate(len(orders)))
+}
+
+// CheckTwap values the TWAP at the reference price of Prices, as it has no
+// limit price. Its slices do not rest, so the open order limit does not
+// apply.
+func (m *RiskManager) CheckTwap(req TwapRequest) error {
+	order := OrderRequest{Coin: req.Coin, IsBuy: req.IsBuy, Size: req.Size, ReduceOnly: req.ReduceOnly}
+	if err := m.checkKill([]OrderRequest{order}); err != nil {
+		return m.reject(err)
+	}
+	if m.cfg.Limits.MaxOrderNotional > 0 {
+		if m.cfg.Prices == nil {
+			return m.reject(RiskError{Rule: "maxOrderNotional", Coin: req.Coin, Message: "no price source to value the TWAP"})
+		}
+		px, err := m.cfg.Prices.ReferencePrice(req.Coin, req.IsBuy)
+		if err != nil {
+			return m.reject(fmt.Errorf("price of %s: %w", req.Coin, err))
+		}
+		if err := m.checkNotional(req.Coin, req.Size*px); err != nil {
+			return m.reject(err)
+		}
+	}
+	if err := m.checkPositions([]OrderRequest{order}); err != nil {
+		return m.reject(err)
+	}
+	return m.reject(m.checkRate(1))
+}
+
+func (m *RiskManager) checkOrders(orders []OrderRequest) error {
+	if err := m.checkKill(orders); err != nil {
+		return err
+	}
+	for _, order := range orders {
+		if err := m.checkOrder(order); err != nil {
+			return err
+		}
+	}
+	if err := m.checkPositions(orders); err != nil {
+		return err
+	}
+	if err := m.checkOpenOrders(orders); err != nil {
+		return err
+	}
+	return m.checkRate(len(orders))
+}
+
+func (m *RiskManager) reject(err error) error {
+	if err != nil && m.cfg.OnReject != nil {
+		m.cfg.OnReject(err)
+	}
+	return err
+}
+
+func (m *RiskManager) checkKill(orders []OrderRequest) error {
+	m.mu.Lock()
+	killed, reason := m.killed, m.killReason
+	m.mu.Unlock()
+	if !killed {
+		return nil
+	}
+	for _, order := range orders {
+		if !order.ReduceOnly {
+			return fmt.Errorf("%w: %s", ErrKillSwitch, reason)
+		}
+	}
+	return nil
+}
+
+// checkOrder applies the limits that look at a single order.
+func (m *RiskManager) checkOrder(order OrderRequest) error {
+	limits := m.cfg.Limits
+	if err := m.checkNotional(order.Coin, order.Size*order.LimitPx); err != nil {
+		return err
+	}
+	if limits.PriceCollar > 0 && m.cfg.Prices != nil && order.OrderType.Trigger == nil {
+		mid, err := m.cfg.Prices.ReferencePrice(order.Coin, order.IsBuy)
+		if err != nil {
+			return fmt.Errorf("price collar for %s: %w", order.Coin, err)
+		}
+		if mid > 0 && math.Abs(order.LimitPx-mid)/mid > limits.PriceCollar {
+			return RiskError{
+				Rule:    "priceCollar",
+				Coin:    order.Coin,
+				Message: fmt.Sprintf("price %v is more than %v away from mid %v", order.LimitPx, limits.PriceCollar, mid),
+			}
+		}
+	}
+	return nil
+}
+
+func (m *RiskManager) checkNotional(coin string, notional float64) error {
+	limit := m.cfg.Limits.MaxOrderNotional
+	if limit > 0 && notional > limit {
+		return RiskError{
+			Rule:    "maxOrderNotional",
+			Coin:    coin,
+			Message: fmt.Sprintf("notional %v exceeds %v", notional, limit),
+		}
+	}
+	return nil
+}
+
+// checkPositions projects the position of every coin as if all orders that
+// increase it filled.
+func (m *RiskManager) checkPositions(orders []OrderRequest) error {
+	limits := m.cfg.Limits
+	if m.cfg.Positions == nil || (limits.DefaultMaxPosition == 0 && len(limits.MaxPosition) == 0) {
+		return nil
+	}
+	deltas := make(map[string]float64)
+	for _, order := range orders {
+		if order.ReduceOnly {
+			continue
+		}
+		if order.IsBuy {
+			deltas[order.Coin] += order.Size
+		} else {
+			deltas[order.Coin] -= order.Size
+		}
+	}
+	for coin, delta := range deltas {
+		limit, ok := limits.MaxPosition[coin]
+		if !ok {
+			limit = limits.DefaultMaxPosition
+		}
+		if limit <= 0 {
+			continue
+		}
+		current, err := m.cfg.Positions.PositionSize(coin)
+		if err != nil {
+			return fmt.Errorf("position of %s: %w", coin, err)
+		}
+		if projected := current + delta; math.Abs(projected) > limit && math.Abs(projected) > math.Abs(current) {
+			return RiskError{
+				Rule:    "maxPosition",
+				Coin:    coin,
+				Message: fmt.Sprintf("position %v would exceed %v", projected, limit),
+			}
+		}
+	}
+	return nil
+}
+
+func (m *RiskManager) checkOpenOrders(orders []OrderRequest) error {
+	limit := m.cfg.Limits.MaxOpenOrders
+	if limit <= 0 || m.cfg.OpenOrders == nil {
+		return nil
+	}
+	resting := 0
+	for _, order := range orders {
+		if order.OrderType.Limit != nil && order.OrderType.Limit.Tif == TifIoc {
+			continue
+		}
+		resting++
+	}
+	if resting == 0 {
+		return nil
+	}
+	count, err := m.cfg.OpenOrders.OpenOrderCount()
+	if err != nil {
+		return fmt.Errorf("open orders: %w", err)
+	}
+	if count+resting > limit {
+		return RiskError{
+			Rule:    "maxOpenOrders",
+			Message: fmt.Sprintf("%d open orders plus %d new exceed %d", count, resting, limit),
+		}
+	}
+	return nil
+}
+
+// checkRate counts n submissions against the per-minute limit and records
+// them if they pass.
+func (m *RiskManager) checkRate(n int) error {
+	limit := m.cfg.Limits.MaxOrdersPerMinute
+	if limit <= 0 {
+		return nil
+	}
+	now := time.Now()
+	cutoff := now.Add(-time.Minute)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := 0
+	for i < len(m.sent) && !m.sent[i].After(cutoff) {
+		i++
+	}
+	m.sent = m.sent[i:]
+	if len(m.sent)+n > limit {
+		return RiskError{
+			Rule:    "maxOrdersPerMinute",
+			Message: fmt.Sprintf("%d orders in the last minute plus %d new exceed %d", len(m.sent), n, limit),
+		}
+	}
+	for range n {
+		m.sent = append(m.sent, now)
+	}
+	return nil
+}
+
+// AccountRiskSource is a PositionSource and OpenOrderSource backed by REST
+// queries. Every check costs a request, so prefer live sources such as the
+// tracker and oms packages when orders are frequent.
+type AccountRiskSource struct {
+	info AccountQuerier
+	user string
+}
+
+func NewAccountRiskSource(info AccountQuerier, user string) *AccountRiskSource {
+	return &AccountRiskSource{info: info, user: user}
+}
+
+func (s *AccountRiskSource) PositionSize(coin string) (float64, error) {
+	state, err := s.info.UserState(s.user)
+	if err != nil {
+		return 0, err
+	}
+	for _, ap := range state.AssetPositions {
+		if ap.Position.Coin == coin {
+			return strconv.ParseFloat(ap.Position.Szi, 64)
+		}
+	}
+	return 0, nil
+}
+
+func (s *AccountRiskSource) OpenOrderCount() (int, error) {
+	orders, err := s.info.OpenOrders(s.user)
+	if err != nil {
+		return 0, err
+	}
+	return len(orders), nil
+}
@@ -0,0 +1,120 @@
+package sdk_test
+
+import (
+	"errors"
+	"testing"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+type fixedPrices map[string]float64
+
+func (p fixedPrices) ReferencePrice(coin string, _ bool) (float64, error) {
+	return p[coin], nil
+}
+
+func (p fixedPrices) PositionSize(string) (float64, error) {
+	return 0, nil
+}
+
+func TestRiskManager(t *testing.T) {
+	prices := fixedPrices{"SOL": 100}
+	risk := sdk.NewRiskManager(sdk.RiskConfig{
+		Limits: sdk.RiskLimits{
+			MaxOrderNotional:   1000,
+			DefaultMaxPosition: 5,
+			PriceCollar:        0.1,
+			MaxOrdersPerMinute: 3,
+		},
+		Prices:    prices,
+		Positions: prices,
+	})
+	limit := sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifGtc}}
+
+	tests := []struct {
+		name  string
+		order sdk.OrderRequest
+		rule  string
+	}{
+		{"ok", sdk.OrderRequest{Coin: "SOL", IsBuy: true, Size: 1, LimitPx: 99, OrderType: limit}, ""},
+		{"notional", sdk.OrderRequest{Coin: "SOL", IsBuy: true, Size: 20, LimitPx: 99, OrderType: limit}, "maxOrderNotional"},
+		{"collar", sdk.OrderRequest{Coin: "SOL", IsBuy: true, Size: 1, LimitPx: 80, OrderType: limit}, "priceCollar"},
+		{"position", sdk.OrderRequest{Coin: "SOL", IsBuy: false, Size: 6, LimitPx: 101, OrderType: limit}, "maxPosition"},
+	}
+	for _, tt := range tests {
+		err := risk.CheckOrders([]sdk.OrderRequest{tt.order})
+		var riskErr sdk.RiskError
+		switch {
+		case tt.rule == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.rule != "" && (!errors.As(err, &riskErr) || riskErr.Rule != tt.rule):
+			t.Errorf("%s: expected %s violation, got %v", tt.name, tt.rule, err)
+		}
+	}
+
+	risk.Kill("test")
+	err := risk.CheckOrders([]sdk.OrderRequest{tests[0].order})
+	if !errors.Is(err, sdk.ErrKillSwitch) {
+		t.Errorf("expected kill switch error, got %v", err)
+	}
+	reduce := tests[0].order
+	reduce.ReduceOnly = true
+	if err := risk.CheckOrders([]sdk.OrderRequest{reduce}); err != nil {
+		t.Errorf("reduce-only order blocked by kill switch: %v", err)
+	}
+	risk.Reset()
+
+	// the first and the reduce-only order already count towards the rate
+	if err := risk.CheckOrders([]sdk.OrderRequest{tests[0].order}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	err = risk.CheckOrders([]sdk.OrderRequest{tests[0].order})
+	var riskErr sdk.RiskError
+	if !errors.As(err, &riskErr) || riskErr.Rule != "maxOrdersPerMinute" {
+		t.Errorf("expected rate violation, got %v", err)
+	}
+}
+
+func TestRiskManagerTwapAndModifies(t *testing.T) {
+	prices := fixedPrices{"SOL": 100}
+	risk := sdk.NewRiskManager(sdk.RiskConfig{
+		Limits:    sdk.RiskLimits{MaxOrderNotional: 1000, DefaultMaxPosition: 5},
+		Prices:    prices,
+		Positions: prices,
+	})
+	limit := sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifGtc}}
+
+	tests := []struct {
+		name  string
+		check func() error
+		rule  string
+	}{
+		{"twap", func() error {
+			return risk.CheckTwap(sdk.TwapRequest{Coin: "SOL", IsBuy: true, Size: 5, Minutes: 10})
+		}, ""},
+		{"twap notional", func() error {
+			return risk.CheckTwap(sdk.TwapRequest{Coin: "SOL", IsBuy: true, Size: 1000, Minutes: 10})
+		}, "maxOrderNotional"},
+		{"modify", func() error {
+			return risk.CheckModifies([]sdk.ModifyRequest{sdk.NewModifyByOid(1, sdk.OrderRequest{Coin: "SOL", IsBuy: true, Size: 5, LimitPx: 1, OrderType: limit})})
+		}, ""},
+		{"modify position", func() error {
+			return risk.CheckModifies([]sdk.ModifyRequest{sdk.NewModifyByOid(1, sdk.OrderRequest{Coin: "SOL", IsBuy: true, Size: 50, LimitPx: 1, OrderType: limit})})
+		}, "maxPosition"},
+	}
+	for _, tt := range tests {
+		err := tt.check()
+		var riskErr sdk.RiskError
+		switch {
+		case tt.rule == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.rule != "" && (!errors.As(err, &riskErr) || riskErr.Rule != tt.rule):
+			t.Errorf("%s: expected %s violation, got %v", tt.name, tt.rule, err)
+		}
+	}
+
+	unpriced := sdk.NewRiskManager(sdk.RiskConfig{Limits: sdk.RiskLimits{MaxOrderNotional: 1000}})
+	if err := unpriced.CheckTwap(sdk.TwapRequest{Coin: "SOL", IsBuy: true, Size: 1, Minutes: 10}); err == nil {
+		t.Error("expected a TWAP without a price source to be rejected under a notional limit")
+	}
+}
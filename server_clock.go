@@ -0,0 +1,113 @@
+package sdk
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// ServerClockConfig configures a ServerClock.
+type ServerClockConfig struct {
+	// Clock is the local clock. Defaults to SystemClock.
+	Clock Clock
+	// Samples is how many recent observations the offset is estimated from.
+	// Defaults to 32.
+	Samples int
+	// DriftThreshold is the offset above which OnDrift is called. Defaults
+	// to 2s.
+	DriftThreshold time.Duration
+	// OnDrift is called when the offset exceeds DriftThreshold, and again
+	// only after it came back within. Defaults to logging a warning.
+	OnDrift func(offset time.Duration)
+}
+
+// ServerClock is a Clock that follows the server time, estimated from the
+// timestamps of responses and messages. With WithServerClock, nonces are
+// generated from it, so they stay in the window the exchange accepts when
+// the local clock drifts.
+//
+// A server timestamp is taken before its message travels, so each sample
+// server-local underestimates the offset by the latency; the estimate is the
+// largest sample of the recent ones, that of the fastest message.
+type ServerClock struct {
+	cfg ServerClockConfig
+
+	mu       sync.Mutex
+	samples  []time.Duration // ring buffer
+	next     int
+	offset   time.Duration
+	drifting bool
+}
+
+func NewServerClock(cfg ServerClockConfig) *ServerClock {
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock
+	}
+	if cfg.Samples <= 0 {
+		cfg.Samples = 32
+	}
+	if cfg.DriftThreshold <= 0 {
+		cfg.DriftThreshold = 2 * time.Second
+	}
+	if cfg.OnDrift == nil {
+		cfg.OnDrift = func(offset time.Duration) {
+			log.Printf("local clock is %v off the server clock", -offset)
+		}
+	}
+	return &ServerClock{cfg: cfg, samples: make([]time.Duration, 0, cfg.Samples)}
+}
+
+// Now returns the local time corrected by the estimated offset.
+func (c *ServerClock) Now() time.Time {
+	return c.cfg.Clock.Now().Add(c.Offset())
+}
+
+// Offset returns the estimated server time minus the local time, zero until
+// a timestamp was observed.
+func (c *ServerClock) Offset() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.offset
+}
+
+// Observe records a server timestamp in milliseconds, received now. Zero
+// timestamps are ignored.
+func (c *ServerClock) Observe(serverMs int64) {
+	if serverMs <= 0 {
+		return
+	}
+	sample := time.UnixMilli(serverMs).Sub(c.cfg.Clock.Now())
+
+	c.mu.Lock()
+	if len(c.samples) < c.cfg.Samples {
+		c.samples = append(c.samples, sample)
+	} else {
+		c.samples[c.next] = sample
+	}
+	c.next = (c.next + 1) % c.cfg.Samples
+	c.offset = c.samples[0]
+	for _, s := range c.samples[1:] {
+		c.offset = max(c.offset, s)
+	}
+	offset := c.offset
+	drifting := offset > c.cfg.DriftThreshold || offset < -c.cfg.DriftThreshold
+	warn := drifting && !c.drifting
+	c.drifting = drifting
+	c.mu.Unlock()
+
+	if warn {
+		c.cfg.OnDrift(offset)
+	}
+}
+
+// HandleL2Book observes the time of an l2Book websocket message, see
+// SubscribeToOrderbook.
+func (c *ServerClock) HandleL2Book(msg WSMessage) {
+	var book struct {
+		Time int64 `json:"time"`
+	}
+	if err := json.Unmarshal(msg.Data, &book); err == nil {
+		c.Observe(book.Time)
+	}
+}
@@ -0,0 +1,76 @@
+package sdk_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+func TestServerClock(t *testing.T) {
+	srv, signer := newServer(t, hltest.Config{NonceWindow: time.Minute})
+
+	// the local clock is 10 minutes behind
+	local := sdk.NewManualClock(time.Now().Add(-10 * time.Minute))
+	skewed := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer, sdk.WithClock(local))
+	if err := skewed.UpdateLeverage("ETH", true, 5); err == nil || !strings.HasPrefix(err.Error(), "Invalid nonce:") {
+		t.Errorf("Expected a nonce outside of the window, got %v", err)
+	}
+
+	var drift time.Duration
+	clock := sdk.NewServerClock(sdk.ServerClockConfig{
+		Clock:   local,
+		OnDrift: func(offset time.Duration) { drift = offset },
+	})
+	info, err := sdk.NewInfo(srv.URL, sdk.WithInfoServerClock(clock))
+	if err != nil {
+		t.Fatalf("Failed to create info: %v", err)
+	}
+	if _, err := info.UserState(signer.Address().Hex()); err != nil {
+		t.Fatalf("Failed to get user state: %v", err)
+	}
+	if offset := clock.Offset(); offset < 9*time.Minute || offset > 11*time.Minute {
+		t.Errorf("Expected an offset of about 10m, got %v", offset)
+	}
+	if drift != clock.Offset() {
+		t.Errorf("Expected a drift warning of %v, got %v", clock.Offset(), drift)
+	}
+
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer, sdk.WithServerClock(clock))
+	if err := exchange.UpdateLeverage("ETH", true, 5); err != nil {
+		t.Errorf("Nonce from the server clock was rejected: %v", err)
+	}
+}
+
+func TestHeartbeatServerClock(t *testing.T) {
+	srv, signer := newServer(t, hltest.Config{})
+	// the deadline follows the exchange's clock, not the local one
+	clock := sdk.NewManualClock(time.Now().Add(time.Minute))
+	exchange := sdk.NewExchange(srv.URL, nil, srv.Meta(), signer, sdk.WithClock(clock))
+	hb, err := sdk.NewHeartbeat(exchange, sdk.HeartbeatConfig{Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hb.Start(context.Background()); err != nil {
+		t.Fatalf("Heartbeat start failed: %v", err)
+	}
+	hb.Stop()
+
+	var action struct {
+		Action sdk.ScheduleCancelAction `json:"action"`
+	}
+	requests := srv.Requests()
+	if len(requests) != 1 || json.Unmarshal(requests[0].Body, &action) != nil || action.Action.Time == nil {
+		t.Fatalf("Expected one scheduleCancel request, got %+v", requests)
+	}
+	if want := uint64(clock.Now().Add(10 * time.Second).UnixMilli()); *action.Action.Time != want {
+		t.Errorf("Expected the cancel at %d, got %d", want, *action.Action.Time)
+	}
+	if remaining := hb.Status().Remaining; remaining != 10*time.Second {
+		t.Errorf("Expected 10s left on the exchange's clock, got %v", remaining)
+	}
+}
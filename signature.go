@@ -54,14 +54,26 @@ type Signer interface {
 	Sign(msg []byte) ([]byte, error)
 }
 
+// TypedDataSigner is implemented by signers that want the typed data behind
+// a digest, e.g. to show what they sign for review. SignInner prefers it to
+// Sign.
+type TypedDataSigner interface {
+	SignTypedData(data apitypes.TypedData, digest []byte) ([]byte, error)
+}
+
 func SignInner(signer Signer, msg apitypes.TypedData) (*Signature, error) {
 	bytes, _, err := apitypes.TypedDataAndHash(msg)
 	if err != nil {
 		return nil, fmt.Errorf("SignInner hash on data: %v, err: %#v", msg, err)
 	}
-	sig, err := signer.Sign(bytes)
+	var sig []byte
+	if typed, ok := signer.(TypedDataSigner); ok {
+		sig, err = typed.SignTypedData(msg, bytes)
+	} else {
+		sig, err = signer.Sign(bytes)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("SignInner sign err on data: %v, err: %#v", msg, err)
+		return nil, fmt.Errorf("SignInner sign err on data: %v, err: %w", msg, err)
 	}
 	return NewSignature(sig)
 }
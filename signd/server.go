@@ -0,0 +1,184 @@
+// Package signd serves the remote signing protocol of sdk.RemoteSigner, so
+// that keys live on a signing host rather than on the trading hosts:
+//
+//	signer, _ := sdk.NewLocalSignerFromHex(key)
+//	srv := signd.New(signd.Config{Signer: signer, Secret: secret, Log: logFile})
+//	http.ListenAndServeTLS(":8710", cert, key, srv)
+//
+// Every request must carry the shared secret, if one is set, and is
+// written to the request log. A digest is only signed together with the
+// typed data it is the hash of, which is checked against it and can be
+// reviewed by Config.Approve. Only typed data of Hyperliquid actions is
+// signed unless Config.AnyDomain is set. cmd/hlsignd is a ready-made daemon.
+package signd
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+)
+
+// maxRequestSize bounds the body of a sign request.
+const maxRequestSize = 1 << 20
+
+// Config configures a Server.
+type Config struct {
+	Signer sdk.Signer
+	// Secret is the bearer token clients must send. Empty disables the
+	// check, e.g. when clients are authenticated by mutual TLS.
+	Secret string
+	// AllowDigest allows signing bare digests without their typed data.
+	AllowDigest bool
+	// AnyDomain allows signing typed data of any EIP-712 domain. By default
+	// only the domains of Hyperliquid actions are signed: "Exchange" with
+	// chain id 1337 for L1 actions and "HyperliquidSignTransaction" for
+	// user-signed actions.
+	AnyDomain bool
+	// Approve reviews a request before it is signed; an error refuses it.
+	Approve func(req *sdk.SignRequest) error
+	// Log receives a JSON line per request. Nil disables the log.
+	Log io.Writer
+}
+
+// LogEntry is a line of the request log.
+type LogEntry struct {
+	Time        time.Time                 `json:"time"`
+	Remote      string                    `json:"remote"`
+	Client      string                    `json:"client,omitempty"` // TLS client certificate subject
+	Path        string                    `json:"path"`
+	Status      int                       `json:"status"`
+	Digest      hexutil.Bytes             `json:"digest,omitempty"`
+	PrimaryType string                    `json:"primaryType,omitempty"`
+	Domain      *apitypes.TypedDataDomain `json:"domain,omitempty"`
+	Message     apitypes.TypedDataMessage `json:"message,omitempty"`
+	Error       string                    `json:"error,omitempty"`
+}
+
+type Server struct {
+	cfg Config
+
+	logMu sync.Mutex
+	log   *json.Encoder
+}
+
+func New(cfg Config) *Server {
+	s := &Server{cfg: cfg}
+	if cfg.Log != nil {
+		s.log = json.NewEncoder(cfg.Log)
+	}
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	entry := LogEntry{Time: time.Now().UTC(), Remote: r.RemoteAddr, Path: r.URL.Path}
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		entry.Client = r.TLS.PeerCertificates[0].Subject.String()
+	}
+	result, status, err := s.handle(r, &entry)
+	entry.Status = status
+	if err != nil {
+		entry.Error = err.Error()
+		http.Error(w, err.Error(), status)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(result)
+	}
+	s.writeLog(entry)
+}
+
+func (s *Server) handle(r *http.Request, entry *LogEntry) (any, int, error) {
+	if !s.authorized(r) {
+		return nil, http.StatusUnauthorized, errors.New("unauthorized")
+	}
+	switch {
+	case r.URL.Path == sdk.SignerAddressPath && r.Method == http.MethodGet:
+		return sdk.SignerAddress{Address: s.cfg.Signer.Address()}, http.StatusOK, nil
+	case r.URL.Path == sdk.SignerSignPath && r.Method == http.MethodPost:
+	default:
+		return nil, http.StatusNotFound, fmt.Errorf("no %s %s", r.Method, r.URL.Path)
+	}
+
+	var req sdk.SignRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestSize)).Decode(&req); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err)
+	}
+	entry.Digest = req.Digest
+	if req.TypedData != nil {
+		entry.PrimaryType = req.TypedData.PrimaryType
+		entry.Domain = &req.TypedData.Domain
+		entry.Message = req.TypedData.Message
+	}
+	if req.Address != s.cfg.Signer.Address() {
+		return nil, http.StatusBadRequest, fmt.Errorf("not signing for %s", req.Address.Hex())
+	}
+	if len(req.Digest) != 32 {
+		return nil, http.StatusBadRequest, fmt.Errorf("digest length is %d, expected 32", len(req.Digest))
+	}
+	if req.TypedData == nil {
+		if !s.cfg.AllowDigest {
+			return nil, http.StatusBadRequest, errors.New("typed data is required")
+		}
+	} else {
+		digest, _, err := apitypes.TypedDataAndHash(*req.TypedData)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid typed data: %w", err)
+		}
+		if !bytes.Equal(digest, req.Digest) {
+			return nil, http.StatusBadRequest, errors.New("digest does not match the typed data")
+		}
+		if !s.cfg.AnyDomain && !hyperliquidDomain(req.TypedData.Domain) {
+			return nil, http.StatusForbidden, fmt.Errorf("not signing for domain %q", req.TypedData.Domain.Name)
+		}
+	}
+	if s.cfg.Approve != nil {
+		if err := s.cfg.Approve(&req); err != nil {
+			return nil, http.StatusForbidden, err
+		}
+	}
+
+	sig, err := s.cfg.Signer.Sign(req.Digest)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return sdk.SignResponse{Signature: sig}, http.StatusOK, nil
+}
+
+// hyperliquidDomain reports whether domain is the one of L1 actions or of
+// user-signed actions, see sdk.SignL1Action and sdk.UserSignedPayload.
+func hyperliquidDomain(domain apitypes.TypedDataDomain) bool {
+	switch domain.Name {
+	case "Exchange":
+		return domain.ChainId != nil && (*big.Int)(domain.ChainId).Cmp(big.NewInt(1337)) == 0
+	case "HyperliquidSignTransaction":
+		return true
+	}
+	return false
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.cfg.Secret == "" {
+		return true
+	}
+	want := []byte("Bearer " + s.cfg.Secret)
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) == 1
+}
+
+func (s *Server) writeLog(entry LogEntry) {
+	if s.log == nil {
+		return
+	}
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	_ = s.log.Encode(entry)
+}
@@ -0,0 +1,145 @@
+package signd_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/signd"
+)
+
+func typedData(domain string) *apitypes.TypedData {
+	return &apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {{Name: "name", Type: "string"}, {Name: "chainId", Type: "uint256"}},
+			"Ping":         {{Name: "value", Type: "string"}},
+		},
+		PrimaryType: "Ping",
+		Domain:      apitypes.TypedDataDomain{Name: domain, ChainId: math.NewHexOrDecimal256(1337)},
+		Message:     apitypes.TypedDataMessage{"value": "pong"},
+	}
+}
+
+func TestServer(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := sdk.NewLocalSignerFromHex(hex.EncodeToString(crypto.FromECDSA(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := typedData("Exchange")
+	digest, _, err := apitypes.TypedDataAndHash(*data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := typedData("Other")
+	otherDigest, _, err := apitypes.TypedDataAndHash(*other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	approve := func(req *sdk.SignRequest) error {
+		if req.TypedData != nil && req.TypedData.Message["value"] == "refuse" {
+			return errors.New("refused")
+		}
+		return nil
+	}
+	refused := typedData("Exchange")
+	refused.Message["value"] = "refuse"
+	refusedDigest, _, err := apitypes.TypedDataAndHash(*refused)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		req    sdk.SignRequest
+		status int
+		err    string
+	}{
+		{"signs typed data", "s3cret", sdk.SignRequest{Address: signer.Address(), Digest: digest, TypedData: data}, http.StatusOK, ""},
+		{"wrong secret", "wrong", sdk.SignRequest{Address: signer.Address(), Digest: digest, TypedData: data}, http.StatusUnauthorized, "unauthorized"},
+		{"other address", "s3cret", sdk.SignRequest{Address: common.Address{1}, Digest: digest, TypedData: data}, http.StatusBadRequest, "not signing for"},
+		{"bare digest", "s3cret", sdk.SignRequest{Address: signer.Address(), Digest: digest}, http.StatusBadRequest, "typed data is required"},
+		{"digest mismatch", "s3cret", sdk.SignRequest{Address: signer.Address(), Digest: make([]byte, 32), TypedData: data}, http.StatusBadRequest, "does not match"},
+		{"not approved", "s3cret", sdk.SignRequest{Address: signer.Address(), Digest: refusedDigest, TypedData: refused}, http.StatusForbidden, "refused"},
+		{"other domain", "s3cret", sdk.SignRequest{Address: signer.Address(), Digest: otherDigest, TypedData: other}, http.StatusForbidden, "not signing for domain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requestLog bytes.Buffer
+			srv := signd.New(signd.Config{Signer: signer, Secret: "s3cret", Approve: approve, Log: &requestLog})
+			body, err := json.Marshal(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := httptest.NewRequest(http.MethodPost, sdk.SignerSignPath, bytes.NewReader(body))
+			r.Header.Set("Authorization", "Bearer "+tt.secret)
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body)
+			}
+			if tt.err != "" && !strings.Contains(w.Body.String(), tt.err) {
+				t.Errorf("Expected error %q, got %q", tt.err, w.Body)
+			}
+			var entry signd.LogEntry
+			if err := json.Unmarshal(requestLog.Bytes(), &entry); err != nil {
+				t.Fatalf("Invalid log line %q: %v", requestLog.String(), err)
+			}
+			if entry.Status != tt.status {
+				t.Errorf("Expected logged status %d, got %d", tt.status, entry.Status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var resp sdk.SignResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			pub, err := crypto.SigToPub(digest, resp.Signature)
+			if err != nil || crypto.PubkeyToAddress(*pub) != signer.Address() {
+				t.Errorf("Signature does not recover to %s: %v", signer.Address(), err)
+			}
+		})
+	}
+}
+
+func TestServerAnyDomain(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := sdk.NewLocalSignerFromHex(hex.EncodeToString(crypto.FromECDSA(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := typedData("Other")
+	digest, _, err := apitypes.TypedDataAndHash(*data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(sdk.SignRequest{Address: signer.Address(), Digest: digest, TypedData: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := signd.New(signd.Config{Signer: signer, AnyDomain: true})
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, sdk.SignerSignPath, bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected any domain to be signed, got %d: %s", w.Code, w.Body)
+	}
+}
@@ -160,3 +160,52 @@ func UserSignedPayload(primaryType string, payloadTypes []apitypes.Type, action
 		Message:     action,
 	}, nil
 }
+
+// RecoverL1ActionSigner returns the address that signed an L1 action, the
+// inverse of SignL1Action. A wrong action, vault or nonce recovers a
+// different address rather than failing, as on the exchange.
+func RecoverL1ActionSigner(
+	action any,
+	vault *common.Address,
+	nonce uint64,
+	isMainnet bool,
+	sig *Signature,
+) (common.Address, error) {
+	hash, err := actionHash(action, vault, nonce)
+	if err != nil {
+		return common.Address{}, err
+	}
+	phantomAgent := constructPhantomAgent(hash, isMainnet)
+	return recoverSigner(l1Payload(phantomAgent), sig)
+}
+
+// RecoverUserSignedActionSigner returns the address that signed a user-signed
+// action, the inverse of SignUserSignedAction.
+func RecoverUserSignedActionSigner(
+	action apitypes.TypedDataMessage,
+	payloadTypes []apitypes.Type,
+	primaryType string,
+	sig *Signature,
+) (common.Address, error) {
+	payload, err := UserSignedPayload(primaryType, payloadTypes, action)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return recoverSigner(*payload, sig)
+}
+
+func recoverSigner(msg apitypes.TypedData, sig *Signature) (common.Address, error) {
+	hash, _, err := apitypes.TypedDataAndHash(msg)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("recover signer hash on data: %w", err)
+	}
+	raw, err := sig.Encode()
+	if err != nil {
+		return common.Address{}, err
+	}
+	pub, err := crypto.SigToPub(hash, raw)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("recover signer: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
@@ -0,0 +1,33 @@
+package sdk_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+)
+
+func newSigner(t *testing.T) *sdk.LocalSigner {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := sdk.NewLocalSignerFromHex(hex.EncodeToString(crypto.FromECDSA(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// newServer starts an hltest server with a funded signer.
+func newServer(t *testing.T, cfg hltest.Config) (*hltest.Server, *sdk.LocalSigner) {
+	t.Helper()
+	srv := hltest.NewServer(cfg)
+	t.Cleanup(srv.Close)
+	signer := newSigner(t)
+	srv.Fund(signer.Address(), 10_000)
+	return srv, signer
+}
@@ -0,0 +1,175 @@
+package tracker_test
+
+import (
+	"context"
+	"encoding/hex"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	sdk "github.com/funcblock-quant/hyperliquid-go-sdk"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/hltest"
+	"github.com/funcblock-quant/hyperliquid-go-sdk/tracker"
+)
+
+func newAccount(t *testing.T) (*hltest.Server, *sdk.Exchange, *sdk.Info) {
+	t.Helper()
+	srv := hltest.NewServer(hltest.Config{})
+	t.Cleanup(srv.Close)
+	key, _ := crypto.GenerateKey()
+	signer, err := sdk.NewLocalSignerFromHex(hex.EncodeToString(crypto.FromECDSA(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Fund(signer.Address(), 10_000)
+	info, err := sdk.NewInfo(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, sdk.NewExchange(srv.URL, nil, srv.Meta(), signer), info
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestTrackerPnl(t *testing.T) {
+	_, exchange, info := newAccount(t)
+	tr := tracker.New(info, nil, tracker.Config{User: exchange.AccountAddress().Hex()})
+	if err := tr.Reconcile(); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	now := time.Now().UnixMilli() + 1_000
+	fill := func(tid int64, side string, px, sz, start, closedPnl string) sdk.WsFill {
+		return sdk.WsFill{
+			Coin: "SOL", Side: side, Px: px, Sz: sz, StartPosition: start,
+			ClosedPnl: closedPnl, Fee: "0.1", FeeToken: "USDC", Tid: tid, Time: now + tid,
+		}
+	}
+	steps := []struct {
+		name         string
+		fill         sdk.WsFill
+		mid          string
+		szi, entryPx float64
+		unrealized   float64
+		realized     float64
+		accountValue float64
+	}{
+		{"open", fill(1, sdk.SideBid, "100", "1", "0", "0"), "100", 1, 100, 0, 0, 9_999.9},
+		{"add", fill(2, sdk.SideBid, "110", "1", "1", "0"), "120", 2, 105, 30, 0, 10_029.8},
+		{"flip", fill(3, sdk.SideAsk, "120", "3", "2", "30"), "110", -1, 120, 10, 30, 10_039.7},
+		{"close", fill(4, sdk.SideBid, "100", "1", "-1", "20"), "100", 0, 0, 0, 50, 10_049.6},
+	}
+	for _, step := range steps {
+		tr.ApplyFills([]sdk.WsFill{step.fill})
+		tr.ApplyMids(map[string]string{"SOL": step.mid})
+		pos, _ := tr.PositionSize("SOL")
+		account := tr.Account()
+		if !near(pos, step.szi) {
+			t.Errorf("%s: expected size %v, got %v", step.name, step.szi, pos)
+		}
+		if p, ok := tr.Position("SOL"); ok && (!near(p.EntryPx, step.entryPx) || !near(p.UnrealizedPnl, step.unrealized)) {
+			t.Errorf("%s: expected entry %v and unrealized %v, got %+v", step.name, step.entryPx, step.unrealized, p)
+		}
+		if !near(account.UnrealizedPnl, step.unrealized) || !near(account.RealizedPnl, step.realized) {
+			t.Errorf("%s: expected unrealized %v and realized %v, got %+v", step.name, step.unrealized, step.realized, account)
+		}
+		if !near(account.AccountValue, step.accountValue) {
+			t.Errorf("%s: expected account value %v, got %v", step.name, step.accountValue, account.AccountValue)
+		}
+	}
+
+	// replayed and pre-snapshot fills are skipped
+	tr.ApplyFills([]sdk.WsFill{fill(4, sdk.SideBid, "100", "1", "-1", "20")})
+	stale := fill(5, sdk.SideBid, "100", "1", "0", "0")
+	stale.Time = 1
+	tr.ApplyFills([]sdk.WsFill{stale})
+	if pos, _ := tr.PositionSize("SOL"); pos != 0 {
+		t.Errorf("Expected replayed fills to be skipped, got size %v", pos)
+	}
+	if fees := tr.Account().Fees; !near(fees, 0.4) {
+		t.Errorf("Expected fees of 0.4, got %v", fees)
+	}
+
+	funding := sdk.WsUserFunding{Coin: "SOL", Usdc: "-1.5", Time: now + 10}
+	tr.ApplyFundings([]sdk.WsUserFunding{funding, funding})
+	if account := tr.Account(); !near(account.Funding, -1.5) || !near(account.AccountValue, 10_048.1) {
+		t.Errorf("Expected funding to be paid once, got %+v", account)
+	}
+}
+
+func TestTrackerReconcileDrift(t *testing.T) {
+	srv, exchange, info := newAccount(t)
+	srv.SetBook("SOL", []sdk.Level{{Px: 149, Sz: 100}}, []sdk.Level{{Px: 151, Sz: 100}})
+	type drift struct{ tracked, actual float64 }
+	var drifts []drift
+	tr := tracker.New(info, nil, tracker.Config{
+		User:    exchange.AccountAddress().Hex(),
+		OnDrift: func(coin string, tracked, actual float64) { drifts = append(drifts, drift{tracked, actual}) },
+	})
+	if err := tr.Reconcile(); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	// the fill never reaches the tracker
+	if _, err := exchange.Order(sdk.OrderRequest{
+		Coin: "SOL", IsBuy: true, Size: 2, LimitPx: 152,
+		OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifIoc}},
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Reconcile(); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if len(drifts) != 1 || drifts[0] != (drift{0, 2}) {
+		t.Errorf("Expected a drift from 0 to 2, got %+v", drifts)
+	}
+	pos, ok := tr.Position("SOL")
+	if !ok || pos.Szi != 2 || pos.EntryPx != 151 {
+		t.Errorf("Expected the position from the snapshot, got %+v", pos)
+	}
+}
+
+func TestTrackerStreams(t *testing.T) {
+	srv, exchange, info := newAccount(t)
+	srv.SetBook("SOL", []sdk.Level{{Px: 149, Sz: 100}}, []sdk.Level{{Px: 151, Sz: 100}})
+	ws := sdk.NewWebsocketClient(srv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+
+	tr := tracker.New(info, ws, tracker.Config{User: exchange.AccountAddress().Hex(), ReconcileInterval: -1})
+	if err := tr.Start(ctx); err != nil {
+		t.Fatalf("Failed to start tracker: %v", err)
+	}
+	defer tr.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := exchange.Order(sdk.OrderRequest{
+		Coin: "SOL", IsBuy: true, Size: 1, LimitPx: 152,
+		OrderType: sdk.OrderType{Limit: &sdk.LimitOrderType{Tif: sdk.TifIoc}},
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetBook("SOL", []sdk.Level{{Px: 159, Sz: 100}}, []sdk.Level{{Px: 161, Sz: 100}})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		pos, ok := tr.Position("SOL")
+		if ok && pos.Szi == 1 && pos.EntryPx == 151 && pos.MarkPx == 160 {
+			if !near(pos.UnrealizedPnl, 9) {
+				t.Errorf("Expected unrealized PnL of 9, got %+v", pos)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Fill and mids not applied, got %+v", pos)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
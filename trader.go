@@ -0,0 +1,127 @@
+package sdk
+
+import "github.com/ethereum/go-ethereum/common"
+
+// The interfaces below cover the trading and query surfaces of Exchange and
+// Info, so that code using them can be given mocks, recorders, decorators
+// such as risk wrappers, or the simulated exchange of the paper package.
+// Consumers should ask for the smallest interface they need.
+
+// OrderPlacer places orders. See Exchange for the statuses returned.
+type OrderPlacer interface {
+	Order(req OrderRequest, builder *BuilderInfo) (any, error)
+	BulkOrders(orders []OrderRequest, builder *BuilderInfo) ([]any, error)
+	BulkOrdersWithGrouping(orders []OrderRequest, grouping string, builder *BuilderInfo) ([]any, error)
+	MarketOrder(req MarketRequest, builder *BuilderInfo) (any, error)
+	BulkMarketOrders(reqs []MarketRequest, builder *BuilderInfo) ([]any, error)
+}
+
+// OrderCanceler cancels orders by oid or cloid.
+type OrderCanceler interface {
+	Cancel(req CancelRequest) (any, error)
+	CancelByCloid(req CancelByCloidRequest) (any, error)
+	BulkCancel(reqs []CancelRequest) ([]any, error)
+	BulkCancelByCloid(reqs []CancelByCloidRequest) ([]any, error)
+}
+
+// OrderModifier modifies resting orders.
+type OrderModifier interface {
+	ModifyOrder(req ModifyRequest) (any, error)
+	BulkModifyOrders(reqs []ModifyRequest) ([]any, error)
+}
+
+// Rounder rounds prices and sizes to what the exchange accepts for a coin.
+type Rounder interface {
+	RoundPrice(coin string, px float64) (float64, error)
+	RoundSize(coin string, size float64) (float64, error)
+}
+
+// Trader is the order surface of an exchange: placing, canceling and
+// modifying orders, with results of the same types as Exchange returns. It is
+// implemented by Exchange and by the paper package's simulated exchange, so a
+// strategy written against it can be dry-run on live data.
+type Trader interface {
+	AccountAddress() common.Address
+	OrderPlacer
+	OrderCanceler
+	OrderModifier
+	Rounder
+}
+
+// AccountManager changes the leverage and margin of positions and moves funds.
+type AccountManager interface {
+	UpdateLeverage(coin string, isCross bool, leverage int) error
+	UpdateIsolatedMargin(coin string, amount float64) error
+	VaultUsdTransfer(isDeposit bool, vaultAddress string, amount int) (*ExchangeRequest, error)
+}
+
+// Flattener cancels everything and closes positions, see CancelAll.
+type Flattener interface {
+	CancelAll(filter CancelAllFilter) (*CancelAllResult, error)
+	CloseAllPositions(slippage float64) (*CloseAllResult, error)
+	MarketClose(coin string, slippage float64, builder *BuilderInfo) (any, error)
+}
+
+// ActionPoster signs and posts arbitrary actions. The helpers of the
+// exchange_api package are built on it.
+type ActionPoster interface {
+	NonceSource
+	Signer() Signer
+	PostActionAndParseResponse(action Action, signature *Signature, nonce uint64, opts ...PostOption) (string, []any, error)
+}
+
+// ExchangeAPI is the full trading surface of Exchange.
+type ExchangeAPI interface {
+	Trader
+	AccountManager
+	Flattener
+	ActionPoster
+
+	VaultAddress() *common.Address
+	AmendOrder(req ModifyRequest, builder *BuilderInfo) (*AmendResult, error)
+	BracketOrder(req BracketRequest, builder *BuilderInfo) (*BracketResult, error)
+	PositionTpsl(req PositionTpslRequest, builder *BuilderInfo) (*BracketResult, error)
+	ScheduleCancel(t *uint64) error
+	TwapOrder(req TwapRequest) (TwapID, error)
+	TwapCancel(coin string, twapID TwapID) error
+}
+
+// MetaQuerier queries the perp and spot universes.
+type MetaQuerier interface {
+	Meta() (*Meta, error)
+	SpotMeta() (*SpotMeta, error)
+}
+
+// MarketQuerier queries market data.
+type MarketQuerier interface {
+	AllMids() (map[string]string, error)
+	L2Snapshot(coin string) (*L2Book, error)
+	CandlesSnapshot(coin, interval string, startTime, endTime int64) ([]Candle, error)
+	FundingHistory(coin string, startTime int64, endTime *int64) ([]FundingHistory, error)
+}
+
+// AccountQuerier queries the state, orders and fills of an account.
+type AccountQuerier interface {
+	UserState(address string) (*UserState, error)
+	SpotUserState(address string) (*SpotState, error)
+	OpenOrders(address string) ([]OpenOrder, error)
+	FrontendOpenOrders(address string) ([]FrontendOpenOrder, error)
+	QueryOrderByOid(user string, oid int64) (*OpenOrder, error)
+	QueryOrderByCloid(user string, cloid Cloid) (*OpenOrder, error)
+	UserFills(address string) ([]Fill, error)
+	UserFillsByTime(address string, startTime int64, endTime *int64) ([]Fill, error)
+	UserFundingHistory(user string, startTime int64, endTime *int64) ([]UserFundingHistory, error)
+	UserFees(address string) (*UserFees, error)
+}
+
+// Querier is the query surface of Info used for trading.
+type Querier interface {
+	MetaQuerier
+	MarketQuerier
+	AccountQuerier
+}
+
+var (
+	_ ExchangeAPI = (*Exchange)(nil)
+	_ Querier     = (*Info)(nil)
+)
@@ -14,10 +14,17 @@ import (
 )
 
 const (
+	SubTypeAllMids      = "allMids"
 	SubTypeTrades       = "trades"
 	SubTypeL2Book       = "l2Book"
 	SubTypeUserFills    = "userFills"
 	SubTypeOrderUpdates = "orderUpdates"
+
+	SubTypeUserTwapSliceFills = "userTwapSliceFills"
+	SubTypeUserTwapHistory    = "userTwapHistory"
+
+	SubTypeUserFundings                = "userFundings"
+	SubTypeUserNonFundingLedgerUpdates = "userNonFundingLedgerUpdates"
 )
 
 type WebsocketClient struct {
@@ -29,6 +36,7 @@ type WebsocketClient struct {
 	nextSubID     atomic.Int32
 	done          chan struct{}
 	reconnectWait time.Duration
+	lastMessage   atomic.Int64 // unix ms of the last frame read
 }
 
 func NewWebsocketClient(baseURL string) *WebsocketClient {
@@ -39,7 +47,12 @@ func NewWebsocketClient(baseURL string) *WebsocketClient {
 	if err != nil {
 		log.Fatalf("invalid URL: %v", err)
 	}
-	parsedURL.Scheme = "wss"
+	// plain http, e.g. a local test server, is served over ws
+	if parsedURL.Scheme == "http" {
+		parsedURL.Scheme = "ws"
+	} else {
+		parsedURL.Scheme = "wss"
+	}
 	parsedURL.Path = "/ws"
 	wsURL := parsedURL.String()
 
@@ -128,6 +141,23 @@ func (w *WebsocketClient) Unsubscribe(sub Subscription, id int) error {
 	return nil
 }
 
+// IsConnected reports whether the client currently holds an open connection.
+func (w *WebsocketClient) IsConnected() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.conn != nil
+}
+
+// LastMessageTime returns the time the last frame was received, or the zero
+// time if nothing has been received yet.
+func (w *WebsocketClient) LastMessageTime() time.Time {
+	ms := w.lastMessage.Load()
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
 func (w *WebsocketClient) Close() error {
 	close(w.done)
 
@@ -167,6 +197,7 @@ func (w *WebsocketClient) readPump(ctx context.Context) {
 				}
 				return
 			}
+			w.lastMessage.Store(time.Now().UnixMilli())
 
 			if string(msg) == "Websocket connection established." {
 				continue
@@ -283,6 +314,11 @@ func (w *WebsocketClient) writeJSON(v any) error {
 	return w.conn.WriteJSON(v)
 }
 
+func (w *WebsocketClient) SubscribeToAllMids(callback func(WSMessage)) (int, error) {
+	sub := Subscription{Type: SubTypeAllMids}
+	return w.subscribe(sub, callback)
+}
+
 func (w *WebsocketClient) SubscribeToTrades(coin string, callback func(WSMessage)) (int, error) {
 	sub := Subscription{Type: SubTypeTrades, Coin: coin}
 	return w.subscribe(sub, callback)
@@ -303,6 +339,26 @@ func (w *WebsocketClient) SubscribeToOrderUpdates(user string, callback func(WSM
 	return w.subscribe(sub, callback)
 }
 
+func (w *WebsocketClient) SubscribeToUserTwapSliceFills(user string, callback func(WSMessage)) (int, error) {
+	sub := Subscription{Type: SubTypeUserTwapSliceFills, User: user}
+	return w.subscribe(sub, callback)
+}
+
+func (w *WebsocketClient) SubscribeToUserTwapHistory(user string, callback func(WSMessage)) (int, error) {
+	sub := Subscription{Type: SubTypeUserTwapHistory, User: user}
+	return w.subscribe(sub, callback)
+}
+
+func (w *WebsocketClient) SubscribeToUserFundings(user string, callback func(WSMessage)) (int, error) {
+	sub := Subscription{Type: SubTypeUserFundings, User: user}
+	return w.subscribe(sub, callback)
+}
+
+func (w *WebsocketClient) SubscribeToUserNonFundingLedgerUpdates(user string, callback func(WSMessage)) (int, error) {
+	sub := Subscription{Type: SubTypeUserNonFundingLedgerUpdates, User: user}
+	return w.subscribe(sub, callback)
+}
+
 func (w *WebsocketClient) subscribe(sub Subscription, callback func(WSMessage)) (int, error) {
 	if callback == nil {
 		return 0, fmt.Errorf("callback cannot be nil")
@@ -333,6 +389,8 @@ func (w *WebsocketClient) subscribe(sub Subscription, callback func(WSMessage))
 
 func matchSubscription(key subKey, msg WSMessage) bool {
 	switch key.typ {
+	case SubTypeAllMids:
+		return msg.Channel == SubTypeAllMids
 	case SubTypeL2Book:
 		return msg.Channel == SubTypeL2Book
 	case SubTypeTrades:
@@ -341,6 +399,14 @@ func matchSubscription(key subKey, msg WSMessage) bool {
 		return msg.Channel == SubTypeUserFills
 	case SubTypeOrderUpdates:
 		return msg.Channel == SubTypeOrderUpdates
+	case SubTypeUserTwapSliceFills:
+		return msg.Channel == SubTypeUserTwapSliceFills
+	case SubTypeUserTwapHistory:
+		return msg.Channel == SubTypeUserTwapHistory
+	case SubTypeUserFundings:
+		return msg.Channel == SubTypeUserFundings
+	case SubTypeUserNonFundingLedgerUpdates:
+		return msg.Channel == SubTypeUserNonFundingLedgerUpdates
 	default:
 		return false
 	}
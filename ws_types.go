@@ -42,6 +42,11 @@ type subscriptionCallback struct {
 	callback func(WSMessage)
 }
 
+// WsAllMids represents mid prices of all coins from WebSocket
+type WsAllMids struct {
+	Mids map[string]string `json:"mids"`
+}
+
 // WsUserFills represents user fills data from WebSocket
 type WsUserFills struct {
 	IsSnapshot *bool    `json:"isSnapshot,omitempty"`
@@ -67,6 +72,7 @@ type WsFill struct {
 	Liquidation   *FillLiquidation `json:"liquidation,omitempty"`
 	FeeToken      string           `json:"feeToken"`             // the token the fee was paid in
 	BuilderFee    *string          `json:"builderFee,omitempty"` // amount paid to builder, also included in fee
+	Cloid         *Cloid           `json:"cloid,omitempty"`
 }
 
 // FillLiquidation represents liquidation information for a fill
@@ -85,12 +91,77 @@ type WsOrder struct {
 
 // WsBasicOrder represents basic order information
 type WsBasicOrder struct {
-	Coin      string  `json:"coin"`
-	Side      string  `json:"side"`
-	LimitPx   string  `json:"limitPx"`
-	Sz        string  `json:"sz"`
-	Oid       int64   `json:"oid"`
-	Timestamp int64   `json:"timestamp"`
-	OrigSz    string  `json:"origSz"`
-	Cloid     *string `json:"cloid,omitempty"`
+	Coin      string `json:"coin"`
+	Side      string `json:"side"`
+	LimitPx   string `json:"limitPx"`
+	Sz        string `json:"sz"`
+	Oid       int64  `json:"oid"`
+	Timestamp int64  `json:"timestamp"`
+	OrigSz    string `json:"origSz"`
+	Cloid     *Cloid `json:"cloid,omitempty"`
+}
+
+// WsUserTwapSliceFills represents TWAP slice fills data from WebSocket
+type WsUserTwapSliceFills struct {
+	IsSnapshot     *bool             `json:"isSnapshot,omitempty"`
+	User           string            `json:"user"`
+	TwapSliceFills []WsTwapSliceFill `json:"twapSliceFills"`
+}
+
+// WsTwapSliceFill represents a single fill executed by a TWAP slice
+type WsTwapSliceFill struct {
+	Fill   WsFill `json:"fill"`
+	TwapID TwapID `json:"twapId"`
+}
+
+// WsUserTwapHistory represents TWAP history data from WebSocket
+type WsUserTwapHistory struct {
+	IsSnapshot *bool         `json:"isSnapshot,omitempty"`
+	User       string        `json:"user"`
+	History    []TwapHistory `json:"history"`
+}
+
+// WsUserFundings represents funding payments from WebSocket
+type WsUserFundings struct {
+	IsSnapshot *bool           `json:"isSnapshot,omitempty"`
+	User       string          `json:"user"`
+	Fundings   []WsUserFunding `json:"fundings"`
+}
+
+// WsUserFunding represents a single funding payment, negative usdc means paid
+type WsUserFunding struct {
+	Time        int64  `json:"time"`
+	Coin        string `json:"coin"`
+	Usdc        string `json:"usdc"`
+	Szi         string `json:"szi"`
+	FundingRate string `json:"fundingRate"`
+}
+
+// WsUserNonFundingLedgerUpdates represents ledger updates other than funding from WebSocket
+type WsUserNonFundingLedgerUpdates struct {
+	IsSnapshot              *bool            `json:"isSnapshot,omitempty"`
+	User                    string           `json:"user"`
+	NonFundingLedgerUpdates []WsLedgerUpdate `json:"nonFundingLedgerUpdates"`
+}
+
+// WsLedgerUpdate represents a single ledger update
+type WsLedgerUpdate struct {
+	Time  int64         `json:"time"`
+	Hash  string        `json:"hash"`
+	Delta WsLedgerDelta `json:"delta"`
+}
+
+// WsLedgerDelta holds the fields of all ledger update types; only those of
+// Type are set. Possible types: deposit, withdraw, accountClassTransfer,
+// internalTransfer, subAccountTransfer, spotTransfer, liquidation,
+// vaultCreate, vaultDeposit, vaultWithdraw, vaultDistribution, rewardsClaim
+type WsLedgerDelta struct {
+	Type        string `json:"type"`
+	Usdc        string `json:"usdc,omitempty"`
+	ToPerp      *bool  `json:"toPerp,omitempty"`      // accountClassTransfer
+	User        string `json:"user,omitempty"`        // sender of transfers
+	Destination string `json:"destination,omitempty"` // receiver of transfers
+	Fee         string `json:"fee,omitempty"`
+	Token       string `json:"token,omitempty"`  // spotTransfer
+	Amount      string `json:"amount,omitempty"` // spotTransfer
 }